@@ -0,0 +1,335 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyFunc is called by Apply for each node, with the cursor of the node
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses the tree of the root in the order of Walk, calling pre for each
+// node before its children and post after them, when they are not nil. The nodes
+// can be replaced, deleted or inserted with the cursor; the children of a node
+// replaced by pre are those of the new node.
+//
+// If pre returns false, the children of the node are skipped, and post is not
+// called for the node. If post returns false, the traversal stops. Apply returns
+// the root, which may have been replaced.
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	parent := &applyRoot{Node: root}
+	defer func() {
+		if r := recover(); r != nil && r != errAbort {
+			panic(r)
+		}
+		result = parent.Node
+	}()
+	a := &application{pre: pre, post: post}
+	a.apply(parent, "Node", func(n Node) { parent.Node = n }, root)
+	return parent.Node
+}
+
+// applyRoot is the parent of the root of Apply
+type applyRoot struct{ Node }
+
+var errAbort = new(int) // the sentinel of the traversal stopped by post
+
+// Cursor describes a node of Apply: its parent, and the field of the parent
+// holding it
+type Cursor struct {
+	parent Node
+	name   string
+	node   Node
+	set    func(Node) // replaces the node in its field, nil in a list
+	del    func()     // deletes the entry of a hash or set literal, nil otherwise
+	iter   *iterator  // the list holding the node, nil if it is not in a list
+}
+
+// iterator is the position of Apply in a list, e.g. the statements of a block
+type iterator struct {
+	list  reflect.Value // the slice field of the parent
+	index int
+	step  int // the number of nodes to advance by after the current one
+}
+
+// Node returns the current node
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the parent of the current node, nil for the root
+func (c *Cursor) Parent() Node {
+	if _, ok := c.parent.(*applyRoot); ok {
+		return nil
+	}
+	return c.parent
+}
+
+// Name returns the name of the field of the parent holding the current node, e.g.
+// "Left" or "Statements". The keys and values of hash literals are "Key" and "Value".
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns the index of the current node in its list, or -1 if it is not in
+// a list
+func (c *Cursor) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+// Replace replaces the current node. It panics if the node cannot be held by the
+// field, e.g. a statement in place of an expression.
+func (c *Cursor) Replace(n Node) {
+	if c.iter != nil {
+		c.iter.list.Index(c.iter.index).Set(c.elem(n))
+	} else {
+		c.set(n)
+	}
+	c.node = n
+}
+
+// Delete deletes the current node from its list, or the entry of a hash or set
+// literal. It panics for the other nodes.
+func (c *Cursor) Delete() {
+	switch {
+	case c.iter != nil:
+		list, i := c.iter.list, c.iter.index
+		l := list.Len()
+		reflect.Copy(list.Slice(i, l), list.Slice(i+1, l))
+		list.Index(l - 1).Set(reflect.Zero(list.Type().Elem()))
+		list.SetLen(l - 1)
+		c.iter.step--
+	case c.del != nil:
+		c.del()
+	default:
+		panic("ast: Delete of a node not in a list, hash or set")
+	}
+}
+
+// InsertBefore inserts the node before the current one in its list. The inserted
+// node is not traversed. It panics if the current node is not in a list.
+func (c *Cursor) InsertBefore(n Node) {
+	c.insert(c.Index(), n)
+	c.iter.index++
+}
+
+// InsertAfter inserts the node after the current one in its list. The inserted
+// node is not traversed. It panics if the current node is not in a list.
+func (c *Cursor) InsertAfter(n Node) {
+	c.insert(c.Index()+1, n)
+	c.iter.step++
+}
+
+func (c *Cursor) insert(i int, n Node) {
+	if c.iter == nil {
+		panic("ast: Insert of a node not in a list")
+	}
+	list := c.iter.list
+	l := list.Len()
+	list.Set(reflect.Append(list, reflect.Zero(list.Type().Elem())))
+	reflect.Copy(list.Slice(i+1, l+1), list.Slice(i, l))
+	list.Index(i).Set(c.elem(n))
+}
+
+// elem returns the value of the node as an element of the list
+func (c *Cursor) elem(n Node) reflect.Value {
+	typ := c.iter.list.Type().Elem()
+	if n == nil {
+		return reflect.Zero(typ)
+	}
+	v := reflect.ValueOf(n)
+	if typ.Kind() == reflect.Struct { // e.g. the cases of a match, held by value
+		v = v.Elem()
+	}
+	if !v.Type().AssignableTo(typ) {
+		panic(fmt.Sprintf("ast: cannot use %T in %s", n, c.name))
+	}
+	return v
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent Node, name string, set func(Node), n Node) {
+	a.applyCursor(Cursor{parent: parent, name: name, node: n, set: set})
+}
+
+// applyEntry applies the key or value of a hash literal, or the element of a set
+// literal, which can be deleted
+func (a *application) applyEntry(parent Node, name string, set func(Node), del func(), n Node) {
+	a.applyCursor(Cursor{parent: parent, name: name, node: n, set: set, del: del})
+}
+
+// applyList applies the nodes of the list, which is a pointer to a slice field of
+// the parent
+func (a *application) applyList(parent Node, name string, list any) {
+	saved := a.iter
+	a.iter = iterator{list: reflect.ValueOf(list).Elem()}
+	for a.iter.index < a.iter.list.Len() {
+		a.iter.step = 1
+		var n Node
+		if el := a.iter.list.Index(a.iter.index); el.Kind() == reflect.Struct {
+			n = el.Addr().Interface().(Node)
+		} else if !el.IsNil() {
+			n = el.Interface().(Node)
+		}
+		a.applyCursor(Cursor{parent: parent, name: name, node: n, iter: &a.iter})
+		a.iter.index += a.iter.step
+	}
+	a.iter = saved
+}
+
+// applyCursor calls pre for the node of the cursor, applies its children, and
+// calls post. The nil nodes are skipped.
+func (a *application) applyCursor(c Cursor) {
+	if c.node == nil || reflect.ValueOf(c.node).IsNil() {
+		return
+	}
+	saved := a.cursor
+	defer func() { a.cursor = saved }()
+	a.cursor = c
+	if a.pre != nil && !a.pre(&a.cursor) {
+		return
+	}
+	if a.cursor.node != nil {
+		a.children(a.cursor.node)
+	}
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(errAbort)
+	}
+}
+
+func (a *application) children(node Node) {
+	switch n := node.(type) {
+	case *Program:
+		a.applyList(n, "Statements", &n.Statements)
+	case *BlockStmt:
+		a.applyList(n, "Statements", &n.Statements)
+	case *LetStmt:
+		a.apply(n, "Name", func(x Node) { n.Name = asIdent(x) }, n.Name)
+		a.apply(n, "Expr", func(x Node) { n.Expr = asExpr(x) }, n.Expr)
+	case *ExpressionStmt:
+		a.apply(n, "Expr", func(x Node) { n.Expr = asExpr(x) }, n.Expr)
+	case *ReassignmentStmt:
+		a.apply(n, "Name", func(x Node) { n.Name = asExpr(x) }, n.Name)
+		a.apply(n, "Expr", func(x Node) { n.Expr = asExpr(x) }, n.Expr)
+	case *ConditionalStmt:
+		a.apply(n, "Condition", func(x Node) { n.Condition = asExpr(x) }, n.Condition)
+		a.apply(n, "Statement", func(x Node) { n.Statement = asStmt(x) }, n.Statement)
+	case *ForLoopStmt:
+		a.apply(n, "Variable", func(x Node) { n.Variable = asIdent(x) }, n.Variable)
+		a.apply(n, "Boundary", func(x Node) { n.Boundary = asExpr(x) }, n.Boundary)
+		a.apply(n, "Statement", func(x Node) { n.Statement = asBlock(x) }, n.Statement)
+	case *IfStmt:
+		a.apply(n, "Consequence", func(x Node) { n.Consequence = asConditional(x) }, n.Consequence)
+		if n.Consequence != nil {
+			n.Condition = n.Consequence.Condition // the same expression
+		}
+		a.applyList(n, "Alternatives", &n.Alternatives)
+	case *MatchExpression:
+		a.apply(n, "Expression", func(x Node) { n.Expression = asExpr(x) }, n.Expression)
+		a.applyList(n, "Cases", &n.Cases)
+		a.apply(n, "Default", func(x Node) { n.Default = asExpr(x) }, n.Default)
+	case *MatchCase:
+		a.apply(n, "Pattern", func(x Node) { n.Pattern = asExpr(x) }, n.Pattern)
+		a.apply(n, "Output", func(x Node) { n.Output = asExpr(x) }, n.Output)
+	case *FunctionLiteral:
+		a.applyList(n, "Params", &n.Params)
+		a.apply(n, "Body", func(x Node) { n.Body = asBlock(x) }, n.Body)
+	case *MacroLiteral:
+		a.applyList(n, "Params", &n.Params)
+		a.apply(n, "Body", func(x Node) { n.Body = asBlock(x) }, n.Body)
+	case *ArrayLiteral:
+		a.applyList(n, "Elements", &n.Elements)
+	case *RangeArrayLiteral:
+		a.apply(n, "Start", func(x Node) { n.Start = asExpr(x) }, n.Start)
+		a.apply(n, "End", func(x Node) { n.End = asExpr(x) }, n.End)
+	case *HashLiteral:
+		for _, key := range hashKeys(n) {
+			key := key
+			deleted := false
+			setKey := func(x Node) {
+				value := n.Pair[key]
+				delete(n.Pair, key)
+				key = asExpr(x)
+				n.Pair[key] = value
+			}
+			del := func() {
+				delete(n.Pair, key)
+				deleted = true
+			}
+			a.applyEntry(n, "Key", setKey, del, key)
+			if !deleted {
+				a.applyEntry(n, "Value", func(x Node) { n.Pair[key] = asExpr(x) }, del, n.Pair[key])
+			}
+		}
+	case *SetLiteral:
+		for _, el := range setElements(n) {
+			el := el
+			set := func(x Node) {
+				delete(n.Elements, el)
+				el = asExpr(x)
+				n.Elements[el] = struct{}{}
+			}
+			a.applyEntry(n, "Elements", set, func() { delete(n.Elements, el) }, el)
+		}
+	case *InfixExpression:
+		a.apply(n, "Left", func(x Node) { n.Left = asExpr(x) }, n.Left)
+		a.apply(n, "Right", func(x Node) { n.Right = asExpr(x) }, n.Right)
+	case *PrefixExpression:
+		a.apply(n, "Right", func(x Node) { n.Right = asExpr(x) }, n.Right)
+	case *PostfixExpression:
+		a.apply(n, "Left", func(x Node) { n.Left = asExpr(x) }, n.Left)
+	case *ReturnExpression:
+		a.apply(n, "Expr", func(x Node) { n.Expr = asExpr(x) }, n.Expr)
+	case *IndexExpression:
+		a.apply(n, "Left", func(x Node) { n.Left = asExpr(x) }, n.Left)
+		a.apply(n, "Index", func(x Node) { n.Index = asExpr(x) }, n.Index)
+	case *CallExpression:
+		a.apply(n, "Function", func(x Node) { n.Function = asExpr(x) }, n.Function)
+		a.applyList(n, "Args", &n.Args)
+	case *ObjectMethodExpression:
+		a.apply(n, "Object", func(x Node) { n.Object = asExpr(x) }, n.Object)
+		a.apply(n, "Method", func(x Node) { n.Method = asExpr(x) }, n.Method)
+	}
+}
+
+// The conversions of the nodes replacing a child, which panic if the node cannot
+// be held by the field
+
+func asExpr(n Node) Expression {
+	if n == nil {
+		return nil
+	}
+	return n.(Expression)
+}
+
+func asStmt(n Node) Statement {
+	if n == nil {
+		return nil
+	}
+	return n.(Statement)
+}
+
+func asIdent(n Node) *Identifier {
+	if n == nil {
+		return nil
+	}
+	return n.(*Identifier)
+}
+
+func asBlock(n Node) *BlockStmt {
+	if n == nil {
+		return nil
+	}
+	return n.(*BlockStmt)
+}
+
+func asConditional(n Node) *ConditionalStmt {
+	if n == nil {
+		return nil
+	}
+	return n.(*ConditionalStmt)
+}
@@ -11,6 +11,7 @@ type Node interface {
 	Pos() token.Pos
 	Literal() string
 	TokenType() token.TokenType
+	String() string // the canonical source of the node
 }
 
 // Statement is an interface implemented by all statements
@@ -46,11 +47,13 @@ type (
 	BlockStmt struct {
 		Token      token.Token
 		Statements []Statement
+		Rbrace     token.Pos // position of the closing brace
 	}
 	ConditionalStmt struct {
 		Token     token.Token
 		Condition Expression
 		Statement Statement
+		Rbrace    token.Pos // position of the closing brace
 	}
 
 	ForLoopStmt struct {
@@ -86,6 +89,7 @@ type (
 		Cases      []MatchCase
 		Default    Expression
 		Token      token.Token
+		Rbrace     token.Pos // position of the closing brace
 	}
 
 	Identifier struct {
@@ -129,6 +133,13 @@ type (
 		Params []*Identifier
 		Body   *BlockStmt
 	}
+	// MacroLiteral is a macro, expanded before the program runs:
+	// macro(params) { quote(...) }
+	MacroLiteral struct {
+		Token  token.Token
+		Params []*Identifier
+		Body   *BlockStmt
+	}
 	ArrayLiteral struct {
 		Token    token.Token
 		Elements []Expression
@@ -232,6 +243,7 @@ func (s *MatchExpression) stmtNode()        {}
 func (s *StringLiteral) exprNode()          {}
 func (s *NilLiteral) exprNode()             {}
 func (s *FunctionLiteral) exprNode()        {}
+func (s *MacroLiteral) exprNode()           {}
 func (s *IntegerLiteral) exprNode()         {}
 func (s *ArrayLiteral) exprNode()           {}
 func (s *RangeArrayLiteral) exprNode()      {}
@@ -260,6 +272,7 @@ func (s *ForLoopStmt) Pos() token.Pos            { return s.Token.Pos }
 func (s *StringLiteral) Pos() token.Pos          { return s.Token.Pos }
 func (s *NilLiteral) Pos() token.Pos             { return s.Token.Pos }
 func (s *FunctionLiteral) Pos() token.Pos        { return s.Token.Pos }
+func (s *MacroLiteral) Pos() token.Pos           { return s.Token.Pos }
 func (s *IntegerLiteral) Pos() token.Pos         { return s.Token.Pos }
 func (s *ArrayLiteral) Pos() token.Pos           { return s.Token.Pos }
 func (s *RangeArrayLiteral) Pos() token.Pos      { return s.Token.Pos }
@@ -279,6 +292,12 @@ func (s *CallExpression) Pos() token.Pos         { return s.Token.Pos }
 func (s *IndexExpression) Pos() token.Pos        { return s.Token.Pos }
 func (s *ObjectMethodExpression) Pos() token.Pos { return s.Token.Pos }
 func (s *MatchExpression) Pos() token.Pos        { return s.Token.Pos }
+func (s *MatchCase) Pos() token.Pos {
+	if s.Pattern == nil {
+		return token.Pos{}
+	}
+	return s.Pattern.Pos()
+}
 
 func (s *Program) Literal() string           { return "" } // TODO
 func (s *LetStmt) Literal() string           { return s.Token.Literal }
@@ -290,6 +309,7 @@ func (s *ForLoopStmt) Literal() string       { return s.Token.Literal }
 func (s *StringLiteral) Literal() string     { return s.Value }
 func (s *NilLiteral) Literal() string        { return s.Value }
 func (s *FunctionLiteral) Literal() string   { return s.Token.Literal } //TODO
+func (s *MacroLiteral) Literal() string      { return s.Token.Literal }
 func (s *IntegerLiteral) Literal() string    { return fmt.Sprint(s.Value) }
 func (s *ArrayLiteral) Literal() string      { return "" } // TODO
 func (s *RangeArrayLiteral) Literal() string { return "" } // TODO
@@ -315,6 +335,7 @@ func (s *CallExpression) Literal() string         { return s.Token.Literal }
 func (s *IndexExpression) Literal() string        { return s.Token.Literal }
 func (s *ObjectMethodExpression) Literal() string { return s.Token.Literal }
 func (s *MatchExpression) Literal() string        { return s.Token.Literal }
+func (s *MatchCase) Literal() string              { return "case" }
 
 func (s *Program) TokenType() token.TokenType                { return s.Token.Type }
 func (s *LetStmt) TokenType() token.TokenType                { return s.Token.Type }
@@ -326,6 +347,7 @@ func (s *ConditionalStmt) TokenType() token.TokenType        { return s.Token.Ty
 func (s *StringLiteral) TokenType() token.TokenType          { return s.Token.Type }
 func (s *NilLiteral) TokenType() token.TokenType             { return s.Token.Type }
 func (s *FunctionLiteral) TokenType() token.TokenType        { return s.Token.Type }
+func (s *MacroLiteral) TokenType() token.TokenType           { return s.Token.Type }
 func (s *IntegerLiteral) TokenType() token.TokenType         { return s.Token.Type }
 func (s *ArrayLiteral) TokenType() token.TokenType           { return s.Token.Type }
 func (s *RangeArrayLiteral) TokenType() token.TokenType      { return s.Token.Type }
@@ -345,3 +367,4 @@ func (s *CallExpression) TokenType() token.TokenType         { return s.Token.Ty
 func (s *IndexExpression) TokenType() token.TokenType        { return s.Token.Type }
 func (s *ObjectMethodExpression) TokenType() token.TokenType { return s.Token.Type }
 func (s *MatchExpression) TokenType() token.TokenType        { return s.Token.Type }
+func (s *MatchCase) TokenType() token.TokenType              { return token.CASE }
@@ -0,0 +1,23 @@
+package ast_test
+
+import (
+	"ede/ast"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	prog := parse(t, `let f = func(a) { return {"a": [a, 1]} }`)
+	copied := ast.Copy(prog)
+	if copied.String() != prog.String() {
+		t.Fatalf("wrong copy.\nexpected=%q\ngot=     %q", prog.String(), copied.String())
+	}
+	ast.Inspect(copied, func(node ast.Node) bool {
+		if integer, ok := node.(*ast.IntegerLiteral); ok {
+			integer.Value = 2
+		}
+		return true
+	})
+	if expected := "let f = func(a) {\n    return {\"a\": [a, 1]}\n}\n"; prog.String() != expected {
+		t.Errorf("the original changed with the copy.\nexpected=%q\ngot=     %q", expected, prog.String())
+	}
+}
@@ -0,0 +1,223 @@
+package ast
+
+import (
+	"ede/token"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Dump writes the tree of the node to w as indented text, one node per line, e.g.
+//
+//	LetStmt 1:1
+//	  Name: Identifier 1:5 Value="a"
+//	  Expr: IntegerLiteral 1:9 Value=10
+func Dump(w io.Writer, node Node) error {
+	buf := new(strings.Builder)
+	writeDumpNode(buf, "", newDumpNode(reflect.ValueOf(node)), 0)
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// DumpJSON writes the tree of the node to w as indented JSON. Each node is an object
+// with its "type" and "pos", its scalar values and its children.
+func DumpJSON(w io.Writer, node Node) error {
+	data, err := json.MarshalIndent(newDumpNode(reflect.ValueOf(node)).toJSON(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// dumpNode is the representation of a node shared by the text and JSON dumps
+type dumpNode struct {
+	typ    string
+	pos    *token.Pos
+	attrs  []dumpAttr
+	fields []dumpField
+}
+
+type dumpAttr struct {
+	name  string
+	value any
+}
+
+type dumpField struct {
+	name     string
+	list     bool
+	children []*dumpNode
+}
+
+var (
+	tokenType = reflect.TypeOf(token.Token{})
+	posType   = reflect.TypeOf(token.Pos{})
+)
+
+func newDumpNode(val reflect.Value) *dumpNode {
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	typ := val.Type()
+	node := &dumpNode{typ: typ.Name()}
+	if n, ok := val.Addr().Interface().(Node); ok {
+		pos := n.Pos()
+		node.pos = &pos
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field, fieldVal := typ.Field(i), val.Field(i)
+		switch {
+		case field.Type == tokenType, field.Type == posType, field.Name == "ParseErrors":
+			continue
+		case field.Type.Kind() == reflect.Map:
+			node.fields = append(node.fields, dumpField{name: field.Name, list: true, children: dumpMap(fieldVal)})
+		case field.Type.Kind() == reflect.Slice:
+			children := make([]*dumpNode, 0, fieldVal.Len())
+			for j := 0; j < fieldVal.Len(); j++ {
+				children = append(children, newDumpNode(fieldVal.Index(j).Addr()))
+			}
+			node.fields = append(node.fields, dumpField{name: field.Name, list: true, children: children})
+		case field.Type.Kind() == reflect.Interface, field.Type.Kind() == reflect.Pointer:
+			if child := newDumpNode(fieldVal); child != nil {
+				node.fields = append(node.fields, dumpField{name: field.Name, children: []*dumpNode{child}})
+			}
+		default:
+			node.attrs = append(node.attrs, dumpAttr{name: field.Name, value: fieldVal.Interface()})
+		}
+	}
+	return node
+}
+
+// dumpMap returns the entries of a hash or set literal, ordered by their position in the source
+func dumpMap(val reflect.Value) []*dumpNode {
+	keys := make([]Expression, 0, val.Len())
+	for _, key := range val.MapKeys() {
+		keys = append(keys, key.Interface().(Expression))
+	}
+	sortByPos(keys)
+
+	entries := make([]*dumpNode, 0, len(keys))
+	for _, key := range keys {
+		keyNode := newDumpNode(reflect.ValueOf(key))
+		if val.Type().Elem().Kind() != reflect.Interface { // set entry
+			entries = append(entries, keyNode)
+			continue
+		}
+		value := newDumpNode(val.MapIndex(reflect.ValueOf(key)))
+		pos := key.Pos()
+		entries = append(entries, &dumpNode{typ: "Pair", pos: &pos, fields: []dumpField{
+			{name: "Key", children: []*dumpNode{keyNode}},
+			{name: "Value", children: []*dumpNode{value}},
+		}})
+	}
+	return entries
+}
+
+// sortByPos sorts the expressions, e.g. the keys of a map, in the order of the
+// source. The expressions at the same position, e.g. built by a macro, are sorted
+// by their source.
+func sortByPos(exprs []Expression) {
+	sort.Slice(exprs, func(i, j int) bool {
+		a, b := exprs[i].Pos(), exprs[j].Pos()
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return exprs[i].String() < exprs[j].String()
+	})
+}
+
+// hashKeys returns the keys of the hash literal, in the order of the source
+func hashKeys(hash *HashLiteral) []Expression {
+	keys := make([]Expression, 0, len(hash.Pair))
+	for key := range hash.Pair {
+		keys = append(keys, key)
+	}
+	sortByPos(keys)
+	return keys
+}
+
+// setElements returns the elements of the set literal, in the order of the source
+func setElements(set *SetLiteral) []Expression {
+	elements := make([]Expression, 0, len(set.Elements))
+	for el := range set.Elements {
+		elements = append(elements, el)
+	}
+	sortByPos(elements)
+	return elements
+}
+
+func writeDumpNode(buf *strings.Builder, label string, node *dumpNode, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(label)
+	if node == nil {
+		buf.WriteString("nil\n")
+		return
+	}
+	buf.WriteString(node.typ)
+	if node.pos != nil {
+		fmt.Fprintf(buf, " %d:%d", node.pos.Line, node.pos.Column)
+	}
+	for _, attr := range node.attrs {
+		fmt.Fprintf(buf, " %s=%s", attr.name, formatAttr(attr.value))
+	}
+	buf.WriteString("\n")
+	for _, field := range node.fields {
+		if !field.list {
+			writeDumpNode(buf, field.name+": ", field.children[0], depth+1)
+			continue
+		}
+		for i, child := range field.children {
+			writeDumpNode(buf, fmt.Sprintf("%s[%d]: ", field.name, i), child, depth+1)
+		}
+	}
+}
+
+func formatAttr(value any) string {
+	if str, ok := value.(string); ok {
+		return fmt.Sprintf("%q", str)
+	}
+	return fmt.Sprint(value)
+}
+
+func (n *dumpNode) toJSON() any {
+	if n == nil {
+		return nil
+	}
+	obj := map[string]any{"type": n.typ}
+	if n.pos != nil {
+		obj["pos"] = map[string]int{"line": n.pos.Line, "column": n.pos.Column}
+	}
+	for _, attr := range n.attrs {
+		obj[jsonName(attr.name)] = attr.value
+	}
+	for _, field := range n.fields {
+		if !field.list {
+			obj[jsonName(field.name)] = field.children[0].toJSON()
+			continue
+		}
+		children := make([]any, 0, len(field.children))
+		for _, child := range field.children {
+			children = append(children, child.toJSON())
+		}
+		obj[jsonName(field.name)] = children
+	}
+	return obj
+}
+
+// jsonName converts an exported field name to its JSON key, e.g. Statements -> statements
+func jsonName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,502 @@
+package ast
+
+import (
+	"ede/token"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// indent is the indentation of a nested block
+const indent = "    "
+
+// Precedence of the expressions when printed, mirroring the precedence of the parser.
+// It decides where parentheses are needed to keep the shape of the tree.
+const (
+	_ int = iota
+	precLowest
+	precCond        // || or &&
+	precAssign      // =
+	precEq          // == or !=
+	precLessGreater // > or <
+	precSum         // + or -
+	precProduct     // * or /
+	precPower       // **
+	precMod         // %
+	precPrefix      // -X or !X
+	precCall        // myFunction(X)
+	precIndex       // array[index], map[key]
+	precHighest
+)
+
+var infixPrecedences = map[string]int{
+	token.OR_OR:    precCond,
+	token.AND_AND:  precCond,
+	token.EQ:       precEq,
+	token.NEQ:      precEq,
+	token.GT:       precLessGreater,
+	token.LT:       precLessGreater,
+	token.GTE:      precLessGreater,
+	token.LTE:      precLessGreater,
+	token.PLUS:     precSum,
+	token.MINUS:    precSum,
+	token.MODULO:   precSum,
+	token.ASTERISK: precProduct,
+	token.SLASH:    precProduct,
+}
+
+// printer renders nodes as canonical source. Statements are printed one per line,
+// blocks are indented, and the blank lines and comments between statements are kept.
+type printer struct {
+	buf         strings.Builder
+	depth       int
+	lineStarted bool
+}
+
+func printNode(node Node) string {
+	p := &printer{}
+	p.node(node)
+	return p.buf.String()
+}
+
+// write writes s, indenting it if it starts a line
+func (p *printer) write(s string) {
+	if !p.lineStarted && s != "" {
+		p.buf.WriteString(strings.Repeat(indent, p.depth))
+		p.lineStarted = true
+	}
+	p.buf.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteString("\n")
+	p.lineStarted = false
+}
+
+func (p *printer) node(node Node) {
+	switch node := node.(type) {
+	case *Program:
+		if len(node.Statements) > 0 {
+			p.stmtList(node.Statements)
+			p.newline()
+		}
+	case *BlockStmt:
+		p.block(node)
+	case *ConditionalStmt:
+		p.conditional(node, true)
+	case *MatchCase:
+		p.matchCase(node)
+	case Statement:
+		p.stmt(node)
+	case Expression:
+		p.expr(node, precLowest)
+	}
+}
+
+// stmtList prints the statements, keeping a blank line where the source had one or more,
+// and keeping comments on the line of the statement they follow
+func (p *printer) stmtList(stmts []Statement) {
+	for i, stmt := range stmts {
+		if i > 0 {
+			prevEnd := endLine(stmts[i-1])
+			if _, ok := stmt.(*CommentStmt); ok && stmt.Pos().Line == prevEnd {
+				p.write(" ")
+				p.stmt(stmt)
+				continue
+			}
+			p.newline()
+			if stmt.Pos().Line-prevEnd > 1 {
+				p.newline()
+			}
+		}
+		p.stmt(stmt)
+	}
+}
+
+func (p *printer) stmt(stmt Statement) {
+	switch stmt := stmt.(type) {
+	case *LetStmt:
+		p.write("let " + stmt.Name.Value)
+		if stmt.Expr != nil {
+			p.write(" = ")
+			p.expr(stmt.Expr, precLowest)
+		}
+	case *ExpressionStmt:
+		p.expr(stmt.Expr, precLowest)
+	case *BlockStmt:
+		p.block(stmt)
+	case *ConditionalStmt:
+		p.conditional(stmt, true)
+	case *IfStmt:
+		p.conditional(stmt.Consequence, true)
+		for _, alt := range stmt.Alternatives {
+			p.write(" ")
+			p.conditional(alt, false)
+		}
+	case *ForLoopStmt:
+		p.write("for " + stmt.Variable.Value + " = range ")
+		p.expr(stmt.Boundary, precLowest)
+		p.write(" ")
+		p.block(stmt.Statement)
+	case *CommentStmt:
+		p.write("//" + strings.TrimRight(stmt.Value, " \t\r\n;"))
+	case *ImportStmt:
+		p.write("import " + stmt.Value)
+	case Expression: // e.g. reassignments and return expressions
+		p.expr(stmt, precLowest)
+	}
+}
+
+// conditional prints an if, else if or else branch. The branches following the
+// first start with "else"
+func (p *printer) conditional(stmt *ConditionalStmt, first bool) {
+	if !first {
+		p.write("else ")
+	}
+	if stmt.Condition != nil {
+		p.write("if (")
+		p.expr(stmt.Condition, precLowest)
+		p.write(") ")
+	}
+	if block, ok := stmt.Statement.(*BlockStmt); ok {
+		p.block(block)
+		return
+	}
+	// the else branches hold a single statement
+	p.write("{")
+	p.depth++
+	p.newline()
+	p.stmt(stmt.Statement)
+	p.depth--
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) block(block *BlockStmt) {
+	p.write("{")
+	stmts := block.Statements
+	if len(stmts) == 0 {
+		p.write("}")
+		return
+	}
+	p.depth++
+	// a comment after the opening brace stays on its line
+	if comment, ok := stmts[0].(*CommentStmt); ok && comment.Pos().Line == block.Pos().Line {
+		p.write(" ")
+		p.stmt(comment)
+		stmts = stmts[1:]
+	}
+	if len(stmts) > 0 {
+		p.newline()
+		p.stmtList(stmts)
+	}
+	p.depth--
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) matchCase(c *MatchCase) {
+	p.write("case ")
+	p.expr(c.Pattern, precLowest)
+	p.write(": ")
+	p.expr(c.Output, precLowest)
+}
+
+// params prints the parameters of a function or macro, and the space before its body
+func (p *printer) params(params []*Identifier) {
+	p.write("(")
+	for i, param := range params {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Value)
+	}
+	p.write(") ")
+}
+
+// expr prints the expression, wrapping it in parentheses if it binds looser than prec
+func (p *printer) expr(expr Expression, prec int) {
+	if exprPrecedence(expr) < prec {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch expr := expr.(type) {
+	case *Identifier:
+		p.write(expr.Value)
+	case *IntegerLiteral:
+		p.write(strconv.FormatInt(expr.Value, 10))
+	case *FloatLiteral:
+		str := strconv.FormatFloat(expr.Value, 'f', -1, 64)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+		p.write(str)
+	case *BooleanLiteral:
+		p.write(strconv.FormatBool(expr.Value))
+	case *NilLiteral:
+		p.write("nil")
+	case *StringLiteral:
+		// strings have no escape sequences, so only raw strings can hold quotes or newlines
+		if strings.ContainsAny(expr.Value, "\"\n") {
+			p.write("`" + expr.Value + "`")
+		} else {
+			p.write(`"` + expr.Value + `"`)
+		}
+	case *ArrayLiteral:
+		p.write("[")
+		p.exprList(expr.Elements)
+		p.write("]")
+	case *RangeArrayLiteral:
+		p.write("[")
+		p.expr(expr.Start, precLessGreater+1)
+		p.write("..")
+		p.expr(expr.End, precLowest)
+		p.write("]")
+	case *HashLiteral:
+		p.write("{")
+		for i, key := range hashKeys(expr) {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expr(key, precLowest)
+			p.write(": ")
+			p.expr(expr.Pair[key], precLowest)
+		}
+		p.write("}")
+	case *SetLiteral:
+		p.write("{")
+		p.exprList(setElements(expr))
+		p.write("}")
+	case *FunctionLiteral:
+		p.write("func")
+		p.params(expr.Params)
+		p.block(expr.Body)
+	case *MacroLiteral:
+		p.write("macro")
+		p.params(expr.Params)
+		p.block(expr.Body)
+	case *InfixExpression:
+		prec := exprPrecedence(expr)
+		p.expr(expr.Left, prec)
+		p.write(" " + expr.Operator + " ")
+		p.expr(expr.Right, prec+1) // the operators are left associative
+	case *PrefixExpression:
+		p.write(expr.Operator)
+		// keep e.g. - -a from being read as a decrement
+		if right, ok := expr.Right.(*PrefixExpression); ok && isSign(expr.Operator) && isSign(right.Operator) {
+			p.write("(")
+			p.expr(expr.Right, precLowest)
+			p.write(")")
+			break
+		}
+		p.expr(expr.Right, precPrefix)
+	case *PostfixExpression:
+		p.expr(expr.Left, precSum)
+		p.write(expr.Operator)
+	case *ReassignmentStmt:
+		// a += b is parsed as a = a + b
+		if infix, ok := expr.Expr.(*InfixExpression); ok && (expr.Token.Type == token.PLUS_EQUAL || expr.Token.Type == token.MINUS_EQUAL) {
+			p.expr(expr.Name, precLowest)
+			p.write(" " + infix.Operator + "= ")
+			p.expr(infix.Right, precLowest)
+			break
+		}
+		p.expr(expr.Name, precLowest)
+		p.write(" = ")
+		p.expr(expr.Expr, precLowest)
+	case *ReturnExpression:
+		keyword := expr.Token.Literal // return, or its alias <-
+		if keyword == "" {
+			keyword = "return"
+		}
+		p.write(keyword)
+		if expr.Expr != nil {
+			p.write(" ")
+			p.expr(expr.Expr, precLowest)
+		}
+	case *IndexExpression:
+		p.expr(expr.Left, precIndex)
+		p.write("[")
+		p.expr(expr.Index, precLowest)
+		p.write("]")
+	case *CallExpression:
+		p.expr(expr.Function, precCall)
+		p.write("(")
+		p.exprList(expr.Args)
+		p.write(")")
+	case *ObjectMethodExpression:
+		p.expr(expr.Object, precCall)
+		p.write(".")
+		p.expr(expr.Method, precLowest)
+	case *MatchExpression:
+		p.write("match (")
+		p.expr(expr.Expression, precLowest)
+		p.write(") {")
+		p.depth++
+		for i := range expr.Cases {
+			p.newline()
+			p.matchCase(&expr.Cases[i])
+		}
+		if expr.Default != nil {
+			p.newline()
+			p.write("default: ")
+			p.expr(expr.Default, precLowest)
+		}
+		p.depth--
+		p.newline()
+		p.write("}")
+	default:
+		p.write(fmt.Sprintf("<%T>", expr))
+	}
+}
+
+func (p *printer) exprList(exprs []Expression) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expr(expr, precLowest)
+	}
+}
+
+func isSign(operator string) bool {
+	return operator == token.PLUS || operator == token.MINUS
+}
+
+func exprPrecedence(expr Expression) int {
+	switch expr := expr.(type) {
+	case *InfixExpression:
+		if prec, ok := infixPrecedences[expr.Operator]; ok {
+			return prec
+		}
+		return precLowest
+	case *ReassignmentStmt:
+		return precAssign
+	case *PostfixExpression:
+		return precSum
+	case *PrefixExpression:
+		return precPrefix
+	case *ReturnExpression, *MatchExpression:
+		return precLowest
+	}
+	return precHighest
+}
+
+// endLine returns the line in the source where the node ends
+func endLine(node Node) int {
+	end := node.Pos().Line
+	extend := func(line int) {
+		if line > end {
+			end = line
+		}
+	}
+	last := func(nodes ...Node) {
+		for _, n := range nodes {
+			if n != nil {
+				extend(endLine(n))
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *StringLiteral:
+		end += strings.Count(node.Value, "\n")
+	case *BlockStmt:
+		extend(node.Rbrace.Line)
+	case *ConditionalStmt:
+		extend(node.Rbrace.Line)
+		last(node.Statement)
+	case *MatchExpression:
+		extend(node.Rbrace.Line)
+	case *IfStmt:
+		if node.Consequence != nil {
+			last(node.Consequence)
+		}
+		for _, alt := range node.Alternatives {
+			last(alt)
+		}
+	case *ForLoopStmt:
+		if node.Statement != nil {
+			last(node.Statement)
+		}
+	case *FunctionLiteral:
+		if node.Body != nil {
+			last(node.Body)
+		}
+	case *MacroLiteral:
+		if node.Body != nil {
+			last(node.Body)
+		}
+	case *LetStmt:
+		last(node.Expr)
+	case *ExpressionStmt:
+		last(node.Expr)
+	case *ReassignmentStmt:
+		last(node.Name, node.Expr)
+	case *ReturnExpression:
+		last(node.Expr)
+	case *InfixExpression:
+		last(node.Left, node.Right)
+	case *PrefixExpression:
+		last(node.Right)
+	case *PostfixExpression:
+		last(node.Left)
+	case *IndexExpression:
+		last(node.Left, node.Index)
+	case *ObjectMethodExpression:
+		last(node.Object, node.Method)
+	case *CallExpression:
+		last(node.Function)
+		for _, arg := range node.Args {
+			last(arg)
+		}
+	case *ArrayLiteral:
+		for _, el := range node.Elements {
+			last(el)
+		}
+	case *RangeArrayLiteral:
+		last(node.Start, node.End)
+	case *HashLiteral:
+		for key, value := range node.Pair {
+			last(key, value)
+		}
+	case *SetLiteral:
+		for el := range node.Elements {
+			last(el)
+		}
+	}
+	return end
+}
+
+func (s *Program) String() string                { return printNode(s) }
+func (s *LetStmt) String() string                { return printNode(s) }
+func (s *ExpressionStmt) String() string         { return printNode(s) }
+func (s *BlockStmt) String() string              { return printNode(s) }
+func (s *CommentStmt) String() string            { return printNode(s) }
+func (s *ConditionalStmt) String() string        { return printNode(s) }
+func (s *ForLoopStmt) String() string            { return printNode(s) }
+func (s *StringLiteral) String() string          { return printNode(s) }
+func (s *NilLiteral) String() string             { return printNode(s) }
+func (s *FunctionLiteral) String() string        { return printNode(s) }
+func (s *MacroLiteral) String() string           { return printNode(s) }
+func (s *IntegerLiteral) String() string         { return printNode(s) }
+func (s *ArrayLiteral) String() string           { return printNode(s) }
+func (s *RangeArrayLiteral) String() string      { return printNode(s) }
+func (s *HashLiteral) String() string            { return printNode(s) }
+func (s *SetLiteral) String() string             { return printNode(s) }
+func (s *BooleanLiteral) String() string         { return printNode(s) }
+func (s *FloatLiteral) String() string           { return printNode(s) }
+func (s *Identifier) String() string             { return printNode(s) }
+func (s *ReassignmentStmt) String() string       { return printNode(s) }
+func (s *InfixExpression) String() string        { return printNode(s) }
+func (s *IfStmt) String() string                 { return printNode(s) }
+func (s *ImportStmt) String() string             { return printNode(s) }
+func (s *PrefixExpression) String() string       { return printNode(s) }
+func (s *ReturnExpression) String() string       { return printNode(s) }
+func (s *PostfixExpression) String() string      { return printNode(s) }
+func (s *CallExpression) String() string         { return printNode(s) }
+func (s *IndexExpression) String() string        { return printNode(s) }
+func (s *ObjectMethodExpression) String() string { return printNode(s) }
+func (s *MatchExpression) String() string        { return printNode(s) }
+func (s *MatchCase) String() string              { return printNode(s) }
@@ -0,0 +1,129 @@
+package ast_test
+
+import (
+	"bytes"
+	"ede/ast"
+	"ede/lexer"
+	"ede/parser"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		t.Fatalf("failed to parse %q: %s", src, prog.ParseErrors)
+	}
+	return prog
+}
+
+func TestPrinter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 1;let b;", "let a = 1\nlet b\n"},
+		{"let a = (1 + 2) * 3 - (4 - 5)", "let a = (1 + 2) * 3 - (4 - 5)\n"},
+		{"let a = ((1 * 2) + 3)", "let a = 1 * 2 + 3\n"},
+		{"let a = !(b || c) && -d", "let a = !(b || c) && -d\n"},
+		{"let a = -(-b)", "let a = -(-b)\n"},
+		{"a += 1\nb -= c * 2\nc = c + 1", "a += 1\nb -= c * 2\nc = c + 1\n"},
+		{"let a = [1,2,[-3..len(b)]]", "let a = [1, 2, [-3..len(b)]]\n"},
+		{`let h = {"b":1, "a":[2]}`, "let h = {\"b\": 1, \"a\": [2]}\n"},
+		{"let s = {3,1,2}", "let s = {3, 1, 2}\n"},
+		{"let f = 2.50 + 3.0", "let f = 2.5 + 3.0\n"},
+		{"let s = `say \"hi\"` + \"x\"", "let s = `say \"hi\"` + \"x\"\n"},
+		{"a.b(1).c[0]++", "a.b(1).c[0]++\n"},
+		{"let f = func(a,b) { <- a }(1, 2)", "let f = func(a, b) {\n    <- a\n}(1, 2)\n"},
+		{"let m = macro(a) { quote(unquote(a)) }", "let m = macro(a) {\n    quote(unquote(a))\n}\n"},
+		{"import json\n\n\nlet a = 1\n\nlet b = 2", "import json\n\nlet a = 1\n\nlet b = 2\n"},
+		{"// head\nlet a = 1 // trailing\n//", "// head\nlet a = 1 // trailing\n//\n"},
+		{"if (a) { // why\n\n b()\n}", "if (a) { // why\n    b()\n}\n"},
+		{
+			"if (a > 1) { b() } else if (a == 1) { c() } else { d() }",
+			"if (a > 1) {\n    b()\n} else if (a == 1) {\n    c()\n} else {\n    d()\n}\n",
+		},
+		{"for i = range [0..n-1] {\nfor j = range i {}\n}", "for i = range [0..n - 1] {\n    for j = range i {}\n}\n"},
+		{
+			"let v = match (a) {\ncase 1: \"one\"\ndefault: nil\n}",
+			"let v = match (a) {\n    case 1: \"one\"\n    default: nil\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			formatted := parse(t, tt.input).String()
+			if formatted != tt.expected {
+				t.Errorf("wrong output.\nexpected=%q\ngot=     %q", tt.expected, formatted)
+			}
+			if again := parse(t, formatted).String(); again != formatted {
+				t.Errorf("formatting is not idempotent.\nfirst= %q\nsecond=%q", formatted, again)
+			}
+		})
+	}
+}
+
+func TestPrinter_Node(t *testing.T) {
+	prog := parse(t, "let f = func(x) {\nif (x) { return x * (2 + 1) }\n}")
+	fn := prog.Statements[0].(*ast.LetStmt).Expr.(*ast.FunctionLiteral)
+	ifStmt := fn.Body.Statements[0].(*ast.IfStmt)
+
+	if got, exp := ifStmt.String(), "if (x) {\n    return x * (2 + 1)\n}"; got != exp {
+		t.Errorf("wrong output. expected=%q, got=%q", exp, got)
+	}
+	if got, exp := ifStmt.Condition.String(), "x"; got != exp {
+		t.Errorf("wrong output. expected=%q, got=%q", exp, got)
+	}
+}
+
+// positions are stripped from the dump, since formatting moves the nodes
+var dumpPos = regexp.MustCompile(` \d+:\d+`)
+
+func dump(t *testing.T, prog *ast.Program) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := ast.Dump(buf, prog); err != nil {
+		t.Fatal(err)
+	}
+	return dumpPos.ReplaceAllString(buf.String(), "")
+}
+
+// TestPrinter_Examples formats every example, and checks the result parses to the
+// same tree and is stable when formatted again
+func TestPrinter_Examples(t *testing.T) {
+	paths, err := filepath.Glob("../examples/*.ede")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := filepath.Glob("../examples/*/*.ede")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, nested...)
+	if len(paths) == 0 {
+		t.Fatal("no examples found")
+	}
+
+	for _, path := range paths {
+		t.Run(strings.TrimPrefix(path, "../"), func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prog := parse(t, string(src))
+			formatted := prog.String()
+
+			reparsed := parse(t, formatted)
+			if exp, got := dump(t, prog), dump(t, reparsed); exp != got {
+				t.Errorf("formatted program has a different tree.\nexpected:\n%s\ngot:\n%s", exp, got)
+			}
+			if again := reparsed.String(); again != formatted {
+				t.Errorf("formatting is not idempotent.\nfirst:\n%s\nsecond:\n%s", formatted, again)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package ast
+
+// A Visitor's Visit method is called for each node of Walk. If the visitor it
+// returns is not nil, Walk visits the children of the node with it, then calls
+// its Visit method with nil.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree of the node in depth-first order: it calls v.Visit(node),
+// then walks the children of the node with the returned visitor, if not nil.
+//
+// The children are visited in the order of the source. The keys of a hash literal
+// are followed by their values, and the entries of hash and set literals are
+// sorted by their position. The condition of an if statement is visited once, as
+// the condition of its first branch.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStmts(v, n.Statements)
+	case *BlockStmt:
+		walkStmts(v, n.Statements)
+	case *LetStmt:
+		walkIdent(v, n.Name)
+		walkExpr(v, n.Expr)
+	case *ExpressionStmt:
+		walkExpr(v, n.Expr)
+	case *ReassignmentStmt:
+		walkExpr(v, n.Name)
+		walkExpr(v, n.Expr)
+	case *ConditionalStmt:
+		walkExpr(v, n.Condition)
+		if n.Statement != nil {
+			Walk(v, n.Statement)
+		}
+	case *ForLoopStmt:
+		walkIdent(v, n.Variable)
+		walkExpr(v, n.Boundary)
+		if n.Statement != nil {
+			Walk(v, n.Statement)
+		}
+	case *IfStmt:
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		for _, alt := range n.Alternatives {
+			if alt != nil {
+				Walk(v, alt)
+			}
+		}
+	case *MatchExpression:
+		walkExpr(v, n.Expression)
+		for i := range n.Cases {
+			Walk(v, &n.Cases[i])
+		}
+		walkExpr(v, n.Default)
+	case *MatchCase:
+		walkExpr(v, n.Pattern)
+		walkExpr(v, n.Output)
+	case *FunctionLiteral:
+		for _, param := range n.Params {
+			walkIdent(v, param)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *MacroLiteral:
+		for _, param := range n.Params {
+			walkIdent(v, param)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *ArrayLiteral:
+		walkExprs(v, n.Elements)
+	case *RangeArrayLiteral:
+		walkExpr(v, n.Start)
+		walkExpr(v, n.End)
+	case *HashLiteral:
+		for _, key := range hashKeys(n) {
+			walkExpr(v, key)
+			walkExpr(v, n.Pair[key])
+		}
+	case *SetLiteral:
+		walkExprs(v, setElements(n))
+	case *InfixExpression:
+		walkExpr(v, n.Left)
+		walkExpr(v, n.Right)
+	case *PrefixExpression:
+		walkExpr(v, n.Right)
+	case *PostfixExpression:
+		walkExpr(v, n.Left)
+	case *ReturnExpression:
+		walkExpr(v, n.Expr)
+	case *IndexExpression:
+		walkExpr(v, n.Left)
+		walkExpr(v, n.Index)
+	case *CallExpression:
+		walkExpr(v, n.Function)
+		walkExprs(v, n.Args)
+	case *ObjectMethodExpression:
+		walkExpr(v, n.Object)
+		walkExpr(v, n.Method)
+	}
+
+	v.Visit(nil)
+}
+
+// walkExpr walks the expression if it is not nil, e.g. the value of `let a`
+func walkExpr(v Visitor, expr Expression) {
+	if expr != nil {
+		Walk(v, expr)
+	}
+}
+
+func walkIdent(v Visitor, ident *Identifier) {
+	if ident != nil {
+		Walk(v, ident)
+	}
+}
+
+func walkExprs(v Visitor, exprs []Expression) {
+	for _, expr := range exprs {
+		walkExpr(v, expr)
+	}
+}
+
+func walkStmts(v Visitor, stmts []Statement) {
+	for _, stmt := range stmts {
+		if stmt != nil {
+			Walk(v, stmt)
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree of the node in the order of Walk: it calls f(node),
+// and if it returns true, inspects the children of the node, then calls f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,225 @@
+package ast_test
+
+import (
+	"ede/ast"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nodeNames returns the type and position of each node inspected
+func nodeNames(node ast.Node) []string {
+	var names []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n != nil {
+			names = append(names, fmt.Sprintf("%s %d:%d", strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."), n.Pos().Line, n.Pos().Column))
+		}
+		return true
+	})
+	return names
+}
+
+func TestInspect(t *testing.T) {
+	input := `let h = {"b": 1, "a": [x..2]}
+if (a) { b } else { c = -d }
+for i = range s { f({3, 1})++ }
+let v = match (m) {
+case 1: o.p
+default: nil
+}
+let m = macro(q) { quote(unquote(q)) }
+`
+	expected := []string{
+		"Program 0:0",
+		"LetStmt 1:1", "Identifier 1:5", "HashLiteral 1:9",
+		"StringLiteral 1:10", "IntegerLiteral 1:15", "StringLiteral 1:18", "RangeArrayLiteral 1:25", "Identifier 1:24", "IntegerLiteral 1:27",
+		"IfStmt 2:1", "ConditionalStmt 2:1", "Identifier 2:5", "BlockStmt 2:8", "ExpressionStmt 2:10", "Identifier 2:10",
+		"ConditionalStmt 2:14", "ExpressionStmt 2:21", "ReassignmentStmt 2:23", "Identifier 2:21", "PrefixExpression 2:25", "Identifier 2:26",
+		"ForLoopStmt 3:1", "Identifier 3:5", "Identifier 3:15", "BlockStmt 3:17",
+		"ExpressionStmt 3:19", "PostfixExpression 3:28", "CallExpression 3:20", "Identifier 3:19", "SetLiteral 3:21", "IntegerLiteral 3:22", "IntegerLiteral 3:25",
+		"LetStmt 4:1", "Identifier 4:5", "MatchExpression 4:9", "Identifier 4:16",
+		"MatchCase 5:6", "IntegerLiteral 5:6", "ObjectMethodExpression 5:10", "Identifier 5:9", "Identifier 5:11", "NilLiteral 6:10",
+		"LetStmt 8:1", "Identifier 8:5", "MacroLiteral 8:9", "Identifier 8:15", "BlockStmt 8:18",
+		"ExpressionStmt 8:20", "CallExpression 8:25", "Identifier 8:20", "CallExpression 8:33", "Identifier 8:26", "Identifier 8:34",
+	}
+	got := nodeNames(parse(t, input))
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong nodes.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestInspect_Skip(t *testing.T) {
+	prog := parse(t, "let f = func(a) { a + 1 }\nf(2)")
+	var idents []string
+	ast.Inspect(prog, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FunctionLiteral); ok {
+			return false
+		}
+		if ident, ok := n.(*ast.Identifier); ok {
+			idents = append(idents, ident.Value)
+		}
+		return true
+	})
+	if got := strings.Join(idents, " "); got != "f f" {
+		t.Errorf("expected the function literal to be skipped, got %q", got)
+	}
+}
+
+// depthVisitor records the depth of each node, and checks the nil visits closing them
+type depthVisitor struct {
+	depth  *int
+	max    *int
+	visits int
+}
+
+func (v depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*v.depth--
+		return nil
+	}
+	*v.depth++
+	if *v.depth > *v.max {
+		*v.max = *v.depth
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	depth, max := 0, 0
+	ast.Walk(depthVisitor{depth: &depth, max: &max}, parse(t, "let a = [1, (2 + 3) * 4]"))
+	if depth != 0 {
+		t.Errorf("expected every node to be closed, got depth %d", depth)
+	}
+	// Program, LetStmt, ArrayLiteral, *, +, 2
+	if max != 6 {
+		t.Errorf("wrong maximum depth. expected=6, got=%d", max)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pre      ast.ApplyFunc
+		post     ast.ApplyFunc
+		expected string
+	}{
+		{
+			name:  "replace",
+			input: "let a = x + y * x",
+			pre: func(c *ast.Cursor) bool {
+				if ident, ok := c.Node().(*ast.Identifier); ok && ident.Value == "x" {
+					c.Replace(parse(t, "(z - 1)").Statements[0].(*ast.ExpressionStmt).Expr)
+				}
+				return true
+			},
+			expected: "let a = z - 1 + y * (z - 1)\n",
+		},
+		{
+			name:  "delete and insert statements",
+			input: "a; b; c",
+			pre: func(c *ast.Cursor) bool {
+				if c.Name() != "Statements" {
+					return true
+				}
+				switch c.Node().String() {
+				case "a":
+					c.InsertBefore(parse(t, "first").Statements[0])
+				case "b":
+					c.Delete()
+				case "c":
+					c.InsertAfter(parse(t, "last").Statements[0])
+				}
+				return true
+			},
+			expected: "first\na\nc\nlast\n",
+		},
+		{
+			name:  "match cases",
+			input: "let v = match (a) {\ncase 1: \"one\"\ncase 2: \"two\"\n}",
+			pre: func(c *ast.Cursor) bool {
+				if mc, ok := c.Node().(*ast.MatchCase); ok && mc.Pattern.String() == "1" {
+					c.Delete()
+				}
+				return true
+			},
+			expected: "let v = match (a) {\n    case 2: \"two\"\n}\n",
+		},
+		{
+			name:  "hash and set entries",
+			input: `let h = {"a": 1, "b": 2}; let s = {1, 2}`,
+			pre: func(c *ast.Cursor) bool {
+				switch n := c.Node().(type) {
+				case *ast.StringLiteral:
+					if n.Value == "a" {
+						c.Delete()
+					}
+				case *ast.IntegerLiteral:
+					if _, ok := c.Parent().(*ast.SetLiteral); ok && n.Value == 1 {
+						c.Replace(&ast.IntegerLiteral{Token: n.Token, Value: 3})
+					}
+				}
+				return true
+			},
+			expected: "let h = {\"b\": 2}\nlet s = {3, 2}\n",
+		},
+		{
+			name:  "post order",
+			input: "let a = 1 + 2 * 3",
+			post: func(c *ast.Cursor) bool {
+				// fold the constant infix expressions, from the leaves up
+				inf, ok := c.Node().(*ast.InfixExpression)
+				if !ok {
+					return true
+				}
+				left, lok := inf.Left.(*ast.IntegerLiteral)
+				right, rok := inf.Right.(*ast.IntegerLiteral)
+				if lok && rok {
+					value := left.Value + right.Value
+					if inf.Operator == "*" {
+						value = left.Value * right.Value
+					}
+					c.Replace(&ast.IntegerLiteral{Token: left.Token, Value: value})
+				}
+				return true
+			},
+			expected: "let a = 7\n",
+		},
+		{
+			name:  "abort",
+			input: "a\nb\nc",
+			post: func(c *ast.Cursor) bool {
+				if c.Name() == "Statements" {
+					c.Delete()
+					return c.Index() < 0 // stops after the first statement
+				}
+				return true
+			},
+			expected: "b\nc\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ast.Apply(parse(t, tt.input), tt.pre, tt.post)
+			if got := result.String(); got != tt.expected {
+				t.Errorf("wrong output.\nexpected=%q\ngot=     %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApply_Root(t *testing.T) {
+	prog := parse(t, "a")
+	expr := prog.Statements[0].(*ast.ExpressionStmt).Expr
+	result := ast.Apply(expr, func(c *ast.Cursor) bool {
+		if c.Parent() != nil {
+			t.Errorf("expected no parent for the root, got %T", c.Parent())
+		}
+		c.Replace(&ast.Identifier{Value: "b"})
+		return false
+	}, nil)
+	if got := result.String(); got != "b" {
+		t.Errorf("expected the root to be replaced, got %q", got)
+	}
+}
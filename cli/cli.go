@@ -0,0 +1,180 @@
+// Package cli implements the ede command line
+package cli
+
+import (
+	"ede/ast"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/parser"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Version is the version of ede
+const Version = "0.1.0"
+
+// Exit codes returned by the commands
+const (
+	ExitOK    = 0 // the command succeeded
+	ExitError = 1 // the program failed to parse or run, or a file could not be read
+	ExitUsage = 2 // the command line is invalid
+)
+
+// CLI runs the ede commands with its streams
+type CLI struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(c *CLI, args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "run", summary: "run a program", run: (*CLI).run},
+		{name: "repl", summary: "start an interactive session", run: (*CLI).repl},
+		{name: "check", summary: "parse and lint programs without running them", run: (*CLI).check},
+		{name: "debug", summary: "run a program under a debugger", run: (*CLI).debug},
+		{name: "lint", summary: "report likely mistakes in programs", run: (*CLI).lint},
+		{name: "test", summary: "run the tests of programs", run: (*CLI).test},
+		{name: "fmt", summary: "format programs", run: (*CLI).format},
+		{name: "tokens", summary: "print the tokens of a program", run: (*CLI).tokens},
+		{name: "ast", summary: "print the syntax tree of a program", run: (*CLI).ast},
+		{name: "lsp", summary: "start a language server", run: (*CLI).lsp},
+		{name: "version", summary: "print the ede version", run: (*CLI).version},
+	}
+}
+
+// Main runs the command line with the process' streams, and returns the exit code
+func Main(args []string) int {
+	c := &CLI{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	return c.Run(args)
+}
+
+// Run runs the command named by the first argument, and returns the exit code
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		c.usage(c.Stderr)
+		return ExitUsage
+	}
+
+	switch name := args[0]; name {
+	case "help", "-h", "-help", "--help":
+		c.usage(c.Stdout)
+		return ExitOK
+	case "-e":
+		if len(args) < 2 {
+			fmt.Fprintln(c.Stderr, "ede: flag -e requires the source to run")
+			return ExitUsage
+		}
+		return c.runSource("-e", args[1], append([]string{"-e"}, args[2:]...), runOptions{})
+	default:
+		for _, cmd := range commands {
+			if cmd.name == name {
+				return cmd.run(c, args[1:])
+			}
+		}
+		// `ede file.ede` is short for `ede run file.ede`
+		if strings.HasSuffix(name, ".ede") {
+			return c.run(args)
+		}
+		fmt.Fprintf(c.Stderr, "ede: unknown command %q\nRun 'ede help' for usage.\n", name)
+		return ExitUsage
+	}
+}
+
+func (c *CLI) usage(w io.Writer) {
+	fmt.Fprintln(w, "Ede is a scripting language.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\tede <command> [arguments]")
+	fmt.Fprintln(w, "\tede file.ede [args...]")
+	fmt.Fprintln(w, "\tede -e 'source' [args...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The commands are:")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t%-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Use 'ede <command> --help' for more information about a command.")
+}
+
+// flagSet returns the flag set of a command, whose usage is printed on --help or on invalid flags
+func (c *CLI) flagSet(name, args, summary string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: ede %s %s\n\n%s\n", name, args, summary)
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(flags.Output(), "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// parseFlags parses the arguments of a command. ok is false if the command should
+// stop, and code is then the exit code to return
+func (c *CLI) parseFlags(flags *flag.FlagSet, args []string, minArgs int) (code int, ok bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	if flags.NArg() < minArgs {
+		flags.Usage()
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+// readSource reads the file at path, or the input stream if the path is "-"
+func (c *CLI) readSource(path string) (string, bool) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(c.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+		return "", false
+	}
+	return string(data), true
+}
+
+func parse(src string) (*ast.Program, error) {
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		return prog, prog.ParseErrors
+	}
+	return prog, nil
+}
+
+// parseExpanded parses the program, and expands its macros
+func parseExpanded(src string) (*ast.Program, error) {
+	prog, err := parse(src)
+	if err != nil {
+		return prog, err
+	}
+	if err := evaluator.Expand(prog); err != nil {
+		return prog, errors.New(err.Message)
+	}
+	return prog, nil
+}
@@ -0,0 +1,336 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testRun(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	c := &CLI{Stdin: strings.NewReader(stdin), Stdout: &out, Stderr: &errOut}
+	code = c.Run(args)
+	return code, out.String(), errOut.String()
+}
+
+func writeFile(t *testing.T, name, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	script := writeFile(t, "main.ede", "#!/usr/bin/env ede\nimport os\nprintln(os.args())\n")
+	failing := writeFile(t, "fail.ede", "let a = 1 * \"a\"\n")
+	exiting := writeFile(t, "exit.ede", "import os\nos.exit(3)\n")
+	invalid := writeFile(t, "invalid.ede", "let = 1\n")
+	scriptDir := writeFile(t, "dir.ede", "import path\nprintln(path.script_dir())\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "run", args: []string{"run", script, "a", "-b"}, code: ExitOK, stdout: "[" + script + ", a, -b]\n"},
+		{name: "file shorthand", args: []string{script}, code: ExitOK, stdout: "[" + script + "]\n"},
+		{name: "stdin", args: []string{"run", "-"}, stdin: `println("from stdin")`, code: ExitOK, stdout: "from stdin\n"},
+		{name: "eval", args: []string{"-e", `import os; println(1 + 1, os.args())`, "x"}, code: ExitOK, stdout: "2 [-e, x]\n"},
+		{name: "final value", args: []string{"-e", `let a = [1, 2]; a.length() + 1`}, code: ExitOK, stdout: "3\n"},
+		{name: "script dir", args: []string{"run", scriptDir}, code: ExitOK, stdout: filepath.Dir(scriptDir) + "\n"},
+		{name: "runtime error", args: []string{"run", failing}, code: ExitError, stderr: "invalid infix operator"},
+		{name: "parse error", args: []string{"run", invalid}, code: ExitError, stderr: "expected token IDENT"},
+		{name: "exit code", args: []string{"run", exiting}, code: 3},
+		{name: "missing file", args: []string{"run", "missing.ede"}, code: ExitError, stderr: "no such file"},
+		{name: "missing argument", args: []string{"run"}, code: ExitUsage, stderr: "usage: ede run"},
+		{name: "help", args: []string{"run", "--help"}, code: ExitOK, stderr: "usage: ede run"},
+		{name: "unknown command", args: []string{"foo"}, code: ExitUsage, stderr: `unknown command "foo"`},
+		{name: "no command", args: []string{}, code: ExitUsage, stderr: "The commands are:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := testRun(t, tt.stdin, tt.args...)
+			if code != tt.code {
+				t.Errorf("wrong exit code. expected=%d, got=%d (stderr: %s)", tt.code, code, stderr)
+			}
+			if tt.stdout != "" && stdout != tt.stdout {
+				t.Errorf("wrong stdout. expected=%q, got=%q", tt.stdout, stdout)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr %q does not contain %q", stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestInspectCommands(t *testing.T) {
+	script := writeFile(t, "main.ede", "let a = 10\n")
+	clean := writeFile(t, "clean.ede", "let a = 10\nprintln(a)\n")
+
+	tests := []struct {
+		args   []string
+		stdout string
+	}{
+		{args: []string{"tokens", script}, stdout: "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"a\"\n1:7\t=\t\"=\"\n1:9\tINT\t\"10\"\n1:11\tNEWLINE\t\"\\n\"\n1:11\tEOF\t\"\"\n"},
+		{args: []string{"ast", script}, stdout: "Program 0:0\n  Statements[0]: LetStmt 1:1\n    Name: Identifier 1:5 Value=\"a\"\n    Expr: IntegerLiteral 1:9 Value=10\n"},
+		{args: []string{"ast", "-json", script}, stdout: `"type": "IntegerLiteral"`},
+		{args: []string{"check", clean}, stdout: ""},
+		{args: []string{"version"}, stdout: "ede version " + Version},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			code, stdout, stderr := testRun(t, "", tt.args...)
+			if code != ExitOK {
+				t.Fatalf("wrong exit code. expected=%d, got=%d (stderr: %s)", ExitOK, code, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout %q does not contain %q", stdout, tt.stdout)
+			}
+		})
+	}
+
+	t.Run("check error", func(t *testing.T) {
+		invalid := writeFile(t, "invalid.ede", "let = 1\n")
+		code, _, stderr := testRun(t, "", "check", clean, invalid)
+		if code != ExitError {
+			t.Fatalf("wrong exit code. expected=%d, got=%d", ExitError, code)
+		}
+		if !strings.HasPrefix(stderr, invalid+": ") {
+			t.Errorf("expected error to be reported for %s, got %q", invalid, stderr)
+		}
+	})
+
+	t.Run("check diagnostics", func(t *testing.T) {
+		code, _, stderr := testRun(t, "", "check", script)
+		if code != ExitError {
+			t.Fatalf("wrong exit code. expected=%d, got=%d", ExitError, code)
+		}
+		expected := script + ":1:5: variable a is declared but not used (unused)\n"
+		if stderr != expected {
+			t.Errorf("wrong diagnostics. expected=%q, got=%q", expected, stderr)
+		}
+	})
+}
+
+func TestFormat(t *testing.T) {
+	src := "let a = [1,2];\nif (a.length() > 1){ println(a) }\n"
+	formatted := "let a = [1, 2]\nif (a.length() > 1) {\n    println(a)\n}\n"
+
+	t.Run("print", func(t *testing.T) {
+		code, stdout, stderr := testRun(t, src, "fmt", "-")
+		if code != ExitOK {
+			t.Fatalf("wrong exit code. expected=%d, got=%d (stderr: %s)", ExitOK, code, stderr)
+		}
+		if stdout != formatted {
+			t.Errorf("wrong stdout. expected=%q, got=%q", formatted, stdout)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		path := writeFile(t, "main.ede", src)
+		_, stdout, _ := testRun(t, "", "fmt", "-d", path)
+		for _, line := range []string{"--- " + path, "-let a = [1,2];", "+let a = [1, 2]", "+    println(a)"} {
+			if !strings.Contains(stdout, line+"\n") {
+				t.Errorf("diff %q does not contain %q", stdout, line)
+			}
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		path := writeFile(t, "main.ede", src)
+		code, stdout, _ := testRun(t, "", "fmt", "-w", "-l", path)
+		if code != ExitOK || stdout != path+"\n" {
+			t.Fatalf("expected the file to be listed, got code=%d stdout=%q", code, stdout)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != formatted {
+			t.Errorf("wrong file content. expected=%q, got=%q", formatted, data)
+		}
+
+		// formatting again changes nothing
+		_, stdout, _ = testRun(t, "", "fmt", "-l", "-d", path)
+		if stdout != "" {
+			t.Errorf("expected no changes, got %q", stdout)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		code, _, stderr := testRun(t, "let = 1", "fmt", "-")
+		if code != ExitError || !strings.HasPrefix(stderr, "-: ") {
+			t.Errorf("expected the parse error to be reported, got code=%d stderr=%q", code, stderr)
+		}
+	})
+}
+
+func TestRunProfile(t *testing.T) {
+	script := writeFile(t, "main.ede", "let double = func(n) {\n    return n * 2\n}\nprintln(double(2))\n")
+	profileFile := filepath.Join(t.TempDir(), "out.pprof")
+
+	code, stdout, stderr := testRun(t, "", "run", "-profile", profileFile, script)
+	if code != ExitOK || stdout != "4\n" {
+		t.Fatalf("wrong result. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+	for _, exp := range []string{"double (" + script + ":1)\n", "       1      4  println(double(2))\n"} {
+		if !strings.Contains(stderr, exp) {
+			t.Errorf("stderr %q does not contain %q", stderr, exp)
+		}
+	}
+	if info, err := os.Stat(profileFile); err != nil || info.Size() == 0 {
+		t.Errorf("expected a profile to be written, got %v", err)
+	}
+}
+
+func TestMacros(t *testing.T) {
+	script := writeFile(t, "main.ede", "let twice = macro(x) { quote(unquote(x) * 2) }\nprintln(twice(3))\n")
+
+	code, stdout, stderr := testRun(t, "", "run", script)
+	if code != ExitOK || stdout != "6\n" {
+		t.Errorf("wrong result. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = testRun(t, "", "ast", "-expand", script)
+	exp := "Program 0:0\n  Statements[0]: ExpressionStmt 2:1\n    Expr: CallExpression 2:8\n      Function: Identifier 2:1 Value=\"println\"\n      Args[0]: InfixExpression 1:41 Operator=\"*\"\n"
+	if code != ExitOK || !strings.HasPrefix(stdout, exp) {
+		t.Errorf("wrong expanded tree. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+
+	invalid := writeFile(t, "invalid.ede", "let m = macro() { 1 }\nm()\n")
+	code, _, stderr = testRun(t, "", "run", invalid)
+	if code != ExitError || !strings.Contains(stderr, "macro m must return a quote, got INT") {
+		t.Errorf("expected the expansion error, got code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestRunTrace(t *testing.T) {
+	script := writeFile(t, "main.ede", "import json\nprintln(json.string({\"a\": 1}))\n")
+
+	code, stdout, stderr := testRun(t, "", "run", "-trace", script)
+	if code != ExitOK || stdout != "{\"a\":1}\n" {
+		t.Fatalf("wrong result. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+	exp := `{"event":"module","line":2,"column":14,"depth":0,"name":"json.string","args":["{\n  a: 1\n}"],"result":"{\"a\":1}"}`
+	if !strings.Contains(stderr, exp+"\n") {
+		t.Errorf("stderr %q does not contain %q", stderr, exp)
+	}
+}
+
+func TestCover(t *testing.T) {
+	script := writeFile(t, "main.ede", "let sign = func(n) {\n    if (n < 0) {\n        return -1\n    }\n    return 1\n}\nprintln(sign(2))\n")
+	tests := writeFile(t, "sign_test.ede", "import testing\nlet sign = func(n) {\n    if (n < 0) {\n        return -1\n    }\n    return 1\n}\nlet test_sign = func() {\n    testing.assert_eq(sign(-2), -1)\n}\n")
+	lcov := filepath.Join(t.TempDir(), "cover.lcov")
+	html := filepath.Join(t.TempDir(), "cover.html")
+
+	code, stdout, stderr := testRun(t, "", "run", "-cover", "-lcov", lcov, "-coverhtml", html, script)
+	if code != ExitOK || stdout != "1\n" {
+		t.Fatalf("wrong result. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+	if expected := script + ": 80.0% of statements, 50.0% of branches\n"; stderr != expected {
+		t.Errorf("wrong summary. expected=%q, got=%q", expected, stderr)
+	}
+	for file, exp := range map[string]string{lcov: "DA:3,0\n", html: `<tr class="uncovered"><td class="num">3</td>`} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), exp) {
+			t.Errorf("%s does not contain %q", file, exp)
+		}
+	}
+
+	code, stdout, stderr = testRun(t, "", "test", "-cover", tests)
+	if code != ExitOK || !strings.Contains(stdout, "ok\t1 passed") || !strings.HasSuffix(stdout, tests+": 85.7% of statements, 50.0% of branches\n") {
+		t.Errorf("wrong test coverage. code=%d stdout=%q stderr=%q", code, stdout, stderr)
+	}
+}
+
+func TestLint(t *testing.T) {
+	clean := writeFile(t, "clean.ede", "let a = 1\nprintln(a)\n")
+	mistakes := writeFile(t, "mistakes.ede", "import json\njson.prase(\"1\")\nb = 2\n")
+
+	code, stdout, _ := testRun(t, "", "lint", clean)
+	if code != ExitOK || stdout != "" {
+		t.Errorf("expected no diagnostics, got code=%d stdout=%q", code, stdout)
+	}
+
+	code, stdout, _ = testRun(t, "", "lint", mistakes)
+	expected := mistakes + ":2:6: module json has no function prase (unknown-function)\n" +
+		mistakes + ":3:1: assignment to undeclared name b, declare it with let (undeclared)\n"
+	if code != ExitError || stdout != expected {
+		t.Errorf("wrong diagnostics. code=%d\nexpected=%q\ngot=     %q", code, expected, stdout)
+	}
+
+	code, stdout, _ = testRun(t, "", "lint", "-rules")
+	if code != ExitOK || !strings.Contains(stdout, "unreachable       statement after a return\n") {
+		t.Errorf("expected the rules, got code=%d stdout=%q", code, stdout)
+	}
+}
+
+func TestTest(t *testing.T) {
+	file := writeFile(t, "math_test.ede", "import testing\nlet test_pass = func() {\n    testing.assert(true)\n}\nlet test_fail = func() {\n    testing.assert_eq(1, 2)\n}\n")
+	invalid := writeFile(t, "invalid_test.ede", "let = 1\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"text", []string{"test", file}, ExitError, "--- PASS: test_pass", ""},
+		{"failure position", []string{"test", file}, ExitError, file + ":6:13: assert_eq failed: got 1, want 2", ""},
+		{"filter", []string{"test", "-run", "pass", file}, ExitOK, "ok\t1 passed, 0 failed, 0 skipped", ""},
+		{"tap", []string{"test", "-format", "tap", "-run", "pass", file}, ExitOK, "TAP version 13\n1..1\nok 1 - " + file + " test_pass\n", ""},
+		{"junit", []string{"test", "-format", "junit", file}, ExitError, `<testcase name="test_fail"`, ""},
+		{"parse error", []string{"test", invalid}, ExitError, "ok\t0 passed", invalid + ": 1 error occurred"},
+		{"invalid format", []string{"test", "-format", "xml", file}, ExitUsage, "", `unknown format "xml"`},
+		{"invalid filter", []string{"test", "-run", "(", file}, ExitUsage, "", "invalid -run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := testRun(t, "", tt.args...)
+			if code != tt.code {
+				t.Errorf("wrong exit code. expected=%d, got=%d (stderr: %s)", tt.code, code, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout %q does not contain %q", stdout, tt.stdout)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr %q does not contain %q", stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestDebug(t *testing.T) {
+	script := writeFile(t, "main.ede", "let name = input()\nprintln(\"hi \" + name)\n")
+
+	// the commands and the input of the program are read from the same stream
+	code, stdout, stderr := testRun(t, "n\nbob\np name\nc\n", "debug", script)
+	if code != ExitOK {
+		t.Fatalf("wrong exit code. expected=%d, got=%d (stderr: %s)", ExitOK, code, stderr)
+	}
+	for _, exp := range []string{"stopped at line 1 (entry)", "stopped at line 2 (step)", "(debug) \"bob\"\n", "hi bob\n"} {
+		if !strings.Contains(stdout, exp) {
+			t.Errorf("stdout %q does not contain %q", stdout, exp)
+		}
+	}
+
+	code, _, stderr = testRun(t, "", "debug")
+	if code != ExitUsage || !strings.Contains(stderr, "usage: ede debug") {
+		t.Errorf("expected the usage, got code=%d stderr=%q", code, stderr)
+	}
+}
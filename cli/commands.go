@@ -0,0 +1,507 @@
+package cli
+
+import (
+	"ede/ast"
+	"ede/cover"
+	"ede/debug"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/lint"
+	"ede/lsp"
+	"ede/object"
+	"ede/profile"
+	"ede/repl"
+	"ede/tester"
+	"ede/token"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+)
+
+func (c *CLI) run(args []string) int {
+	flags := c.flagSet("run", "[-profile file] [-cover] [-trace] file.ede [args...]", "Run runs the program in the file, passing it the remaining arguments, and prints the value\nof its last statement unless it is nil. The file \"-\" reads the program from the standard input.")
+	profileFile := flags.String("profile", "", "profile the program: write a report of its functions and lines to the error stream,\nand a profile to the file, to read with `go tool pprof`")
+	trace := flags.Bool("trace", false, "write the statements, calls, returns, branches and errors of the program to the error stream,\nas JSON lines")
+	coverage := c.coverFlags(flags)
+	if code, ok := c.parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	src, ok := c.readSource(flags.Arg(0))
+	if !ok {
+		return ExitError
+	}
+	return c.runSource(flags.Arg(0), src, flags.Args(), runOptions{profileFile: *profileFile, cover: coverage, trace: *trace})
+}
+
+// runOptions are the optional measures of a run
+type runOptions struct {
+	profileFile string // the pprof file of the profile, not profiled if empty
+	cover       *coverOptions
+	trace       bool // whether to write a trace to the error stream
+}
+
+// runSource runs the program, and returns its exit code. Parse and runtime
+// errors are written to the error stream.
+func (c *CLI) runSource(name, src string, args []string, opts runOptions) int {
+	prog, err := parseExpanded(src)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "%s: %s\n", name, err)
+		return ExitError
+	}
+
+	e := &evaluator.Evaluator{Stdin: c.Stdin, Stdout: c.Stdout, Stderr: c.Stderr, Args: args}
+	if name != "-" && name != "-e" { // the program of the input stream or of -e has no file
+		e.File = name
+	}
+	var p *profile.Profiler
+	if opts.profileFile != "" {
+		p = profile.New(e, name)
+		p.Start()
+	}
+	var coverage *cover.Coverage
+	if opts.cover.enabled() {
+		coverage = cover.New()
+		coverage.Track(e, name, src, prog)
+	}
+	if opts.trace {
+		e.AddHook(evaluator.NewTracer(c.Stderr))
+	}
+	result := e.Eval(prog, object.NewEnvironment(nil))
+	if p != nil {
+		p.Stop()
+		if err := c.writeProfile(p, src, opts.profileFile); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+			return ExitError
+		}
+	}
+	if coverage != nil {
+		if err := c.writeCoverage(coverage, opts.cover, c.Stderr); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+			return ExitError
+		}
+	}
+
+	switch result := result.(type) {
+	case *object.Exit:
+		return result.Code
+	case *object.Error:
+		fmt.Fprintf(c.Stderr, "%s: %s\n", name, result.Message)
+		return ExitError
+	case nil, *object.Nil:
+	default: // the value of the last statement
+		fmt.Fprintln(c.Stdout, result.Inspect())
+	}
+	return ExitOK
+}
+
+// coverOptions are the flags of the coverage of run and test
+type coverOptions struct {
+	summary  *bool
+	lcovFile *string
+	htmlFile *string
+}
+
+func (c *CLI) coverFlags(flags *flag.FlagSet) *coverOptions {
+	return &coverOptions{
+		summary:  flags.Bool("cover", false, "measure the coverage of the statements and branches, and print the percentages of each file"),
+		lcovFile: flags.String("lcov", "", "measure the coverage, and write it to the file in the LCOV format"),
+		htmlFile: flags.String("coverhtml", "", "measure the coverage, and write an annotated report of the sources to the HTML file"),
+	}
+}
+
+func (o *coverOptions) enabled() bool {
+	return o != nil && (*o.summary || *o.lcovFile != "" || *o.htmlFile != "")
+}
+
+// writeCoverage writes the summary of the coverage to the stream, and the reports
+// requested to their files
+func (c *CLI) writeCoverage(coverage *cover.Coverage, opts *coverOptions, summary io.Writer) error {
+	coverage.WriteSummary(summary)
+	reports := []struct {
+		file  string
+		write func(io.Writer) error
+	}{
+		{*opts.lcovFile, coverage.WriteLCOV},
+		{*opts.htmlFile, coverage.WriteHTML},
+	}
+	for _, r := range reports {
+		if r.file == "" {
+			continue
+		}
+		f, err := os.Create(r.file)
+		if err != nil {
+			return err
+		}
+		if err := r.write(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProfile writes the report of the profile to the error stream, and the
+// profile to the file in the pprof format
+func (c *CLI) writeProfile(p *profile.Profiler, src, file string) error {
+	if err := p.WriteText(c.Stderr, src); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := p.WritePprof(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *CLI) repl(args []string) int {
+	flags := c.flagSet("repl", "[-history file]", "Repl starts an interactive session. Type :help in the session for its commands.")
+	historyFile := flags.String("history", defaultHistoryFile(), "the file keeping the lines entered, none if empty")
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+	return (&repl.REPL{In: c.Stdin, Out: c.Stdout, HistoryFile: *historyFile}).Run()
+}
+
+// defaultHistoryFile returns the history file of the repl, $EDE_HISTORY or ~/.ede_history
+func defaultHistoryFile() string {
+	if file, ok := os.LookupEnv("EDE_HISTORY"); ok {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ede_history")
+}
+
+func (c *CLI) check(args []string) int {
+	flags := c.flagSet("check", "file.ede...", "Check parses and lints the programs, and reports their errors and diagnostics without\nrunning them.")
+	if code, ok := c.parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	code := ExitOK
+	for _, path := range flags.Args() {
+		src, ok := c.readSource(path)
+		if !ok {
+			code = ExitError
+			continue
+		}
+		prog, err := parse(src)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "%s: %s\n", path, err)
+			code = ExitError
+			continue
+		}
+		for _, d := range lint.Lint(prog) {
+			fmt.Fprintf(c.Stderr, "%s:%s\n", path, d)
+			code = ExitError
+		}
+	}
+	return code
+}
+
+func (c *CLI) lint(args []string) int {
+	flags := c.flagSet("lint", "[-rules] file.ede...", "Lint reports the likely mistakes of the programs without running them, e.g. unused\nbindings or calls of unknown module functions. A diagnostic is silenced by a comment\n\"// ede:ignore RULE\" on its line or on the line before.")
+	listRules := flags.Bool("rules", false, "list the rules and exit")
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+	if *listRules {
+		ids := make([]string, 0, len(lint.Rules))
+		for id := range lint.Rules {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Fprintf(c.Stdout, "%-17s %s\n", id, lint.Rules[id])
+		}
+		return ExitOK
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	code := ExitOK
+	for _, path := range flags.Args() {
+		src, ok := c.readSource(path)
+		if !ok {
+			code = ExitError
+			continue
+		}
+		prog, err := parse(src)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "%s: %s\n", path, err)
+			code = ExitError
+			continue
+		}
+		for _, d := range lint.Lint(prog) {
+			fmt.Fprintf(c.Stdout, "%s:%s\n", path, d)
+			code = ExitError
+		}
+	}
+	return code
+}
+
+func (c *CLI) test(args []string) int {
+	flags := c.flagSet("test", "[-run regexp] [-format text|tap|junit] [-cover] [path...]", "Test runs the test_* functions of the *_test.ede files found in the paths, the current\ndirectory by default. Each test runs in a new environment, and fails if it returns an\nerror, e.g. a failed assertion of the testing module.")
+	run := flags.String("run", "", "run only the tests whose name matches the regular expression")
+	format := flags.String("format", "text", "the format of the report: text, tap or junit")
+	coverage := c.coverFlags(flags)
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+
+	var filter *regexp.Regexp
+	if *run != "" {
+		var err error
+		if filter, err = regexp.Compile(*run); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: invalid -run: %s\n", err)
+			return ExitUsage
+		}
+	}
+	if *format != "text" && *format != "tap" && *format != "junit" {
+		fmt.Fprintf(c.Stderr, "ede: unknown format %q\n", *format)
+		return ExitUsage
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := tester.Discover(paths)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+		return ExitError
+	}
+
+	opts := tester.Options{Run: filter}
+	var cov *cover.Coverage
+	if coverage.enabled() {
+		cov = cover.New()
+		opts.Setup = func(e *evaluator.Evaluator, file, src string, prog *ast.Program) {
+			cov.Track(e, file, src, prog)
+		}
+	}
+
+	code := ExitOK
+	var results []tester.Result
+	for _, file := range files {
+		res, err := tester.RunFile(file, opts)
+		if err != nil {
+			fmt.Fprintln(c.Stderr, err)
+			code = ExitError
+			continue
+		}
+		results = append(results, res...)
+	}
+	if tester.Summarize(results).Failed > 0 {
+		code = ExitError
+	}
+
+	switch *format {
+	case "tap":
+		tester.WriteTAP(c.Stdout, results)
+	case "junit":
+		if err := tester.WriteJUnit(c.Stdout, results); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+			return ExitError
+		}
+	default:
+		tester.WriteText(c.Stdout, results)
+	}
+	if cov != nil {
+		// the summary follows the text report, and keeps the other formats valid
+		summary := c.Stdout
+		if *format != "text" {
+			summary = c.Stderr
+		}
+		if err := c.writeCoverage(cov, coverage, summary); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+			return ExitError
+		}
+	}
+	return code
+}
+
+func (c *CLI) format(args []string) int {
+	flags := c.flagSet("fmt", "[-w] [-d] [-l] file.ede...", "Fmt formats the programs in their canonical style, and prints the result.\nThe file \"-\" reads the program from the standard input.")
+	write := flags.Bool("w", false, "write the result to the file instead of printing it")
+	diff := flags.Bool("d", false, "print a diff of the changes instead of the result")
+	list := flags.Bool("l", false, "print the files whose formatting differs instead of the result")
+	if code, ok := c.parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	code := ExitOK
+	for _, path := range flags.Args() {
+		src, ok := c.readSource(path)
+		if !ok {
+			code = ExitError
+			continue
+		}
+		prog, err := parse(src)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "%s: %s\n", path, err)
+			code = ExitError
+			continue
+		}
+
+		formatted := prog.String()
+		changed := formatted != src
+		if *list && changed {
+			fmt.Fprintln(c.Stdout, path)
+		}
+		if *diff {
+			fmt.Fprint(c.Stdout, unifiedDiff(path, src, formatted))
+		}
+		if *write && changed && path != "-" {
+			if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+				code = ExitError
+			}
+		}
+		if !*list && !*diff && (!*write || path == "-") {
+			fmt.Fprint(c.Stdout, formatted)
+		}
+	}
+	return code
+}
+
+func (c *CLI) tokens(args []string) int {
+	flags := c.flagSet("tokens", "file.ede", "Tokens prints the tokens of the program, one per line, with their line and column.")
+	if code, ok := c.parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	src, ok := c.readSource(flags.Arg(0))
+	if !ok {
+		return ExitError
+	}
+	l := lexer.New(src)
+	for {
+		tok := l.NextToken()
+		typ := tok.Type
+		if typ == token.NEWLINE {
+			typ = "NEWLINE"
+		}
+		fmt.Fprintf(c.Stdout, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, typ, tok.Literal)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	return ExitOK
+}
+
+func (c *CLI) ast(args []string) int {
+	flags := c.flagSet("ast", "[-json] [-expand] file.ede", "Ast prints the syntax tree of the program.")
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	expand := flags.Bool("expand", false, "print the tree once the macros are expanded")
+	if code, ok := c.parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	src, ok := c.readSource(flags.Arg(0))
+	if !ok {
+		return ExitError
+	}
+	parseSource := parse
+	if *expand {
+		parseSource = parseExpanded
+	}
+	prog, err := parseSource(src)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "%s: %s\n", flags.Arg(0), err)
+		return ExitError
+	}
+
+	dump := ast.Dump
+	if *asJSON {
+		dump = ast.DumpJSON
+	}
+	if err := dump(c.Stdout, prog); err != nil {
+		fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func (c *CLI) debug(args []string) int {
+	flags := c.flagSet("debug", "file.ede [args...] | -dap", "Debug runs the program under an interactive debugger, stopping before its first statement.\nWith -dap, it speaks the Debug Adapter Protocol over the standard streams instead, and\nthe program is given by the launch request of the editor.")
+	dap := flags.Bool("dap", false, "start a debug adapter")
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+
+	if *dap {
+		if err := debug.NewDAP(c.Stdin, c.Stdout).Serve(); err != nil {
+			fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+			return ExitError
+		}
+		return ExitOK
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	path := flags.Arg(0)
+	src, ok := c.readSource(path)
+	if !ok {
+		return ExitError
+	}
+	prog, err := parseExpanded(src)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "%s: %s\n", path, err)
+		return ExitError
+	}
+
+	e := &evaluator.Evaluator{Stdin: c.Stdin, Stdout: c.Stdout, Stderr: c.Stderr, Args: flags.Args()}
+	if path != "-" {
+		e.File = path
+	}
+	d := debug.New(e, prog)
+	// the commands and the program share the input stream
+	switch result := debug.NewTerminal(d, src, e.Streams().Stdin, c.Stdout).Run().(type) {
+	case *object.Exit:
+		return result.Code
+	case *object.Error:
+		fmt.Fprintf(c.Stderr, "%s: %s\n", path, result.Message)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func (c *CLI) lsp(args []string) int {
+	flags := c.flagSet("lsp", "", "Lsp starts a language server, speaking the Language Server Protocol over the standard streams.")
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+	if err := lsp.NewServer(c.Stdin, c.Stdout).Serve(); err != nil {
+		fmt.Fprintf(c.Stderr, "ede: %s\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func (c *CLI) version(args []string) int {
+	flags := c.flagSet("version", "", "Version prints the ede version.")
+	if code, ok := c.parseFlags(flags, args, 0); !ok {
+		return code
+	}
+	fmt.Fprintf(c.Stdout, "ede version %s %s/%s\n", Version, runtime.GOOS, runtime.GOARCH)
+	return ExitOK
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines printed around the changes
+const diffContext = 3
+
+// diffOp is a line of a diff, prefixed by ' ', '-' or '+'
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the changes from a to b in the unified format, or an empty
+// string if they are equal
+func unifiedDiff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", name, name)
+	for start := 0; start < len(ops); {
+		// find the next change, and the end of its hunk
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+		to, unchanged := first, 0
+		for to < len(ops) && unchanged <= 2*diffContext {
+			if ops[to].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			to++
+		}
+		if unchanged > diffContext {
+			to -= unchanged - diffContext
+		}
+		writeHunk(buf, ops, from, to)
+		start = to
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *strings.Builder, ops []diffOp, from, to int) {
+	lineA, lineB := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			lineA++
+		}
+		if op.kind != '-' {
+			lineB++
+		}
+	}
+	countA, countB := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			countA++
+		}
+		if op.kind != '-' {
+			countB++
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB)
+	for _, op := range ops[from:to] {
+		fmt.Fprintf(buf, "%c%s\n", op.kind, op.line)
+	}
+}
+
+// diffLines returns the edits turning a into b, from their longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
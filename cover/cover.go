@@ -0,0 +1,196 @@
+// Package cover measures the statements and branches of programs that run. The
+// branches are the alternatives of if statements, the cases of match expressions
+// and the bodies of for loops.
+package cover
+
+import (
+	"ede/ast"
+	"ede/evaluator"
+	"ede/object"
+	"ede/scope"
+	"ede/token"
+	"sort"
+)
+
+// Coverage is the coverage of a set of files
+type Coverage struct {
+	files map[string]*File
+	order []string
+}
+
+// New returns an empty coverage
+func New() *Coverage {
+	return &Coverage{files: make(map[string]*File)}
+}
+
+// File is the coverage of a file
+type File struct {
+	Name       string
+	Src        string
+	Statements []*Statement // in the order of the source
+	Branches   []*Branch    // in the order of the source
+
+	prog       *ast.Program
+	statements map[ast.Statement]*Statement
+	branches   map[ast.Node][]*Branch
+}
+
+// Statement is a statement and the number of times it ran
+type Statement struct {
+	Pos   token.Pos
+	Count int
+}
+
+// Branch is a branch of a statement, and the number of times it was taken
+type Branch struct {
+	Pos   token.Pos // the position of the if, match or for
+	Block int       // the index of the statement in the branches of the file
+	Index int       // the index of the branch in the statement
+	Label string    // e.g. "else", "case 2"
+	Count int
+}
+
+// Track records the coverage of the program, from the file, evaluated by the
+// evaluator. A file can be tracked by several evaluators, e.g. one per test, as
+// long as they evaluate the same program.
+func (c *Coverage) Track(e *evaluator.Evaluator, name, src string, prog *ast.Program) {
+	f, ok := c.files[name]
+	if !ok || f.prog != prog {
+		f = newFile(name, src, prog)
+		if !ok {
+			c.order = append(c.order, name)
+		}
+		c.files[name] = f
+	}
+	e.AddHook(&hook{file: f})
+}
+
+// Files returns the coverage of the files, in the order they were tracked
+func (c *Coverage) Files() []*File {
+	files := make([]*File, len(c.order))
+	for i, name := range c.order {
+		files[i] = c.files[name]
+	}
+	return files
+}
+
+// hook records the statements and branches of a file
+type hook struct {
+	evaluator.NopHook
+	file *File
+}
+
+func (h *hook) Statement(stmt ast.Statement, env *object.Environment) {
+	if s, ok := h.file.statements[stmt]; ok {
+		s.Count++
+	}
+}
+
+func (h *hook) Branch(node ast.Node, branch int) {
+	if branches, ok := h.file.branches[node]; ok && branch < len(branches) {
+		branches[branch].Count++
+	}
+}
+
+// newFile collects the statements and branches of the program
+func newFile(name, src string, prog *ast.Program) *File {
+	f := &File{
+		Name:       name,
+		Src:        src,
+		prog:       prog,
+		statements: make(map[ast.Statement]*Statement),
+		branches:   make(map[ast.Node][]*Branch),
+	}
+	var branchNodes []ast.Node
+	ast.Inspect(prog, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			f.addStatements(node.Statements)
+		case *ast.BlockStmt:
+			f.addStatements(node.Statements)
+		case *ast.ConditionalStmt:
+			if _, ok := node.Statement.(*ast.BlockStmt); !ok {
+				f.addStatements([]ast.Statement{node.Statement})
+			}
+		case *ast.IfStmt, *ast.MatchExpression, *ast.ForLoopStmt:
+			branchNodes = append(branchNodes, node)
+		}
+		return true
+	})
+
+	sort.SliceStable(f.Statements, func(i, j int) bool { return scope.Before(f.Statements[i].Pos, f.Statements[j].Pos) })
+	sort.SliceStable(branchNodes, func(i, j int) bool { return scope.Before(branchNodes[i].Pos(), branchNodes[j].Pos()) })
+	for block, node := range branchNodes {
+		for i, label := range branchLabels(node) {
+			b := &Branch{Pos: node.Pos(), Block: block, Index: i, Label: label}
+			f.branches[node] = append(f.branches[node], b)
+			f.Branches = append(f.Branches, b)
+		}
+	}
+	return f
+}
+
+func (f *File) addStatements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*ast.CommentStmt); ok || stmt == nil {
+			continue
+		}
+		s := &Statement{Pos: stmt.Pos()}
+		f.statements[stmt] = s
+		f.Statements = append(f.Statements, s)
+	}
+}
+
+// branchLabels returns the labels of the branches of the node, in the order of
+// the branches of evaluator.Hook
+func branchLabels(node ast.Node) []string {
+	switch node := node.(type) {
+	case *ast.IfStmt:
+		labels := []string{"if"}
+		for _, alt := range node.Alternatives {
+			if alt.Condition == nil {
+				return append(labels, "else")
+			}
+			labels = append(labels, "else if")
+		}
+		// the implicit else, when no condition holds
+		return append(labels, "no branch")
+	case *ast.MatchExpression:
+		labels := make([]string, 0, len(node.Cases)+1)
+		for _, c := range node.Cases {
+			labels = append(labels, "case "+c.Pattern.String())
+		}
+		if node.Default != nil {
+			return append(labels, "default")
+		}
+		return append(labels, "no case")
+	case *ast.ForLoopStmt:
+		return []string{"body", "no iteration"}
+	}
+	return nil
+}
+
+// Percent returns the percentage of the statements that ran, and of the branches
+// taken. A percentage is 100 when there is nothing to cover.
+func (f *File) Percent() (statements, branches float64) {
+	covered := 0
+	for _, s := range f.Statements {
+		if s.Count > 0 {
+			covered++
+		}
+	}
+	taken := 0
+	for _, b := range f.Branches {
+		if b.Count > 0 {
+			taken++
+		}
+	}
+	return percent(covered, len(f.Statements)), percent(taken, len(f.Branches))
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(n) / float64(total)
+}
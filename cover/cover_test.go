@@ -0,0 +1,135 @@
+package cover
+
+import (
+	"bytes"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const src = `let classify = func(n) {
+    if (n < 0) {
+        return "negative"
+    } else if (n == 0) {
+        return "zero"
+    }
+    return "positive"
+}
+let name = match (classify(1)) {
+    case "zero": "z"
+    default: "other"
+}
+for i = range [1..2] {
+    println(classify(i), name)
+}
+`
+
+// run evaluates the program the number of times, with the coverage tracked
+func run(t *testing.T, times int) *Coverage {
+	t.Helper()
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		t.Fatal(prog.ParseErrors)
+	}
+	c := New()
+	for i := 0; i < times; i++ {
+		e := &evaluator.Evaluator{Stdout: new(bytes.Buffer)}
+		c.Track(e, "classify.ede", src, prog)
+		if err, ok := e.Eval(prog, object.NewEnvironment(nil)).(*object.Error); ok {
+			t.Fatal(err.Message)
+		}
+	}
+	return c
+}
+
+func TestCoverage(t *testing.T) {
+	files := run(t, 2).Files()
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	f := files[0]
+
+	var counts []string
+	for _, s := range f.Statements {
+		counts = append(counts, fmt.Sprintf("%d:%d=%d", s.Pos.Line, s.Pos.Column, s.Count))
+	}
+	expected := "1:1=2 2:5=6 3:9=0 5:9=0 7:5=6 9:1=2 13:1=2 14:5=4"
+	if got := strings.Join(counts, " "); got != expected {
+		t.Errorf("wrong statement counts.\nexpected=%s\ngot=     %s", expected, got)
+	}
+
+	var branches []string
+	for _, b := range f.Branches {
+		branches = append(branches, fmt.Sprintf("%s=%d", b.Label, b.Count))
+	}
+	expected = `if=0 else if=0 no branch=6 case "zero"=0 default=2 body=4 no iteration=0`
+	if got := strings.Join(branches, " "); got != expected {
+		t.Errorf("wrong branch counts.\nexpected=%s\ngot=     %s", expected, got)
+	}
+
+	statements, branchPercent := f.Percent()
+	if statements != 75 || branchPercent < 42.8 || branchPercent > 42.9 {
+		t.Errorf("wrong percentages. got=%.1f %.1f", statements, branchPercent)
+	}
+}
+
+func TestReports(t *testing.T) {
+	c := run(t, 1)
+
+	out := new(bytes.Buffer)
+	c.WriteSummary(out)
+	if expected := "classify.ede: 75.0% of statements, 42.9% of branches\n"; out.String() != expected {
+		t.Errorf("wrong summary. expected=%q, got=%q", expected, out)
+	}
+
+	out.Reset()
+	if err := c.WriteLCOV(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `TN:
+SF:classify.ede
+BRDA:2,0,0,-
+BRDA:2,0,1,-
+BRDA:2,0,2,3
+BRDA:9,1,0,-
+BRDA:9,1,1,1
+BRDA:13,2,0,2
+BRDA:13,2,1,-
+BRF:7
+BRH:3
+DA:1,1
+DA:2,3
+DA:3,0
+DA:5,0
+DA:7,3
+DA:9,1
+DA:13,1
+DA:14,2
+LF:8
+LH:6
+end_of_record
+`
+	if out.String() != expected {
+		t.Errorf("wrong LCOV.\nexpected=%s\ngot=     %s", expected, out)
+	}
+
+	out.Reset()
+	if err := c.WriteHTML(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		`<a href="#file0">classify.ede</a>: 75.0% of statements, 42.9% of branches`,
+		`<tr class="partial"><td class="num">2</td><td class="count">3</td><td class="code">    if (n &lt; 0) {  <span class="branches">not taken: if, else if</span></td></tr>`,
+		`<tr class="uncovered"><td class="num">3</td><td class="count">0</td>`,
+		`<tr class="covered"><td class="num">14</td><td class="count">2</td>`,
+		`<tr class=""><td class="num">15</td><td class="count"></td><td class="code">}</td></tr>`,
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("HTML report does not contain %q", exp)
+		}
+	}
+}
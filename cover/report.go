@@ -0,0 +1,153 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteSummary writes the percentage of the statements and branches covered of
+// each file
+func (c *Coverage) WriteSummary(w io.Writer) {
+	for _, f := range c.Files() {
+		statements, branches := f.Percent()
+		fmt.Fprintf(w, "%s: %.1f%% of statements, %.1f%% of branches\n", f.Name, statements, branches)
+	}
+}
+
+// lineCounts returns the number of times each line with a statement ran, the
+// count of its statement that ran the most
+func (f *File) lineCounts() map[int]int {
+	lines := make(map[int]int)
+	for _, s := range f.Statements {
+		if count, ok := lines[s.Pos.Line]; !ok || s.Count > count {
+			lines[s.Pos.Line] = s.Count
+		}
+	}
+	return lines
+}
+
+// WriteLCOV writes the coverage in the LCOV tracefile format
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	for _, f := range c.Files() {
+		fmt.Fprintln(w, "TN:")
+		fmt.Fprintf(w, "SF:%s\n", f.Name)
+
+		taken := 0
+		for _, b := range f.Branches {
+			count := fmt.Sprint(b.Count)
+			if b.Count == 0 {
+				count = "-"
+			} else {
+				taken++
+			}
+			fmt.Fprintf(w, "BRDA:%d,%d,%d,%s\n", b.Pos.Line, b.Block, b.Index, count)
+		}
+		fmt.Fprintf(w, "BRF:%d\nBRH:%d\n", len(f.Branches), taken)
+
+		counts := f.lineCounts()
+		lines := make([]int, 0, len(counts))
+		for line := range counts {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		hit := 0
+		for _, line := range lines {
+			if counts[line] > 0 {
+				hit++
+			}
+			fmt.Fprintf(w, "DA:%d,%d\n", line, counts[line])
+		}
+		fmt.Fprintf(w, "LF:%d\nLH:%d\n", len(lines), hit)
+		if _, err := fmt.Fprintln(w, "end_of_record"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("cover").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ede coverage</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+table.source { border-collapse: collapse; font-family: monospace; white-space: pre; }
+table.source td { padding: 0 0.5em; }
+td.num, td.count { color: #888; text-align: right; }
+tr.covered td.code { background: #dfd; }
+tr.uncovered td.code { background: #fdd; }
+tr.partial td.code { background: #ffd; }
+span.branches { color: #a60; font-size: smaller; }
+</style>
+</head>
+<body>
+<h1>Coverage</h1>
+<ul>
+{{- range .}}
+<li><a href="#{{.ID}}">{{.Name}}</a>: {{printf "%.1f" .Statements}}% of statements, {{printf "%.1f" .Branches}}% of branches</li>
+{{- end}}
+</ul>
+{{- range .}}
+<h2 id="{{.ID}}">{{.Name}}</h2>
+<table class="source">
+{{- range .Lines}}
+<tr class="{{.Class}}"><td class="num">{{.Num}}</td><td class="count">{{.Count}}</td><td class="code">{{.Text}}{{if .Missed}}  <span class="branches">not taken: {{.Missed}}</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlFile struct {
+	ID, Name             string
+	Statements, Branches float64
+	Lines                []htmlLine
+}
+
+type htmlLine struct {
+	Num         int
+	Count, Text string
+	Class       string // covered, uncovered, partial, or empty when there is no statement
+	Missed      string // the branches of the line that were not taken
+}
+
+// WriteHTML writes a report of the files, with their lines annotated with the
+// number of times they ran and the branches not taken
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	var files []htmlFile
+	for i, f := range c.Files() {
+		statements, branches := f.Percent()
+		hf := htmlFile{ID: fmt.Sprintf("file%d", i), Name: f.Name, Statements: statements, Branches: branches}
+
+		counts := f.lineCounts()
+		missed := make(map[int][]string)
+		for _, b := range f.Branches {
+			if b.Count == 0 {
+				missed[b.Pos.Line] = append(missed[b.Pos.Line], b.Label)
+			}
+		}
+		for i, text := range strings.Split(strings.TrimSuffix(f.Src, "\n"), "\n") {
+			line := htmlLine{Num: i + 1, Text: text}
+			if count, ok := counts[i+1]; ok {
+				line.Count = fmt.Sprint(count)
+				switch {
+				case count == 0:
+					line.Class = "uncovered"
+				case len(missed[i+1]) > 0:
+					line.Class = "partial"
+				default:
+					line.Class = "covered"
+				}
+			}
+			line.Missed = strings.Join(missed[i+1], ", ")
+			hf.Lines = append(hf.Lines, line)
+		}
+		files = append(files, hf)
+	}
+	return htmlTemplate.Execute(w, files)
+}
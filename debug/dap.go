@@ -0,0 +1,433 @@
+package debug
+
+import (
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"ede/rpc"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The subset of the Debug Adapter Protocol used by the server.
+// See https://microsoft.github.io/debug-adapter-protocol/specification
+
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type dapResponse struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// threadID is the id of the only thread of the programs
+const threadID = 1
+
+// DAP is a Debug Adapter Protocol server, reading the requests of an editor from
+// a stream and writing the responses and events to another. It debugs one
+// program, launched by the editor.
+type DAP struct {
+	reader *rpc.Reader
+	writer *rpc.Writer
+
+	mu  sync.Mutex
+	seq int
+
+	d       *Debugger
+	source  dapSource
+	stop    bool // stop on entry
+	started bool
+	// refs are the environments and values whose variables the editor can
+	// expand, by variablesReference - 1. They are valid until the program resumes.
+	refs []any
+}
+
+func NewDAP(in io.Reader, out io.Writer) *DAP {
+	return &DAP{reader: rpc.NewReader(in), writer: rpc.NewWriter(out)}
+}
+
+// Serve handles the requests until the editor disconnects, or the input stream ends
+func (s *DAP) Serve() error {
+	for {
+		body, err := s.reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req dapRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		result, err := s.handle(&req)
+		if err := s.respond(&req, result, err); err != nil {
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		switch req.Command {
+		case "initialize":
+			s.event("initialized", nil)
+		case "configurationDone":
+			if s.d != nil && !s.started {
+				s.started = true
+				s.d.Start(s.stop)
+				go s.forwardEvents()
+			}
+		case "continue":
+			s.d.Continue()
+		case "next":
+			s.d.StepOver()
+		case "stepIn":
+			s.d.StepIn()
+		case "stepOut":
+			s.d.StepOut()
+		case "disconnect", "terminate":
+			if s.d != nil && s.started {
+				s.d.Quit()
+			}
+			return nil
+		}
+	}
+}
+
+func (s *DAP) handle(req *dapRequest) (any, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsSetVariable":              true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args struct {
+			Program     string   `json:"program"`
+			Args        []string `json:"args"`
+			StopOnEntry bool     `json:"stopOnEntry"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args.Program, args.Args, args.StopOnEntry)
+	case "setBreakpoints":
+		var args struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if s.d == nil {
+			return nil, errors.New("no program launched")
+		}
+		lines := make([]int, len(args.Breakpoints))
+		breakpoints := make([]map[string]any, len(args.Breakpoints))
+		for i, bp := range args.Breakpoints {
+			lines[i] = bp.Line
+			breakpoints[i] = map[string]any{"verified": true, "line": bp.Line}
+		}
+		s.d.SetBreakpoints(lines)
+		return map[string]any{"breakpoints": breakpoints}, nil
+	case "configurationDone", "disconnect", "terminate":
+		return nil, nil
+	case "threads":
+		return map[string]any{"threads": []map[string]any{{"id": threadID, "name": "main"}}}, nil
+	case "stackTrace":
+		stack, err := s.stack()
+		if err != nil {
+			return nil, err
+		}
+		frames := make([]map[string]any, len(stack))
+		for i, frame := range stack {
+			frames[i] = map[string]any{"id": i, "name": frame.Name, "source": s.source, "line": frame.Pos.Line, "column": frame.Pos.Column}
+		}
+		return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+	case "scopes":
+		frame, err := s.frame(req.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		scopes := []map[string]any{}
+		for _, scope := range Scopes(frame) {
+			scopes = append(scopes, map[string]any{
+				"name":               scope.Name,
+				"variablesReference": s.ref(scope.Env),
+				"expensive":          false,
+			})
+		}
+		return map[string]any{"scopes": scopes}, nil
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return map[string]any{"variables": s.variables(args.VariablesReference)}, nil
+	case "setVariable":
+		var args struct {
+			VariablesReference int    `json:"variablesReference"`
+			Name               string `json:"name"`
+			Value              string `json:"value"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		env, ok := s.deref(args.VariablesReference).(*object.Environment)
+		if !ok {
+			return nil, errors.New("only the variables of a scope can be set")
+		}
+		value, err := s.d.Set(env, args.Name, args.Value)
+		if err != nil {
+			return nil, err
+		}
+		v := s.variable(args.Name, value)
+		return map[string]any{"value": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		frame, err := s.frame(req.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.d.Evaluate(frame.Env, args.Expression)
+		if err != nil {
+			return nil, err
+		}
+		v := s.variable("", value)
+		return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+	case "continue", "next", "stepIn", "stepOut":
+		// the program is resumed once the response is sent, see Serve
+		if _, err := s.stack(); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.refs = nil
+		s.mu.Unlock()
+		if req.Command == "continue" {
+			return map[string]any{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	case "pause":
+		if s.d == nil {
+			return nil, errors.New("no program launched")
+		}
+		s.d.Pause()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported command %q", req.Command)
+}
+
+// launch prepares the debugger of the program, which runs once the editor is
+// done configuring it
+func (s *DAP) launch(path string, args []string, stopOnEntry bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	prog := parser.New(lexer.New(string(data))).Parse()
+	if prog.ParseErrors != nil {
+		return fmt.Errorf("%s: %w", path, prog.ParseErrors)
+	}
+	if err := evaluator.Expand(prog); err != nil {
+		return fmt.Errorf("%s: %s", path, strings.TrimSpace(err.Message))
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	s.source = dapSource{Name: filepath.Base(path), Path: path}
+	e := &evaluator.Evaluator{
+		Stdin:  strings.NewReader(""),
+		Stdout: &outputWriter{s: s, category: "stdout"},
+		Stderr: &outputWriter{s: s, category: "stderr"},
+		Args:   append([]string{path}, args...),
+		File:   path,
+	}
+	s.d = New(e, prog)
+	s.stop = stopOnEntry
+	return nil
+}
+
+// forwardEvents sends the stops and the exit of the program to the editor
+func (s *DAP) forwardEvents() {
+	for ev := range s.d.Events() {
+		if !ev.Exited() {
+			s.event("stopped", map[string]any{"reason": string(ev.Reason), "threadId": threadID, "allThreadsStopped": true})
+			continue
+		}
+		code := 0
+		switch result := ev.Result.(type) {
+		case *object.Exit:
+			code = result.Code
+		case *object.Error:
+			s.event("output", map[string]any{"category": "stderr", "output": result.Message + "\n"})
+			code = 1
+		}
+		s.event("exited", map[string]any{"exitCode": code})
+		s.event("terminated", nil)
+	}
+}
+
+func (s *DAP) stack() ([]*Frame, error) {
+	if s.d == nil {
+		return nil, errors.New("no program launched")
+	}
+	return s.d.Stack()
+}
+
+// frame returns the frame of the frameId argument, or the innermost frame without one
+func (s *DAP) frame(arguments json.RawMessage) (*Frame, error) {
+	var args struct {
+		FrameID int `json:"frameId"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	stack, err := s.stack()
+	if err != nil {
+		return nil, err
+	}
+	if args.FrameID < 0 || args.FrameID >= len(stack) {
+		return nil, fmt.Errorf("invalid frame %d", args.FrameID)
+	}
+	return stack[args.FrameID], nil
+}
+
+// ref returns the variablesReference of an environment or value
+func (s *DAP) ref(v any) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs = append(s.refs, v)
+	return len(s.refs)
+}
+
+func (s *DAP) deref(ref int) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ref < 1 || ref > len(s.refs) {
+		return nil
+	}
+	return s.refs[ref-1]
+}
+
+// variables returns the variables of an environment, or the entries of a value
+func (s *DAP) variables(ref int) []dapVariable {
+	vars := []dapVariable{}
+	switch v := s.deref(ref).(type) {
+	case *object.Environment:
+		for _, name := range v.Names() {
+			value, _ := v.Get(name)
+			vars = append(vars, s.variable(name, value))
+		}
+	case *object.Array:
+		for i, entry := range *v.Entries {
+			vars = append(vars, s.variable(strconv.Itoa(i), entry))
+		}
+	case *object.Hash:
+		keys := make([]string, 0, len(v.Entries))
+		for key := range v.Entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			vars = append(vars, s.variable(key, v.Entries[key]))
+		}
+	}
+	return vars
+}
+
+func (s *DAP) variable(name string, value object.Object) dapVariable {
+	v := dapVariable{Name: name, Value: inspect(value)}
+	if value == nil {
+		return v
+	}
+	v.Type = strings.ToLower(string(value.Type()))
+	switch value := value.(type) {
+	case *object.Array:
+		if len(*value.Entries) > 0 {
+			v.VariablesReference = s.ref(value)
+		}
+	case *object.Hash:
+		if len(value.Entries) > 0 {
+			v.VariablesReference = s.ref(value)
+		}
+	}
+	return v
+}
+
+func (s *DAP) respond(req *dapRequest, body any, err error) error {
+	resp := dapResponse{Seq: s.nextSeq(), Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	return s.writer.Write(resp)
+}
+
+func (s *DAP) event(name string, body any) {
+	s.writer.Write(dapEvent{Seq: s.nextSeq(), Type: "event", Event: name, Body: body})
+}
+
+func (s *DAP) nextSeq() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// outputWriter sends the output of the program to the editor
+type outputWriter struct {
+	s        *DAP
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.s.event("output", map[string]any{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,158 @@
+package debug
+
+import (
+	"ede/rpc"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dapClient drives a DAP server like an editor
+type dapClient struct {
+	t      *testing.T
+	seq    int
+	writer *rpc.Writer
+	reader *rpc.Reader
+	done   chan error
+}
+
+func newDAPClient(t *testing.T) *dapClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &dapClient{t: t, writer: rpc.NewWriter(inW), reader: rpc.NewReader(outR), done: make(chan error, 1)}
+	go func() {
+		err := NewDAP(inR, outW).Serve()
+		outW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+// request sends the request, and returns the body of its response. The events
+// received before the response are skipped.
+func (c *dapClient) request(command string, args any) map[string]any {
+	c.t.Helper()
+	c.seq++
+	if err := c.writer.Write(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg["type"] == "event" {
+			continue
+		}
+		if msg["request_seq"] != float64(c.seq) {
+			c.t.Fatalf("unexpected response %v", msg)
+		}
+		if msg["success"] != true {
+			c.t.Fatalf("%s failed: %v", command, msg["message"])
+		}
+		body, _ := msg["body"].(map[string]any)
+		return body
+	}
+}
+
+// event waits for the event, and returns its body
+func (c *dapClient) event(name string) map[string]any {
+	c.t.Helper()
+	for {
+		msg := c.read()
+		if msg["type"] == "event" && msg["event"] == name {
+			body, _ := msg["body"].(map[string]any)
+			return body
+		}
+	}
+}
+
+func (c *dapClient) read() map[string]any {
+	c.t.Helper()
+	data, err := c.reader.Read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+func TestDAP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.ede")
+	if err := os.WriteFile(path, []byte(testSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newDAPClient(t)
+	caps := c.request("initialize", map[string]any{"adapterID": "ede"})
+	if caps["supportsSetVariable"] != true {
+		t.Errorf("expected supportsSetVariable, got %v", caps)
+	}
+	c.event("initialized")
+	c.request("launch", map[string]any{"program": path})
+	bps := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []any{map[string]any{"line": 3}},
+	})
+	if verified := bps["breakpoints"].([]any)[0].(map[string]any)["verified"]; verified != true {
+		t.Errorf("expected the breakpoint to be verified, got %v", bps)
+	}
+	c.request("configurationDone", nil)
+
+	if stopped := c.event("stopped"); stopped["reason"] != "breakpoint" {
+		t.Fatalf("expected a stop on the breakpoint, got %v", stopped)
+	}
+	trace := c.request("stackTrace", map[string]any{"threadId": threadID})
+	frames := trace["stackFrames"].([]any)
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %v", frames)
+	}
+	if frame := frames[0].(map[string]any); frame["name"] != "add" || frame["line"] != float64(3) {
+		t.Errorf("wrong innermost frame %v", frame)
+	}
+
+	scopes := c.request("scopes", map[string]any{"frameId": 0})
+	local := scopes["scopes"].([]any)[0].(map[string]any)
+	vars := c.request("variables", map[string]any{"variablesReference": local["variablesReference"]})
+	if sum := vars["variables"].([]any)[0].(map[string]any); sum["name"] != "sum" || sum["value"] != "3" {
+		t.Errorf("wrong variable %v", sum)
+	}
+	set := c.request("setVariable", map[string]any{"variablesReference": local["variablesReference"], "name": "sum", "value": "[sum, 7]"})
+	if set["value"] != "[3, 7]" || set["variablesReference"] == float64(0) {
+		t.Errorf("wrong value set %v", set)
+	}
+	eval := c.request("evaluate", map[string]any{"expression": "a + b", "frameId": 0})
+	if eval["result"] != "3" || eval["type"] != "int" {
+		t.Errorf("wrong evaluation %v", eval)
+	}
+
+	c.request("stepOut", map[string]any{"threadId": threadID})
+	if stopped := c.event("stopped"); stopped["reason"] != "step" {
+		t.Fatalf("expected a step, got %v", stopped)
+	}
+	c.request("continue", map[string]any{"threadId": threadID})
+	output := ""
+	for {
+		msg := c.read()
+		if msg["event"] == "exited" {
+			if code := msg["body"].(map[string]any)["exitCode"]; code != float64(0) {
+				t.Errorf("wrong exit code %v", code)
+			}
+			break
+		}
+		if msg["event"] == "output" {
+			output += msg["body"].(map[string]any)["output"].(string)
+		}
+	}
+	if output != "4 [3, 7]\n" {
+		t.Errorf("wrong output %q", output)
+	}
+	c.event("terminated")
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
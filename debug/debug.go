@@ -0,0 +1,338 @@
+// Package debug implements a debugger for ede programs. The debugger evaluates
+// the program with an evaluator hook, which stops it on breakpoints, steps and
+// pauses. While the program is stopped, its call stack and variables can be
+// inspected and modified.
+//
+// The debugger has two front ends: a command line (Terminal), and a Debug
+// Adapter Protocol server (DAP) for editors.
+package debug
+
+import (
+	"ede/ast"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"ede/token"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// StopReason is the reason why the program stopped
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"
+	StopBreakpoint StopReason = "breakpoint"
+	StopStep       StopReason = "step"
+	StopPause      StopReason = "pause"
+)
+
+// ErrRunning is returned when inspecting a program that is not stopped
+var ErrRunning = errors.New("the program is running")
+
+// Event is sent by the debugger when the program stops or exits
+type Event struct {
+	Reason StopReason    // the reason of the stop, empty when the program exited
+	Pos    token.Pos     // the position of the statement the program stopped at
+	Result object.Object // the result of the program once it exited, e.g. an error
+}
+
+// Exited returns true if the program exited
+func (ev Event) Exited() bool {
+	return ev.Reason == ""
+}
+
+// Frame is a function call of the call stack
+type Frame struct {
+	Name string              // the name of the function, or "main" for the program
+	Pos  token.Pos           // the position of the statement being evaluated
+	Env  *object.Environment // the environment of the statement being evaluated
+
+	last ast.Statement // the last statement, to stop once per line on breakpoints
+}
+
+// Scope is an environment of a frame, holding its variables
+type Scope struct {
+	Name string // "Local", or "Global" for the environment of the program
+	Env  *object.Environment
+}
+
+type runMode int
+
+const (
+	runContinue runMode = iota
+	runStepIn
+	runStepOver
+	runStepOut
+	runQuit
+)
+
+// Debugger runs a program, stopping it as requested by a front end
+type Debugger struct {
+	evaluator.NopHook
+
+	eval *evaluator.Evaluator
+	prog *ast.Program
+	env  *object.Environment
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool
+	stopped     bool
+
+	frames     []*Frame
+	mode       runMode
+	depth      int  // the depth of the call stack when the step started
+	entry      bool // stop on the first statement
+	inspecting bool // the hooks are ignored while the front end evaluates expressions
+	events     chan Event
+	resume     chan runMode
+}
+
+// New returns a debugger for the program, which it runs with the evaluator
+func New(e *evaluator.Evaluator, prog *ast.Program) *Debugger {
+	d := &Debugger{
+		eval:        e,
+		prog:        prog,
+		env:         object.NewEnvironment(nil),
+		breakpoints: make(map[int]bool),
+		events:      make(chan Event),
+		resume:      make(chan runMode),
+	}
+	e.AddHook(d)
+	return d
+}
+
+// Start runs the program in the background. If stopOnEntry is true, the program
+// stops before its first statement. The stops and the exit of the program are
+// sent on the Events channel, which is closed after the exit.
+func (d *Debugger) Start(stopOnEntry bool) {
+	d.entry = stopOnEntry
+	d.frames = []*Frame{{Name: "main", Env: d.env}}
+	go func() {
+		result := d.eval.Eval(d.prog, d.env)
+		d.events <- Event{Result: result}
+		close(d.events)
+	}()
+}
+
+// Events returns the channel of the stops and exit of the program
+func (d *Debugger) Events() <-chan Event {
+	return d.events
+}
+
+// SetBreakpoints replaces the breakpoints with ones on the lines
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// Breakpoints returns the sorted lines of the breakpoints
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Continue resumes the program until the next breakpoint
+func (d *Debugger) Continue() error { return d.run(runContinue) }
+
+// StepIn resumes the program until the next statement, entering the functions called
+func (d *Debugger) StepIn() error { return d.run(runStepIn) }
+
+// StepOver resumes the program until the next statement of the current function
+func (d *Debugger) StepOver() error { return d.run(runStepOver) }
+
+// StepOut resumes the program until it returns from the current function
+func (d *Debugger) StepOut() error { return d.run(runStepOut) }
+
+// Quit stops the program
+func (d *Debugger) Quit() error { return d.run(runQuit) }
+
+// Pause stops the program before its next statement
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = true
+}
+
+func (d *Debugger) run(mode runMode) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.mu.Unlock()
+		return ErrRunning
+	}
+	d.stopped = false
+	d.mu.Unlock()
+	d.resume <- mode
+	return nil
+}
+
+// Stack returns the call stack of the stopped program, the innermost frame first
+func (d *Debugger) Stack() ([]*Frame, error) {
+	if !d.isStopped() {
+		return nil, ErrRunning
+	}
+	frames := make([]*Frame, len(d.frames))
+	for i, frame := range d.frames {
+		frames[len(frames)-1-i] = frame
+	}
+	return frames, nil
+}
+
+// Scopes returns the environments visible from the frame, the innermost first.
+// The environments without variables are skipped, except the global one.
+func Scopes(frame *Frame) []Scope {
+	var scopes []Scope
+	for env := frame.Env; env != nil; env = env.Outer() {
+		switch {
+		case env.Outer() == nil:
+			scopes = append(scopes, Scope{Name: "Global", Env: env})
+		case len(env.Names()) > 0:
+			scopes = append(scopes, Scope{Name: "Local", Env: env})
+		}
+	}
+	return scopes
+}
+
+// Evaluate evaluates the source in the environment of the stopped program, and
+// returns the value of its last statement. The source can also change the
+// variables, e.g. with `a = 1`.
+func (d *Debugger) Evaluate(env *object.Environment, src string) (result object.Object, err error) {
+	if !d.isStopped() {
+		return nil, ErrRunning
+	}
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		return nil, prog.ParseErrors
+	}
+
+	d.inspecting = true
+	defer func() {
+		d.inspecting = false
+		if r := recover(); r != nil {
+			exit, ok := r.(*object.Exit)
+			if !ok {
+				panic(r)
+			}
+			result, err = nil, fmt.Errorf("cannot evaluate an exit: %w", exit)
+		}
+	}()
+	for _, stmt := range prog.Statements {
+		result = d.eval.Eval(stmt, env)
+		if errObj, ok := result.(*object.Error); ok {
+			return nil, errors.New(errObj.Message)
+		}
+	}
+	if result == nil {
+		result = object.NIL
+	}
+	return result, nil
+}
+
+// Set sets the variable visible from the environment to the value of the expression
+func (d *Debugger) Set(env *object.Environment, name, expr string) (object.Object, error) {
+	if _, ok := env.Get(name); !ok {
+		return nil, fmt.Errorf("unknown variable %q", name)
+	}
+	value, err := d.Evaluate(env, expr)
+	if err != nil {
+		return nil, err
+	}
+	env.Update(name, value)
+	return value, nil
+}
+
+func (d *Debugger) isStopped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopped
+}
+
+// Statement stops the program before the statement if requested
+func (d *Debugger) Statement(stmt ast.Statement, env *object.Environment) {
+	if d.inspecting {
+		return
+	}
+	frame := d.frames[len(d.frames)-1]
+	pos := stmt.Pos()
+	// a breakpoint stops on the first statement of its line, or on the same
+	// statement again, e.g. in a loop
+	newLine := frame.last == nil || pos.Line != frame.last.Pos().Line || stmt == frame.last
+	frame.Pos, frame.Env, frame.last = pos, env, stmt
+
+	if reason := d.stopReason(newLine, pos.Line); reason != "" {
+		d.stop(reason, pos)
+	}
+}
+
+func (d *Debugger) stopReason(newLine bool, line int) StopReason {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	depth := len(d.frames)
+	switch {
+	case d.entry:
+		d.entry = false
+		return StopEntry
+	case d.pause:
+		d.pause = false
+		return StopPause
+	case d.mode == runStepIn,
+		d.mode == runStepOver && depth <= d.depth,
+		d.mode == runStepOut && depth < d.depth:
+		return StopStep
+	case newLine && d.breakpoints[line]:
+		return StopBreakpoint
+	}
+	return ""
+}
+
+// stop sends the stop to the front end, and waits for it to resume the program
+func (d *Debugger) stop(reason StopReason, pos token.Pos) {
+	d.mu.Lock()
+	d.stopped = true
+	d.mu.Unlock()
+
+	d.events <- Event{Reason: reason, Pos: pos}
+	mode := <-d.resume
+	if mode == runQuit {
+		// unwinds the evaluation like os.exit does
+		panic(&object.Exit{Code: 0})
+	}
+	d.mode, d.depth = mode, len(d.frames)
+}
+
+// Call pushes the frame of the function called
+func (d *Debugger) Call(call *ast.CallExpression, fn *object.Function, args []object.Object, env *object.Environment) {
+	if d.inspecting {
+		return
+	}
+	name, pos := "func", fn.Body.Pos()
+	if call != nil {
+		if ident, ok := call.Function.(*ast.Identifier); ok {
+			name = ident.Value
+		}
+		pos = call.Pos()
+	}
+	d.frames = append(d.frames, &Frame{Name: name, Pos: pos, Env: env})
+}
+
+// Return pops the frame of the function returning
+func (d *Debugger) Return(call *ast.CallExpression, fn *object.Function, result object.Object) {
+	if d.inspecting {
+		return
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+}
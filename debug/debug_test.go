@@ -0,0 +1,137 @@
+package debug
+
+import (
+	"bytes"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"strings"
+	"testing"
+)
+
+const testSource = `let add = func(a, b) {
+    let sum = a + b
+    return sum
+}
+let x = 1
+let y = add(x, 2)
+for i = range [1..2] {
+    x = x + i
+}
+println(x, y)
+`
+
+// debugTerminal runs the source under the terminal with the commands, and returns its output
+func debugTerminal(t *testing.T, src string, commands ...string) (string, object.Object) {
+	t.Helper()
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		t.Fatal(prog.ParseErrors)
+	}
+	out := new(bytes.Buffer)
+	e := &evaluator.Evaluator{Stdin: strings.NewReader(strings.Join(commands, "\n") + "\n"), Stdout: out, Stderr: out}
+	result := NewTerminal(New(e, prog), src, e.Streams().Stdin, out).Run()
+	return out.String(), result
+}
+
+func TestTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		expected []string
+	}{
+		{
+			name:     "breakpoint",
+			commands: []string{"b 3", "c", "bt", "locals", "c"},
+			expected: []string{
+				"stopped at line 1 (entry)",
+				"stopped at line 3 (breakpoint)\n=>    3      return sum",
+				"* 0  add at line 3\n  1  main at line 6\n",
+				"Local:\n  sum = 3\nLocal:\n  a = 1\n  b = 2\nGlobal:\n  add = func\n  x = 1\n",
+				"4 3\n",
+			},
+		},
+		{
+			name:     "breakpoint in a loop",
+			commands: []string{"b 8", "c", "p i", "c", "p i", "clear 8", "c"},
+			expected: []string{"(debug) 1\n", "(debug) 2\n", "4 3\n"},
+		},
+		{
+			name:     "step in, over and out",
+			commands: []string{"n", "n", "s", "s", "o", "n"},
+			expected: []string{
+				"stopped at line 5 (step)",
+				"stopped at line 6 (step)",
+				"stopped at line 2 (step)",
+				"stopped at line 3 (step)",
+				// the call returns to the statement of line 6, which is done
+				"stopped at line 7 (step)",
+				"stopped at line 8 (step)",
+			},
+		},
+		{
+			name:     "set and print",
+			commands: []string{"b 7", "c", "set y = y * 10", `p "y is " + y.string()`, "frame 1", "c"},
+			expected: []string{"y = 30\n", "\"y is 30\"\n", "invalid frame", "4 30\n"},
+		},
+		{
+			name:     "errors",
+			commands: []string{"set z = 1", "p 1 +", "foo", "c"},
+			expected: []string{`error: unknown variable "z"`, "error: ", `unknown command "foo"`},
+		},
+		{
+			name:     "quit",
+			commands: []string{"q"},
+			expected: []string{"stopped at line 1 (entry)\n=>    1  let add = func(a, b) {\n(debug) "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := debugTerminal(t, testSource, tt.commands...)
+			rest := out
+			for _, exp := range tt.expected {
+				i := strings.Index(rest, exp)
+				if i < 0 {
+					t.Fatalf("output does not contain %q in order. output:\n%s", exp, out)
+				}
+				rest = rest[i+len(exp):]
+			}
+		})
+	}
+}
+
+func TestTerminal_Quit(t *testing.T) {
+	out, result := debugTerminal(t, testSource, "q")
+	if exit, ok := result.(*object.Exit); !ok || exit.Code != 0 {
+		t.Errorf("expected an exit, got %v", result)
+	}
+	if strings.Contains(out, "4 3") {
+		t.Errorf("the program should not have run to the end. output:\n%s", out)
+	}
+}
+
+func TestDebugger_Pause(t *testing.T) {
+	prog := parser.New(lexer.New("let a = 1\nlet b = 2\n")).Parse()
+	d := New(evaluator.New(), prog)
+	d.Pause()
+	d.Start(false)
+
+	ev := <-d.Events()
+	if ev.Reason != StopPause || ev.Pos.Line != 1 {
+		t.Fatalf("expected a pause on line 1, got %+v", ev)
+	}
+	if _, err := d.Evaluate(d.env, "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := d.Continue(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ev := <-d.Events(); !ev.Exited() {
+		t.Fatalf("expected the program to exit, got %+v", ev)
+	}
+	if _, err := d.Stack(); err != ErrRunning {
+		t.Errorf("expected %v, got %v", ErrRunning, err)
+	}
+}
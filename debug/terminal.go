@@ -0,0 +1,205 @@
+package debug
+
+import (
+	"bufio"
+	"ede/object"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const terminalHelp = `Commands:
+  break, b LINE       set a breakpoint on the line
+  clear LINE          delete the breakpoint on the line
+  breakpoints         list the breakpoints
+  continue, c         run until the next breakpoint
+  step, s             step to the next statement, entering function calls
+  next, n             step to the next statement of the current function
+  out, o              run until the current function returns
+  stack, bt           print the call stack
+  frame, f N          select the frame N of the call stack
+  locals, vars        print the variables of the selected frame
+  print, p EXPR       print the value of the expression
+  set NAME = EXPR     set the variable to the value of the expression
+  list, l             print the source around the current line
+  help, h             print this help
+  quit, q             stop the program and the debugger
+`
+
+// Terminal is the command line front end of a debugger
+type Terminal struct {
+	d     *Debugger
+	in    *bufio.Reader
+	out   io.Writer
+	lines []string // the lines of the source
+
+	stack []*Frame
+	frame int // the selected frame
+}
+
+// NewTerminal returns a front end for the debugger of the source. It reads the
+// commands from in, which should be the input stream of the program so that
+// the two do not read ahead of each other.
+func NewTerminal(d *Debugger, src string, in io.Reader, out io.Writer) *Terminal {
+	return &Terminal{d: d, in: bufio.NewReader(in), out: out, lines: strings.Split(src, "\n")}
+}
+
+// Run runs the program, stopping on its first statement, and returns its result
+// once it exits
+func (t *Terminal) Run() object.Object {
+	fmt.Fprintln(t.out, `Type "help" for the list of commands.`)
+	t.d.Start(true)
+	for ev := range t.d.Events() {
+		if ev.Exited() {
+			return ev.Result
+		}
+		fmt.Fprintf(t.out, "stopped at line %d (%s)\n", ev.Pos.Line, ev.Reason)
+		t.printLines(ev.Pos.Line, 0)
+		t.stack, _ = t.d.Stack()
+		t.frame = 0
+		t.prompt()
+	}
+	return nil
+}
+
+// prompt reads and runs commands until one resumes the program
+func (t *Terminal) prompt() {
+	for {
+		fmt.Fprint(t.out, "(debug) ")
+		line, err := t.in.ReadString('\n')
+		if err != nil && line == "" { // the end of the input quits
+			fmt.Fprintln(t.out)
+			t.d.Quit()
+			return
+		}
+		if t.command(strings.TrimSpace(line)) {
+			return
+		}
+	}
+}
+
+// command runs the command, and returns true if it resumed the program
+func (t *Terminal) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "":
+	case "break", "b", "clear":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			fmt.Fprintf(t.out, "invalid line %q\n", arg)
+			return false
+		}
+		lines := t.d.Breakpoints()
+		if name == "clear" {
+			kept := lines[:0]
+			for _, l := range lines {
+				if l != n {
+					kept = append(kept, l)
+				}
+			}
+			lines = kept
+		} else {
+			lines = append(lines, n)
+		}
+		t.d.SetBreakpoints(lines)
+	case "breakpoints":
+		for _, l := range t.d.Breakpoints() {
+			fmt.Fprintf(t.out, "line %d\n", l)
+		}
+	case "continue", "c":
+		return t.d.Continue() == nil
+	case "step", "s":
+		return t.d.StepIn() == nil
+	case "next", "n":
+		return t.d.StepOver() == nil
+	case "out", "o":
+		return t.d.StepOut() == nil
+	case "quit", "q":
+		return t.d.Quit() == nil
+	case "stack", "bt":
+		for i, frame := range t.stack {
+			marker := " "
+			if i == t.frame {
+				marker = "*"
+			}
+			fmt.Fprintf(t.out, "%s %d  %s at line %d\n", marker, i, frame.Name, frame.Pos.Line)
+		}
+	case "frame", "f":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 || n >= len(t.stack) {
+			fmt.Fprintf(t.out, "invalid frame %q\n", arg)
+			return false
+		}
+		t.frame = n
+		t.printLines(t.stack[n].Pos.Line, 0)
+	case "locals", "vars":
+		for _, scope := range Scopes(t.stack[t.frame]) {
+			fmt.Fprintf(t.out, "%s:\n", scope.Name)
+			for _, name := range scope.Env.Names() {
+				value, _ := scope.Env.Get(name)
+				fmt.Fprintf(t.out, "  %s = %s\n", name, inspect(value))
+			}
+		}
+	case "print", "p":
+		value, err := t.d.Evaluate(t.stack[t.frame].Env, arg)
+		if err != nil {
+			fmt.Fprintf(t.out, "error: %s\n", err)
+			return false
+		}
+		fmt.Fprintln(t.out, inspect(value))
+	case "set":
+		name, expr, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Fprintln(t.out, "usage: set NAME = EXPR")
+			return false
+		}
+		value, err := t.d.Set(t.stack[t.frame].Env, strings.TrimSpace(name), expr)
+		if err != nil {
+			fmt.Fprintf(t.out, "error: %s\n", err)
+			return false
+		}
+		fmt.Fprintf(t.out, "%s = %s\n", strings.TrimSpace(name), inspect(value))
+	case "list", "l":
+		t.printLines(t.stack[t.frame].Pos.Line, 5)
+	case "help", "h":
+		fmt.Fprint(t.out, terminalHelp)
+	default:
+		fmt.Fprintf(t.out, "unknown command %q, type \"help\" for the list of commands\n", name)
+	}
+	return false
+}
+
+// printLines prints the line of the source with the lines around it, marking
+// the line and the breakpoints
+func (t *Terminal) printLines(line, around int) {
+	breakpoints := map[int]bool{}
+	for _, l := range t.d.Breakpoints() {
+		breakpoints[l] = true
+	}
+	for n := line - around; n <= line+around; n++ {
+		if n < 1 || n > len(t.lines) {
+			continue
+		}
+		marker := "  "
+		switch {
+		case n == line:
+			marker = "=>"
+		case breakpoints[n]:
+			marker = " *"
+		}
+		fmt.Fprintf(t.out, "%s %4d  %s\n", marker, n, strings.TrimRight(t.lines[n-1], "\r"))
+	}
+}
+
+// inspect returns the representation of the value shown to the user, quoting strings
+func inspect(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *object.String:
+		return strconv.Quote(obj.Value)
+	}
+	return obj.Inspect()
+}
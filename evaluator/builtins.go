@@ -3,24 +3,40 @@ package evaluator
 import (
 	"ede/object"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
-var builtins = map[string]*object.Builtin{
-	"len":   {Fn: applyBuiltinLen},
-	"print": {Fn: applyBuiltinPrint},
-	"println": {Fn: func(args ...object.Object) object.Object {
-		applyBuiltinPrint(args...)
-		fmt.Println()
-		return NULL
-	}},
-	"printf": {Fn: func(args ...object.Object) object.Object {
-		print := make([]string, len(args))
-		for i, arg := range args {
-			print[i] = arg.Inspect()
+// builtin returns the builtin function registered with the given name. Builtins
+// are bound to the evaluator, so they read from and write to its streams.
+func (e *Evaluator) builtin(name string) (*object.Builtin, bool) {
+	if e.builtins == nil {
+		e.builtins = map[string]*object.Builtin{
+			"len":      {Fn: applyBuiltinLen},
+			"print":    {Fn: e.applyBuiltinPrint},
+			"println":  {Fn: e.applyBuiltinPrintln},
+			"eprintln": {Fn: e.applyBuiltinEprintln},
+			"input":    {Fn: e.applyBuiltinInput},
+			"readline": {Fn: e.applyBuiltinReadline},
+			"printf":   {Fn: e.applyBuiltinPrintf},
+			"sprintf":  {Fn: applyBuiltinSprintf},
 		}
-		return object.NewString(strings.Join(print, ", "))
-	}},
+	}
+	b, ok := e.builtins[name]
+	return b, ok
+}
+
+// BuiltinNames returns the names of the builtin functions, sorted
+func (e *Evaluator) BuiltinNames() []string {
+	e.builtin("")
+	names := make([]string, 0, len(e.builtins))
+	for name := range e.builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func applyBuiltinLen(args ...object.Object) object.Object {
@@ -30,24 +46,102 @@ func applyBuiltinLen(args ...object.Object) object.Object {
 	arg := args[0]
 	switch arg := arg.(type) {
 	case *object.String:
-		return &object.Int{Value: int64(len(arg.Value))}
+		return &object.Int{Value: int64(utf8.RuneCountInString(arg.Value))}
 	case *object.Array:
 		return &object.Int{Value: int64(len(*arg.Entries))}
+	case *object.Hash:
+		return &object.Int{Value: int64(len(arg.Entries))}
 	}
 	return object.NewErrorWithMsg(fmt.Sprintf("argument to `len` not supported, got %s", arg.Type()))
 }
 
-func applyBuiltinPrint(args ...object.Object) object.Object {
+func (e *Evaluator) applyBuiltinPrint(args ...object.Object) object.Object {
+	fprint(e.Streams().Stdout, args...)
+	return NULL
+}
+
+func (e *Evaluator) applyBuiltinPrintln(args ...object.Object) object.Object {
+	stdout := e.Streams().Stdout
+	fprint(stdout, args...)
+	fmt.Fprintln(stdout)
+	return NULL
+}
+
+// applyBuiltinEprintln is println for the error stream
+func (e *Evaluator) applyBuiltinEprintln(args ...object.Object) object.Object {
+	stderr := e.Streams().Stderr
+	fprint(stderr, args...)
+	fmt.Fprintln(stderr)
+	return NULL
+}
+
+// applyBuiltinPrintf writes the formatted arguments to the output stream.
+// See object.Format for the supported verbs.
+func (e *Evaluator) applyBuiltinPrintf(args ...object.Object) object.Object {
+	str := applyBuiltinSprintf(args...)
+	if str.Type() == object.ERROR_OBJ {
+		return str
+	}
+	fmt.Fprint(e.Streams().Stdout, str.Inspect())
+	return NULL
+}
+
+func applyBuiltinSprintf(args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return object.CountArgumentError(">=1", len(args))
+	}
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return object.NewErrorWithMsg("expected format to be of type 'STRING', got %s", args[0].Type())
+	}
+	str, err := object.Format(format.Value, args[1:])
+	if err != nil {
+		return err
+	}
+	return object.NewString(str)
+}
+
+// applyBuiltinInput writes the optional prompt to the output stream, and reads a line from the input stream
+func (e *Evaluator) applyBuiltinInput(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.CountArgumentError("0 or 1", len(args))
+	}
+	if len(args) == 1 {
+		fmt.Fprint(e.Streams().Stdout, args[0].Inspect())
+	}
+	return e.readLine()
+}
+
+func (e *Evaluator) applyBuiltinReadline(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.CountArgumentError("0", len(args))
+	}
+	return e.readLine()
+}
+
+// readLine reads a line from the input stream without its line ending.
+// It returns nil once the input is exhausted.
+func (e *Evaluator) readLine() object.Object {
+	line, err := e.input().ReadString('\n')
+	if err == io.EOF && line == "" {
+		return NULL
+	}
+	if err != nil && err != io.EOF {
+		return object.NewErrorWithMsg("error reading input: %s", err)
+	}
+	return object.NewString(strings.TrimRight(line, "\r\n"))
+}
+
+func fprint(w io.Writer, args ...object.Object) {
 	for i, arg := range args {
 		if arg == nil {
-			fmt.Println()
+			fmt.Fprintln(w)
 		} else if arg.Inspect() == "\\n" {
-			fmt.Println()
+			fmt.Fprintln(w)
 		} else if i == len(args)-1 {
-			fmt.Print(arg.Inspect())
+			fmt.Fprint(w, arg.Inspect())
 		} else {
-			fmt.Printf("%s ", arg.Inspect())
+			fmt.Fprintf(w, "%s ", arg.Inspect())
 		}
 	}
-	return NULL
 }
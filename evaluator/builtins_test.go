@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"bytes"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"strings"
+	"testing"
+)
+
+func testEvalWithIO(input, stdin string) (stdout, stderr string, result object.Object) {
+	var out, errOut bytes.Buffer
+	program := parser.New(lexer.New(input)).Parse()
+	ev := &Evaluator{Stdin: strings.NewReader(stdin), Stdout: &out, Stderr: &errOut}
+	result = ev.Eval(program, object.NewEnvironment(nil))
+	return out.String(), errOut.String(), result
+}
+
+func TestBuiltinStreams(t *testing.T) {
+	tests := []struct {
+		input  string
+		stdin  string
+		stdout string
+		stderr string
+	}{
+		{input: `print("a", 1)`, stdout: "a 1"},
+		{input: `println("hello", "world!")`, stdout: "hello world!\n"},
+		{input: `println("a", "\n", "b")`, stdout: "a \nb\n"},
+		{input: `eprintln("oops", 1)`, stderr: "oops 1\n"},
+		{input: `let name = input("name: "); println("hi", name)`, stdin: "ede\n", stdout: "name: hi ede\n"},
+		{input: `println(readline()); println(readline())`, stdin: "one\r\ntwo", stdout: "one\ntwo\n"},
+		{input: `println(readline())`, stdin: "", stdout: "nil\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			stdout, stderr, result := testEvalWithIO(tt.input, tt.stdin)
+			if err, ok := result.(*object.Error); ok {
+				t.Fatalf("unexpected error: %s", err.Message)
+			}
+			if stdout != tt.stdout {
+				t.Errorf("wrong stdout. expected=%q, got=%q", tt.stdout, stdout)
+			}
+			if stderr != tt.stderr {
+				t.Errorf("wrong stderr. expected=%q, got=%q", tt.stderr, stderr)
+			}
+		})
+	}
+}
+
+func TestBuiltinStreams_Error(t *testing.T) {
+	_, _, result := testEvalWithIO(`readline("foo")`, "")
+	err, ok := result.(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error to be returned, got %T", result)
+	}
+	exp := "expected 0 argument(s), got 1"
+	if !strings.Contains(err.Message, exp) {
+		t.Fatalf("Error message '%s' does not contain '%s'", err.Message, exp)
+	}
+}
+
+func TestBuiltinPrintf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sprintf("plain")`, "plain"},
+		{`sprintf("%d items", 3)`, "3 items"},
+		{`sprintf("%5d|%-5d|%05d", 42, 42, 42)`, "   42|42   |00042"},
+		{`sprintf("%.2f", 3.14159)`, "3.14"},
+		{`sprintf("%8.3f", 2)`, "   2.000"},
+		{`sprintf("%s and %q", "foo", "bar")`, `foo and "bar"`},
+		{`sprintf("%s", [1, 2])`, "[1, 2]"},
+		{`sprintf("%v %v", nil, true)`, "nil true"},
+		{`sprintf("%x %X %x", 255, 255, "hi")`, "ff FF 6869"},
+		{`sprintf("%t", false)`, "false"},
+		{`sprintf("100%%")`, "100%"},
+		{`sprintf("%-4s|", "é")`, "é   |"},
+		{`sprintf("a\tb\n")`, "a\tb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Fatalf("expected a string to be returned, got %T (%+v)", evaluated, evaluated)
+			}
+			if str.Value != tt.expected {
+				t.Errorf("wrong value. expected=%q, got=%q", tt.expected, str.Value)
+			}
+		})
+	}
+
+	t.Run("printf", func(t *testing.T) {
+		stdout, _, result := testEvalWithIO(`printf("%s=%d\n", "x", 10)`, "")
+		if result != NULL {
+			t.Fatalf("expected nil to be returned, got %v", result.Inspect())
+		}
+		if stdout != "x=10\n" {
+			t.Errorf("wrong stdout. expected=%q, got=%q", "x=10\n", stdout)
+		}
+	})
+}
+
+func TestBuiltinPrintf_Error(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sprintf()`, "expected >=1 argument(s), got 0"},
+		{`sprintf(1)`, "expected format to be of type 'STRING', got INT"},
+		{`sprintf("%d")`, `missing argument for verb %d in format "%d"`},
+		{`sprintf("%d", 1, 2)`, `too many arguments for format "%d", expected 1, got 2`},
+		{`sprintf("%d", "a")`, "verb %d expects an INT argument, got STRING"},
+		{`sprintf("%.1f", "a")`, "verb %.1f expects an INT or FLOAT argument, got STRING"},
+		{`sprintf("%t", 1)`, "verb %t expects a BOOLEAN argument, got INT"},
+		{`sprintf("%y", 1)`, "unknown verb %y"},
+		{`sprintf("50%")`, `format "50%" ends with an incomplete verb "%"`},
+		{`printf("%d", "a")`, "verb %d expects an INT argument, got STRING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			err, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Fatalf("expected an error to be returned, got %T (%+v)", evaluated, evaluated)
+			}
+			if !strings.Contains(err.Message, tt.expected) {
+				t.Fatalf("Error message '%s' does not contain '%s'", err.Message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuiltinStreams_ReplacedStdin(t *testing.T) {
+	var out bytes.Buffer
+	ev := &Evaluator{Stdin: strings.NewReader("one\n"), Stdout: &out}
+	program := parser.New(lexer.New(`println(readline())`)).Parse()
+	ev.Eval(program, object.NewEnvironment(nil))
+	ev.Stdin = strings.NewReader("two\n")
+	ev.Eval(program, object.NewEnvironment(nil))
+	if got := out.String(); got != "one\ntwo\n" {
+		t.Fatalf("expected the lines of both inputs, got %q", got)
+	}
+}
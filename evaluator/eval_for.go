@@ -24,7 +24,11 @@ func (e *Evaluator) evalForLoopStmt(node *ast.ForLoopStmt, env *object.Environme
 	switch boundRange := node.Boundary.(type) {
 	case *ast.ArrayLiteral:
 		// TODO: make range array zero index
+		if len(boundRange.Elements) == 0 {
+			e.branch(node, 1)
+		}
 		for i, el := range boundRange.Elements {
+			e.branch(node, 0)
 			// create an environment for the block statemet
 			blockEnv := object.NewEnvironment(env)
 			blockEnv.Set(token.IndexIdentifier, &object.Int{Value: int64(i)})
@@ -42,16 +46,27 @@ func (e *Evaluator) evalForLoopStmt(node *ast.ForLoopStmt, env *object.Environme
 		iter = e.Eval(boundRange, env)
 	}
 
+	if e.isError(iter) {
+		return iter
+	}
+	if it, ok := iter.(*object.Iterator); ok {
+		return e.evalIteratorLoop(node, it, env)
+	}
 	if arr, ok = iter.(Iterable); !ok {
 		return object.NewErrorWithMsg("for loop boundary type is not iterable, got %T", iter)
 	}
 
-	for i, entry := range arr.Items() {
+	items := arr.Items()
+	if len(items) == 0 {
+		e.branch(node, 1)
+	}
+	for i, entry := range items {
+		e.branch(node, 0)
 		stmtEnv := object.NewEnvironment(env)
 		stmtEnv.Set(token.IndexIdentifier, &object.Int{Value: int64(i)})
 		stmtEnv.Set(node.Variable.Value, entry) // bound loop variable
 		for _, stmt := range node.Statement.Statements {
-			result = e.Eval(stmt, stmtEnv)
+			result = e.evalStatement(stmt, stmtEnv)
 			if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
 				return result
 			}
@@ -62,3 +77,35 @@ func (e *Evaluator) evalForLoopStmt(node *ast.ForLoopStmt, env *object.Environme
 	// else we return nil, because it's a statement
 	return NULL
 }
+
+// evalIteratorLoop runs the loop over the items of the iterator as they are
+// produced, and closes the iterator when the loop is over
+func (e *Evaluator) evalIteratorLoop(node *ast.ForLoopStmt, it *object.Iterator, env *object.Environment) object.Object {
+	if it.Close != nil {
+		defer it.Close()
+	}
+	i := 0
+	for ; ; i++ {
+		entry, ok := it.Next()
+		if !ok {
+			break
+		}
+		if e.isError(entry) {
+			return entry
+		}
+		e.branch(node, 0)
+		stmtEnv := object.NewEnvironment(env)
+		stmtEnv.Set(token.IndexIdentifier, &object.Int{Value: int64(i)})
+		stmtEnv.Set(node.Variable.Value, entry) // bound loop variable
+		for _, stmt := range node.Statement.Statements {
+			result := e.evalStatement(stmt, stmtEnv)
+			if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
+				return result
+			}
+		}
+	}
+	if i == 0 {
+		e.branch(node, 1)
+	}
+	return NULL
+}
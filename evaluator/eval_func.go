@@ -75,13 +75,26 @@ func (e *Evaluator) evalObjectMethodExpr(obj object.Object, call *ast.CallExpres
 			return object.NewErrorWithMsg(fmt.Sprintf("unknown method '%s' for type '%T'", ident.Value, obj))
 		}
 	}
-	return method.Fn(args...)
+	result := withPos(checkExit(method.Fn(args...)), call)
+	if imp, ok := obj.(*object.Import); ok {
+		for _, h := range e.hooks {
+			h.ModuleCall(call, imp, args, result)
+		}
+	}
+	return result
 }
 
 func (e *Evaluator) evalObjectAttrExpr(obj object.Object, attr *ast.Identifier, env *object.Environment) object.Object {
 	switch obj := obj.(type) {
 	case *object.Hash:
 		return obj.Entries[attr.Value]
+	case *object.Import:
+		if value, ok := obj.GetValue(attr.Value); ok {
+			return value
+		}
+		err := object.NewErrorWithMsg(fmt.Sprintf("unknown value '%s' for module '%s'", attr.Value, obj.Inspect()))
+		err.Pos = attr.Pos()
+		return err
 	}
 	return nil
 }
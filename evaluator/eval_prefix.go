@@ -16,6 +16,8 @@ func (e *Evaluator) evalPrefixExpression(operator string, right object.Object) o
 	case right.Type() == object.INT_OBJ:
 		right := right.(*object.Int)
 		return e.evalIntegerPrefixExpression(operator, right)
+	case right.Type() == object.FLOAT_OBJ && operator == "-":
+		return object.NewFloat(-right.(*object.Float).Value)
 	}
 	return object.NewErrorWithMsg(fmt.Sprintf("invalid prefix operator %s for %s", operator, right.Inspect()))
 }
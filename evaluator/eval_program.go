@@ -7,8 +7,17 @@ import (
 	"github.com/hashicorp/go-multierror"
 )
 
-func (e *Evaluator) evalProgram(node *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
+func (e *Evaluator) evalProgram(node *ast.Program, env *object.Environment) (result object.Object) {
+	// a call to exit (e.g. os.exit) stops the program from wherever it is made
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(*object.Exit)
+			if !ok {
+				panic(r)
+			}
+			result = exit
+		}
+	}()
 	InitModules(e, env)
 
 	if node.ParseErrors != nil {
@@ -19,7 +28,7 @@ func (e *Evaluator) evalProgram(node *ast.Program, env *object.Environment) obje
 		if _, isComment := stmt.(*ast.CommentStmt); isComment {
 			continue
 		}
-		result = e.Eval(stmt, env)
+		result = e.evalStatement(stmt, env)
 		if result == nil {
 			continue
 		}
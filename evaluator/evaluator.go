@@ -1,10 +1,13 @@
 package evaluator
 
 import (
+	"bufio"
 	"ede/ast"
 	"ede/object"
 	"ede/token"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/hashicorp/go-multierror"
 )
@@ -18,9 +21,28 @@ var (
 // Evaluator is a structure that will define methods to be used
 // to evaluate the AST nodes.
 type Evaluator struct {
+	// Stdin, Stdout and Stderr are the streams used by builtins and modules.
+	// If nil, the process' standard streams are used.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Args are the arguments passed to the program, starting with the script name
+	Args []string
+	// File is the path of the script run, empty if the program is not read
+	// from a file, e.g. in the REPL
+	File string
+
 	pos      token.Pos
 	err      *object.Error
 	errStack error
+
+	stdin    *bufio.Reader
+	stdinSrc io.Reader // the reader buffered by stdin
+	builtins map[string]*object.Builtin
+	modules  map[string]object.Module
+	hooks    []Hook
+	reported *object.Error // the last error passed to the Error hooks
 }
 
 // New returns a new Evaluator
@@ -28,11 +50,45 @@ func New() *Evaluator {
 	return &Evaluator{}
 }
 
+// input returns the buffered reader of the input stream. A single reader is kept,
+// so that data read ahead by one call is not lost to the next, until Stdin is
+// replaced.
+func (e *Evaluator) input() *bufio.Reader {
+	var stdin io.Reader = os.Stdin
+	if e.Stdin != nil {
+		stdin = e.Stdin
+	}
+	if e.stdin == nil || e.stdinSrc != stdin {
+		e.stdin = bufio.NewReader(stdin)
+		e.stdinSrc = stdin
+	}
+	return e.stdin
+}
+
+// Streams returns the streams the evaluator reads from and writes to
+func (e *Evaluator) Streams() object.Streams {
+	streams := object.Streams{Stdin: e.input(), Stdout: e.Stdout, Stderr: e.Stderr}
+	if streams.Stdout == nil {
+		streams.Stdout = os.Stdout
+	}
+	if streams.Stderr == nil {
+		streams.Stderr = os.Stderr
+	}
+	return streams
+}
+
 // Eval walks through the AST and evaluates the nodes into an object
 func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
 	if node == nil {
 		return nil
 	}
+	if len(e.hooks) > 0 {
+		return e.evalHooked(node, env)
+	}
+	return e.eval(node, env)
+}
+
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	e.pos = node.Pos()
 	switch node := node.(type) {
 	case *ast.StringLiteral:
@@ -46,7 +102,7 @@ func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.NilLiteral:
 		return &object.Nil{}
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return e.evalIdentifier(node, env)
 	case *ast.IfStmt:
 		return e.evalIfExpression(node, env)
 	case *ast.InfixExpression:
@@ -82,18 +138,26 @@ func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.ExpressionStmt:
 		return e.Eval(node.Expr, env)
 	case *ast.ConditionalStmt:
+		if _, ok := node.Statement.(*ast.BlockStmt); !ok { // the single statement of an else branch
+			return e.evalStatement(node.Statement, env)
+		}
 		return e.Eval(node.Statement, env)
 	case *ast.ImportStmt:
 		return e.evalImportStmt(node, env)
 	case *ast.FunctionLiteral:
 		return &object.Function{Body: node.Body, Params: node.Params, ParentEnv: env}
+	case *ast.MacroLiteral:
+		return e.EvalError("macros can only be defined at the top level of the program", node.Pos())
 	case *ast.CallExpression:
+		if _, ok := isFormOf(node, quoteName, env); ok {
+			return e.quote(node, env)
+		}
 		fn := e.Eval(node.Function, env)
 		if e.isError(fn) {
 			return fn
 		}
 		args := e.evalArgs(node.Args, env)
-		return e.applyFunction(fn, args)
+		return e.applyFunction(node, fn, args)
 	case *ast.ArrayLiteral:
 		entries := e.evalArgs(node.Elements, env)
 		return &object.Array{Entries: &entries}
@@ -188,12 +252,12 @@ func (e *Evaluator) evalSet(args map[ast.Expression]struct{}, env *object.Enviro
 	return result
 }
 
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+func (e *Evaluator) evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	if obj, ok := env.Get(node.Value); ok {
 		return obj
 	}
 
-	if b, ok := builtins[node.Value]; ok {
+	if b, ok := e.builtin(node.Value); ok {
 		return b
 	}
 
@@ -208,7 +272,7 @@ func (e *Evaluator) evalBlockStmt(node *ast.BlockStmt, env *object.Environment)
 		return NULL
 	}
 	for _, stmt := range node.Statements {
-		result = e.Eval(stmt, env)
+		result = e.evalStatement(stmt, env)
 		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
 			return result
 		}
@@ -221,7 +285,7 @@ func (e *Evaluator) evalImportStmt(node *ast.ImportStmt, env *object.Environment
 		return object.NewErrorWithMsg("invalid import") //TODO improve error message
 	}
 
-	if mod, ok := modules[node.Value]; ok {
+	if mod, ok := e.modules[node.Value]; ok {
 		env.Set(node.Value, object.NewImport(mod, e))
 		return NULL
 	}
@@ -274,18 +338,22 @@ func (e *Evaluator) evalReassignmentStmt(node *ast.ReassignmentStmt, env *object
 func (e *Evaluator) evalIfExpression(node *ast.IfStmt, env *object.Environment) object.Object {
 	cond := e.Eval(node.Consequence.Condition, env)
 	if isTruthy(cond) {
+		e.branch(node, 0)
 		return e.Eval(node.Consequence, env)
 	} else {
-		for _, alt := range node.Alternatives {
+		for i, alt := range node.Alternatives {
 			if alt.Condition == nil { // normal else branch (else)
+				e.branch(node, i+1)
 				return e.Eval(alt, env)
 			}
 			cond := e.Eval(alt.Condition, env) // (else if branch)
 			if isTruthy(cond) {
+				e.branch(node, i+1)
 				return e.Eval(alt, env)
 			}
 		}
 	}
+	e.branch(node, len(node.Alternatives)+1)
 	return NULL
 }
 
@@ -317,12 +385,13 @@ func (e *Evaluator) evalMatchExpression(exprIdent *string, node *ast.MatchExpres
 		env.Set(*exprIdent, expr)
 	}
 
-	for _, matchCase := range node.Cases {
+	for i, matchCase := range node.Cases {
 		// evaluate each case
 		pattern := e.Eval(matchCase.Pattern, matchEnv)
 
 		// if the case matches the match expression, return the case output
 		if pattern != nil && pattern.Equal(expr) {
+			e.branch(node, i)
 			val := e.Eval(matchCase.Output, matchEnv)
 			if _, found := matchEnv.Get(token.ErrorIdentifier); found {
 				matchEnv.Set(token.ErrorIdentifier, object.NewString(fmt.Sprintf("error: %s", expr.Inspect())))
@@ -336,6 +405,7 @@ func (e *Evaluator) evalMatchExpression(exprIdent *string, node *ast.MatchExpres
 		}
 	}
 
+	e.branch(node, len(node.Cases))
 	// if no case matches and there is a default block
 	if node.Default != nil {
 		return e.Eval(node.Default, matchEnv)
@@ -421,7 +491,7 @@ func (e *Evaluator) booleanObj(boolean bool) *object.Boolean {
 	return FALSE
 }
 
-func (e *Evaluator) applyFunction(fn object.Object, args []object.Object) object.Object {
+func (e *Evaluator) applyFunction(call *ast.CallExpression, fn object.Object, args []object.Object) object.Object {
 	if e.isError(fn) {
 		return fn
 	}
@@ -436,14 +506,47 @@ func (e *Evaluator) applyFunction(fn object.Object, args []object.Object) object
 		for i, p := range fn.Params {
 			fnEnv.Set(p.Value, args[i])
 		}
-		result := e.Eval(fn.Body, fnEnv)
-		return unwrapReturnValue(result)
+		for _, h := range e.hooks {
+			h.Call(call, fn, args, fnEnv)
+		}
+		result := unwrapReturnValue(e.Eval(fn.Body, fnEnv))
+		for _, h := range e.hooks {
+			h.Return(call, fn, result)
+		}
+		return result
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return withPos(checkExit(fn.Fn(args...)), call)
 	}
 	return nil
 }
 
+// Apply calls the function or builtin with the arguments, e.g. to call a function
+// of a program that has been evaluated
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) object.Object {
+	if fn, ok := fn.(*object.Function); ok && len(args) != len(fn.Params) {
+		return object.CountArgumentError(fmt.Sprint(len(fn.Params)), len(args))
+	}
+	return e.applyFunction(nil, fn, args)
+}
+
+// withPos sets the position of an error returned by a builtin to the one of
+// the call, if the builtin did not set it
+func withPos(obj object.Object, call *ast.CallExpression) object.Object {
+	if err, ok := obj.(*object.Error); ok && call != nil && err.Pos == (token.Pos{}) {
+		err.Pos = call.Function.Pos()
+	}
+	return obj
+}
+
+// checkExit unwinds the evaluation of the program if the object is a request to exit.
+// The exit is recovered in evalProgram
+func checkExit(obj object.Object) object.Object {
+	if exit, ok := obj.(*object.Exit); ok {
+		panic(exit)
+	}
+	return obj
+}
+
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
@@ -488,5 +591,5 @@ func (e *Evaluator) EvalError(err string, pos token.Pos) *object.Error {
 	Line: %d
 	Column: %d
 	`, err, pos.Line, pos.Column)
-	return &object.Error{Message: msg}
+	return &object.Error{Message: msg, Pos: pos}
 }
@@ -8,10 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/exp/slices"
 )
@@ -56,6 +59,34 @@ func testEval(input string) object.Object {
 	resp := ev.Eval(program, env)
 	return resp
 }
+
+// inspectTest is a program and the inspection of its result, or the message of its
+// error
+type inspectTest struct {
+	input    string
+	expected string
+}
+
+// testInspect evaluates the input of each test after the prelude, e.g. the import of
+// a module, and compares the inspection of the result
+func testInspect(t *testing.T, prelude string, tests []inspectTest) {
+	t.Helper()
+	for _, tt := range tests {
+		evaluated := testEval(prelude + tt.input)
+		if evaluated == nil {
+			t.Errorf("%s - expected %s, got nothing", tt.input, tt.expected)
+			continue
+		}
+		got := evaluated.Inspect()
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		}
+		if got != tt.expected {
+			t.Errorf("%s - expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
 func testEval2(input string) (*Evaluator, object.Object) {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -815,6 +846,104 @@ func TestEvalStatements_ArrayOperations(t *testing.T) {
 	}
 }
 
+func TestEvalStatements_StringOperations(t *testing.T) {
+	tests := []inspectTest{
+		{`"Héllo".upper()`, "HÉLLO"},
+		{`"ÉCOLE".lower()`, "école"},
+		{`"hello wide-world, it's me".title()`, "Hello Wide-World, It's Me"},
+		{`"  pad  ".trim() + "|"`, "pad|"},
+		{`"xxhixx".trim("x")`, "hi"},
+		{`"xxhixx".trim_left("x")`, "hixx"},
+		{`"  hi  ".trim_right() + "|"`, "  hi|"},
+		{`"¿qué?".trim("¿?")`, "qué"},
+		{`"héllo".starts_with("hé")`, "true"},
+		{`"héllo".ends_with("lo")`, "true"},
+		{`"héllo".contains("xyz")`, "false"},
+		{`"héllo wörld".index_of("wö")`, "6"},
+		{`"aéaéa".last_index_of("a")`, "4"},
+		{`"abc".index_of("z")`, "-1"},
+		{`"banana".count("an")`, "2"},
+		{`"ab".repeat(3)`, "ababab"},
+		{`"é".pad_left(3)`, "  é"},
+		{`"7".pad_left(3, "0")`, "007"},
+		{`"ab".pad_right(5, "xy")`, "abxyx"},
+		{`"long".pad_left(2)`, "long"},
+		{"`one\ntwo\r\nthree\n`.lines()", "[one, two, three]"},
+		{`"日本語".chars()`, "[日, 本, 語]"},
+		{`"日本語".length()`, "3"},
+		{`len("日本語")`, "3"},
+		{`"  a  b   c ".fields()`, "[a, b, c]"},
+		{`"héllo".substring(1, 3)`, "él"},
+		{`"héllo".substring(2)`, "llo"},
+		{`"42".to_int() + 1`, "43"},
+		{`"2.5".to_float() * 2`, "5"},
+		{`"%s is %d".format("age", 30)`, "age is 30"},
+		{`"4x".to_int()`, `error: cannot parse "4x" as Int`},
+		{`"x".to_float()`, `error: cannot parse "x" as Float`},
+		{`"abc".substring(2, 5)`, "error: substring [2:5] out of range for a string of length 3"},
+		{`"abc".repeat(-1)`, "error: method 'repeat' expects a non-negative count, got -1"},
+		{`"ab".repeat(9223372036854775807)`, "error: method 'repeat' would return a string longer than 1073741824 bytes"},
+		{`"x".pad_left(9223372036854775807)`, "error: method 'pad_left' would return a string longer than 1073741824 bytes"},
+		{`"x".pad_right(600000000, "é")`, "error: method 'pad_right' would return a string longer than 1073741824 bytes"},
+		{`"abc".starts_with(1)`, "error: method 'starts_with' expects a String argument, got INT"},
+		{`"%d".format("a")`, `error: verb %d expects an INT argument, got STRING`},
+	}
+
+	testInspect(t, "", tests)
+}
+
+func TestEvalStatements_ArrayMethods(t *testing.T) {
+	tests := []inspectTest{
+		{`[1, 2, 3, 4].reduce(func(acc, x) { acc + x })`, "10"},
+		{`[1, 2, 3].reduce(func(acc, x) { acc + x }, 10)`, "16"},
+		{`["a", "b"].reduce(func(acc, x, i) { acc + x + i.string() }, "")`, "a0b1"},
+		{`[].reduce(func(acc, x) { acc + x })`, "error: method 'reduce' of an empty array requires an initial value"},
+		{`[1, 3, 5].any(func(x) { x > 4 })`, "true"},
+		{`[1, 3, 5].all(func(x) { x > 4 })`, "false"},
+		{`[true, 1, "a"].all()`, "true"},
+		{`[].any()`, "false"},
+		{`[1, 2, 3, 4].count(func(x) { x % 2 == 0 })`, "2"},
+		{`[1, 2, 1].count(1)`, "2"},
+		{`[1, 2, 3].sum()`, "6"},
+		{`[1, 2.5].sum()`, "3.5"},
+		{`[1, "a"].sum()`, "error: cannot sum non-number item at index 1"},
+		{`[3, 1.5, 2].min()`, "1.5"},
+		{`["pear", "fig", "banana"].max()`, "pear"},
+		{`["pear", "fig", "banana"].max(func(s) { s.length() })`, "banana"},
+		{`[].min()`, "nil"},
+		{`[1, "a"].max()`, "error: method 'max' cannot compare a and 1"},
+		{`[1, 2, 3].index_of(3)`, "2"},
+		{`[1, 2, 3].index_of(4)`, "-1"},
+		{`let a = [1, 3]; a.insert(1, 2); a`, "[1, 2, 3]"},
+		{`let a = [1, 3]; a.insert(3, 2)`, "error: index 3 out of range for insert in an array of length 2"},
+		{`let a = [1, 2, 3]; let x = a.remove_at(0); [x, a]`, "[1, [2, 3]]"},
+		{`[1, 2, 3, 4].slice(1, 3)`, "[2, 3]"},
+		{`[1, 2, 3, 4].slice(2)`, "[3, 4]"},
+		{`[1, 2].slice(1, 3)`, "error: slice [1:3] out of range for an array of length 2"},
+		{`[1, 2, 1, "a", [1], "a", [1]].unique()`, "[1, 2, a, [1]]"},
+		{`[1, [2, [3, [4]]]].flatten()`, "[1, 2, [3, [4]]]"},
+		{`[1, [2, [3, [4]]]].flatten(2)`, "[1, 2, 3, [4]]"},
+		{`[1, 2, 3].zip(["a", "b"])`, "[[1, a], [2, b]]"},
+		{`[1, 2, 3, 4, 5].chunk(2)`, "[[1, 2], [3, 4], [5]]"},
+		{`[1, 2].chunk(0)`, "error: method 'chunk' expects a positive size, got 0"},
+		{`let g = [1, 2, 3, 4, 5].group_by(func(x) { x % 2 }); [g["0"], g["1"]]`, "[[2, 4], [1, 3, 5]]"},
+		{`let g = ["a", "", " "].group_by(func(x) { x.trim() }); let empty = g[""]; [empty.length(), g["a"]]`, "[2, [a]]"},
+		{`[1, 2, 3, 4].partition(func(x) { x > 2 })`, "[[3, 4], [1, 2]]"},
+		{`[1, 2, 3].take(2)`, "[1, 2]"},
+		{`[1, 2, 3].drop(2)`, "[3]"},
+		{`[1, 2, 3].take(5)`, "[1, 2, 3]"},
+		{`let total = [0]; [1, 2].each(func(x, i) { total.push(x * i) }); total`, "[0, 0, 2]"},
+		{`let a = [1, 2]; let b = a.copy(); b.push(3); [a, b]`, "[[1, 2], [1, 2, 3]]"},
+		{`let a = [1, 2]; a.map(func(x, i) { x * 10 + i }); a`, "[10, 21]"},
+		{`[1, 2].any(func(x) { x + "a" })`, "error: invalid infix operator + for (1) and (a)"},
+		{`let a = [1, 2]; a.map(func(x) { x + "a" })`, "error: invalid infix operator + for (1) and (a)"},
+		{`[1, 2].each(1)`, "error: method 'each' expects a function argument, got INT"},
+		{`[1, 2].reduce(func(a, b, c, d) { a })`, "error: expected 4 argument(s), got 3"},
+	}
+
+	testInspect(t, "", tests)
+}
+
 func TestEvalStatements_RangeArray(t *testing.T) {
 
 	tests := []struct {
@@ -988,6 +1117,35 @@ func TestEvalStatements_HashOperations(t *testing.T) {
 	}
 }
 
+func TestEvalStatements_HashMethods(t *testing.T) {
+	tests := []inspectTest{
+		{`let h = {"a": 1, "b": 2}; let v = h.delete("a"); [v, h.to_array()]`, "[1, [[b, 2]]]"},
+		{`let h = {"a": 1}; h.delete("z")`, "nil"},
+		{`{"b": 2, "a": 1, "c": 3}.values()`, "[1, 2, 3]"},
+		{`{"a": 1, "b": 2}.length()`, "2"},
+		{`len({"a": 1, "b": 2, "c": 3})`, "3"},
+		{`let h = {"a": 1, "b": {"x": 1}}; let m = h.merge({"b": {"y": 2}}, {"c": 3}); [m.to_array(), h.length()]`, "[[[a, 1], [b, {\n  y: 2\n}], [c, 3]], 2]"},
+		{`let m = {"a": {"x": 1, "y": {"p": 1}}}.deep_merge({"a": {"y": {"q": 2}}}); [m.get("a.x"), m.get("a.y.p"), m.get("a.y.q")]`, "[1, 1, 2]"},
+		{`let m = {"a": 1, "b": 2}.map_values(func(v) { v * 10 }); m.to_array()`, "[[a, 10], [b, 20]]"},
+		{`let m = {"a": 1, "b": 2}.map_values(func(v, k) { k + v.string() }); m.values()`, "[a1, b2]"},
+		{`let f = {"a": 1, "b": 2, "c": 3}.filter(func(k, v) { v > 1 && k == "b" }); f.to_array()`, "[[b, 2]]"},
+		{`let p = {"a": 1, "b": 2, "c": 3}.pick("a", "c"); p.to_array()`, "[[a, 1], [c, 3]]"},
+		{`let p = {"a": 1, "b": 2, "c": 3}.omit(["a", "c"]); p.to_array()`, "[[b, 2]]"},
+		{`let i = {"a": "x", "b": 2}.invert(); i.to_array()`, "[[2, b], [x, a]]"},
+		{`{"a": [1]}.invert()`, "error: cannot invert non-hashable value [1]"},
+		{`{"a": {"b": [10, {"c": "deep"}]}}.get("a.b.1.c")`, "deep"},
+		{`{"a": {"b": [10, 20]}}.get(["a", "b", 0])`, "10"},
+		{`{"a.b": 1}.get("a.b")`, "1"},
+		{`{"a": {"b": 1}}.get("a.c", "none")`, "none"},
+		{`{"a": [1]}.get("a.5")`, "nil"},
+		{`{"a": 1}.merge(1)`, "error: method 'merge' expects a hash argument, got INT"},
+		{`{"a": 1}.map_values(func(v) { v + "x" })`, "error: invalid infix operator + for (1) and (x)"},
+		{`{"a": 1}.equal({"a": 1, "b": 2})`, "false"},
+	}
+
+	testInspect(t, "", tests)
+}
+
 func TestEvalStatements_SetOperations(t *testing.T) {
 
 	tests := []struct {
@@ -1336,6 +1494,546 @@ func TestEval_Module(t *testing.T) {
 			t.Fatalf("expected reparsed object to be same as original object")
 		}
 	})
+
+	t.Run("json values", func(t *testing.T) {
+		dir := t.TempDir()
+		values := filepath.Join(dir, "values.jsonl")
+		if err := os.WriteFile(values, []byte("{\"a\": 1}\n\n[2]\n3\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		invalid := filepath.Join(dir, "invalid.jsonl")
+		if err := os.WriteFile(invalid, []byte("1\n{x}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []inspectTest{
+			{"json.parse(`[1, 2.5, null, true, \"a\"]`)", "[1, 2.5, nil, true, a]"},
+			{"let v = json.parse(`42`)\nv.type()", "INT"},
+			{"let v = json.parse(`4.0`)\nv.type()", "FLOAT"},
+			{"json.parse(` null `)", "nil"},
+			{"let v = json.parse(`{\"a\": {\"b\": [1, 2]}}`)\nv.get(\"a.b\")", "[1, 2]"},
+			{"json.string([1, nil, \"<b>\", {\"k\": 2.5}])", `[1,null,"<b>",{"k":2.5}]`},
+			{"json.string({3, 1, 2})", "[1,2,3]"},
+			{"json.string(time.parse(\"2024-01-02\", \"2006-01-02\"))", `"2024-01-02T00:00:00Z"`},
+			{"json.string(\"a\")", `"a"`},
+			{"json.pretty({\"a\": [1]})", "{\n  \"a\": [\n    1\n  ]\n}"},
+			{"json.pretty([1], 4)", "[\n    1\n]"},
+			{`let values = []
+			for v = range json.lines("` + values + `") {
+				values.push([index, v])
+			}
+			values`, "[[0, {\n  a: 1\n}], [1, [2]], [2, 3]]"},
+			{"json.parse(`{\"a\":\n  }`)", "error: error parsing string as json at line 2, column 3 (offset 8): invalid character '}' looking for beginning of value"},
+			{"json.parse(`1 2`)", "error: error parsing string as json at line 1, column 3 (offset 2): invalid character '2' after top-level value"},
+			{"json.parse(``)", "error: error parsing string as json at line 1, column 1 (offset 0): unexpected end of JSON input"},
+			{`for v = range json.lines("` + invalid + `") {
+				v
+			}`, "error: error parsing json line at line 2, column 2 (offset 3): invalid character 'x' looking for beginning of object key string"},
+			{`for v = range json.lines("` + dir + `/none.jsonl") {
+				v
+			}`, "error: error opening json lines file: open " + dir + "/none.jsonl: no such file or directory"},
+			{"json.string(func() {})", "error: error encoding value as json: unsupported value of type FUNCTION"},
+		}
+		testInspect(t, "import json\nimport time\n", tests)
+	})
+}
+
+func TestEval_OSModule(t *testing.T) {
+	t.Run("os.args", func(t *testing.T) {
+		program := parser.New(lexer.New("import os; os.args()")).Parse()
+		ev := &Evaluator{Args: []string{"script.ede", "foo"}}
+		evaluated := ev.Eval(program, object.NewEnvironment(nil))
+		if !testObject(t, evaluated, []string{"script.ede", "foo"}) {
+			t.Fatalf("expected %v, got %v", ev.Args, evaluated.Inspect())
+		}
+	})
+
+	t.Run("os.exit", func(t *testing.T) {
+		input := `
+		import os
+		let stop = func(code) {
+			for i = range [1..3] {
+				if (i == 2) {
+					os.exit(code)
+				}
+			}
+		}
+		let res = stop(3)
+		println("should not get here")
+		`
+		stdout, _, evaluated := testEvalWithIO(input, "")
+		exit, ok := evaluated.(*object.Exit)
+		if !ok {
+			t.Fatalf("expected an exit to be returned, got %T", evaluated)
+		}
+		if exit.Code != 3 {
+			t.Fatalf("expected exit code 3, got %d", exit.Code)
+		}
+		if stdout != "" {
+			t.Fatalf("expected no output, got %q", stdout)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("EDE_TEST_VAR", "foo")
+		input := `
+		import env
+		env.set("EDE_TEST_OTHER", 10)
+		let vars = env.list()
+		[env.get("EDE_TEST_VAR"), env.get("EDE_TEST_OTHER"), env.get("EDE_TEST_UNSET", "default"), vars["EDE_TEST_VAR"]]
+		`
+		t.Cleanup(func() { os.Unsetenv("EDE_TEST_OTHER") })
+		evaluated := testEval(input)
+		exp := []string{"foo", "10", "default", "foo"}
+		if !testObject(t, evaluated, exp) {
+			t.Fatalf("expected %v, got %v", exp, evaluated.Inspect())
+		}
+		if testEval(`import env; env.get("EDE_TEST_UNSET")`) != object.NIL {
+			t.Fatalf("expected nil for unset variable")
+		}
+	})
+}
+
+func TestEval_TestingModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // the message of the error, empty if the assertion passes
+	}{
+		{`testing.assert(1 == 1)`, ""},
+		{`testing.assert(1 == 2)`, "assertion failed"},
+		{`testing.assert(false, "one is two")`, "one is two: assertion failed"},
+		{`testing.assert_eq("a", "a")`, ""},
+		{`testing.assert_eq(1, "1")`, `assert_eq failed: got 1, want "1"`},
+		{`testing.assert_eq([1, [2]], [1, [2]])`, ""},
+		{`testing.assert_eq([1, [2]], [1, [3], 4])`, "assert_eq failed, the values differ at:\n\t[1][0]: got 2, want 3\n\t[2]: missing, want 4"},
+		{`testing.assert_eq({"a": 1, "b": 2}, {"a": 2, "c": 2})`, "assert_eq failed, the values differ at:\n\t[\"a\"]: got 1, want 2\n\t[\"b\"]: unexpected 2\n\t[\"c\"]: missing, want 2"},
+		{`testing.assert_error(1 + "a", "invalid infix")`, ""},
+		{`testing.assert_error(1)`, "assert_error failed: expected an error, got 1"},
+		{`testing.assert_error(1 + "a", "foo")`, `assert_error failed: expected an error containing "foo", got "error: invalid infix operator + for (1) and (a)"`},
+		{`testing.skip("later")`, "skipped: later"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval("import testing\n" + tt.input)
+		if tt.expected == "" {
+			if err, ok := evaluated.(*object.Error); ok {
+				t.Errorf("%s - unexpected error: %s", tt.input, err.Message)
+			}
+			continue
+		}
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s - expected an error, got %v", tt.input, evaluated)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%s - wrong message.\nexpected=%q\ngot=     %q", tt.input, tt.expected, err.Message)
+		}
+		if err.Pos.Line != 2 || err.Pos.Column != 9 {
+			t.Errorf("%s - expected the error at 2:9, got %d:%d", tt.input, err.Pos.Line, err.Pos.Column)
+		}
+	}
+}
+
+func TestEval_MathModule(t *testing.T) {
+	tests := []inspectTest{
+		{`math.abs(-3)`, "3"},
+		{`math.abs(-2.5)`, "2.5"},
+		{`math.floor(2.7)`, "2"},
+		{`math.ceil(2.1)`, "3"},
+		{`math.floor(4)`, "4"},
+		{`math.round(2.5)`, "3"},
+		{`math.round(3.14159, 2)`, "3.14"},
+		{`math.round(1234, -2)`, "1200"},
+		{`math.round(2.5, 400)`, "2.5"},
+		{`math.round(1234.5, -400)`, "0"},
+		{`math.round(1250, -2)`, "1300"},
+		{`math.round(-1250, -2)`, "-1300"},
+		{`math.round(-1249, -2)`, "-1200"},
+		{`math.round(9000000000000000000, -400)`, "0"},
+		{`math.round(4000000000000000000, -19)`, "0"},
+		{`math.round(9223372036854775807, -2)`, "9223372036854775800"},
+		{`math.sqrt(16)`, "4"},
+		{`math.pow(2, 10)`, "1024"},
+		{`math.exp(0)`, "1"},
+		{`math.log(math.e)`, "1"},
+		{`math.log(8, 2)`, "3"},
+		{`math.log2(8)`, "3"},
+		{`math.log10(1000)`, "3"},
+		{`math.sin(0)`, "0"},
+		{`math.cos(0)`, "1"},
+		{`math.atan2(0, 1)`, "0"},
+		{`math.min(3, 1.5, 2)`, "1.5"},
+		{`math.max([3, 7, 2])`, "7"},
+		{`math.clamp(12, 0, 10)`, "10"},
+		{`math.clamp(-1, 0, 10)`, "0"},
+		{`math.clamp(5, 0, 10)`, "5"},
+		{`math.gcd(12, 18)`, "6"},
+		{`math.lcm(4, 6)`, "12"},
+		{`math.lcm(-4, 6)`, "12"},
+		{`math.is_nan(math.sqrt(-1))`, "true"},
+		{`math.is_inf(math.inf)`, "true"},
+		{`math.is_inf(-math.inf)`, "true"},
+		{`math.is_inf(1)`, "false"},
+		{`math.pi > 3.14 && math.pi < 3.15`, "true"},
+		{`math.sqrt("a")`, "error: expected math.sqrt to receive argument of type 'Int' or 'Float', got *object.String"},
+		{`math.max([])`, "error: expected math.max to receive at least 1 number"},
+		{`math.clamp(1, 10, 0)`, "error: expected the minimum of math.clamp to be at most its maximum, got 10 and 0"},
+		{`math.gcd(1.5, 2)`, "error: expected math.gcd to receive argument of type 'Int', got *object.Float"},
+		{`math.lcm(9223372036854775807, 2)`, "error: math.lcm(9223372036854775807, 2) overflows an 'Int'"},
+		{`math.round(9000000000000000000, -19)`, "error: math.round(9000000000000000000, -19) overflows an 'Int'"},
+		{`math.round(9223372036854775807, -1)`, "error: math.round(9223372036854775807, -1) overflows an 'Int'"},
+		{`math.abs(-9223372036854775807 - 1)`, "error: math.abs(-9223372036854775808) overflows an 'Int'"},
+		{`math.tau`, "error: unknown value 'tau' for module 'math'"},
+	}
+
+	testInspect(t, "import math\n", tests)
+}
+
+func TestEval_RandomModule(t *testing.T) {
+	t.Run("seed", func(t *testing.T) {
+		input := `
+		import random
+		let draw = func() {
+			let picks = [random.choice(["a", "b", "c"]), random.shuffle([1..10]), random.sample([1..10], 3)]
+			[random.int(1, 6), random.float(), random.bool(0.5), random.normal(0, 1), picks]
+		}
+		random.seed(7)
+		let first = draw()
+		random.seed(7)
+		[first, draw()]
+		`
+		evaluated, ok := testEval(input).(*object.Array)
+		if !ok {
+			t.Fatalf("expected an array, got %T", evaluated)
+		}
+		draws := *evaluated.Entries
+		if draws[0].Inspect() != draws[1].Inspect() {
+			t.Fatalf("expected the same values with the same seed, got %s and %s", draws[0].Inspect(), draws[1].Inspect())
+		}
+	})
+
+	t.Run("ranges", func(t *testing.T) {
+		input := `
+		import random
+		let values = []
+		for i = range [1..100] {
+			values.push(random.int(-2, 2))
+			values.push(random.crypto_int(5, 6))
+		}
+		values
+		`
+		evaluated, ok := testEval(input).(*object.Array)
+		if !ok {
+			t.Fatalf("expected an array, got %T", evaluated)
+		}
+		for i, v := range *evaluated.Entries {
+			n := v.(*object.Int).Value
+			if i%2 == 0 && (n < -2 || n > 2) || i%2 == 1 && (n < 5 || n > 6) {
+				t.Fatalf("value %d out of range at %d", n, i)
+			}
+		}
+
+		sample, ok := testEval("import random\nrandom.sample([1..5], 5)").(*object.Array)
+		if !ok {
+			t.Fatalf("expected an array, got %T", sample)
+		}
+		var got []int
+		for _, v := range *sample.Entries {
+			got = append(got, int(v.(*object.Int).Value))
+		}
+		sort.Ints(got)
+		if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+			t.Fatalf("expected the sample of all the elements, got %v", got)
+		}
+		bytes, ok := testEval("import random\nrandom.bytes(8)").(*object.Array)
+		if !ok || len(*bytes.Entries) != 8 {
+			t.Fatalf("expected 8 bytes, got %v", bytes)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected string
+		}{
+			{`random.int(3, 1)`, "error: expected the lower bound of random.int to be at most its upper bound, got 3 and 1"},
+			{`random.choice([])`, "error: random.choice of an empty array"},
+			{`random.sample([1, 2], 3)`, "error: expected random.sample to take between 0 and 2 elements, got 3"},
+			{`random.bool(2)`, "error: expected the probability of random.bool to be between 0 and 1, got 2"},
+			{`random.shuffle("abc")`, "error: expected random.shuffle to receive argument of type 'Array', got *object.String"},
+			{`random.seed()`, "error: expected 1 argument(s), got 0"},
+			{`random.bytes(100000000000000)`, "error: expected random.bytes to take at most 1048576 bytes, got 100000000000000"},
+		}
+		for _, tt := range tests {
+			err, ok := testEval("import random\n" + tt.input).(*object.Error)
+			if !ok {
+				t.Errorf("%s - expected an error", tt.input)
+				continue
+			}
+			if err.Message != tt.expected {
+				t.Errorf("%s - expected %s, got %s", tt.input, tt.expected, err.Message)
+			}
+		}
+	})
+}
+
+func TestEval_CSVModule(t *testing.T) {
+	dir := t.TempDir()
+	people := filepath.Join(dir, "people.csv")
+	if err := os.WriteFile(people, []byte("name,age\nann,30\nbob,41\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	malformed := filepath.Join(dir, "malformed.csv")
+	if err := os.WriteFile(malformed, []byte("a,b\n1,2\n3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []inspectTest{
+		{"csv.parse(`a,b\n1,2`)", "[[a, b], [1, 2]]"},
+		{"csv.parse(``)", "[]"},
+		{`csv.parse("a;b", {"delimiter": ";"})`, "[[a, b]]"},
+		{"let rows = csv.parse(`name,age\nann,30`, {\"header\": true})\n[rows.length(), rows[0][\"name\"], rows[0][\"age\"]]", "[1, ann, 30]"},
+		{`csv.stringify([["a", 1], ["b,c", nil]])`, "a,1\n\"b,c\",\n"},
+		{`csv.stringify([{"name": "ann", "age": 30}])`, "age,name\n30,ann\n"},
+		{`csv.stringify([{"name": "ann", "age": 30}], {"columns": ["name"]})`, "name\nann\n"},
+		{`csv.stringify([{"name": "ann"}], {"header": false})`, "ann\n"},
+		{`csv.stringify([[1, 2]], {"columns": ["x", "y"], "delimiter": "|"})`, "x|y\n1|2\n"},
+		{`let names = []
+		for row = range csv.rows("` + people + `", {"header": true}) {
+			names.push([index, row["name"]])
+		}
+		names`, "[[0, ann], [1, bob]]"},
+		{`let count = 0
+		for row = range csv.rows("` + people + `") {
+			count = count + 1
+		}
+		count`, "3"},
+		{`for row = range csv.rows("` + malformed + `") {
+			row
+		}`, "error: error parsing csv file " + malformed + ": record on line 3: wrong number of fields"},
+		{`let rows = csv.rows("` + people + `")
+		let count = 0
+		for row = range rows {
+			count = count + 1
+		}
+		for row = range rows {
+			count = count + 1
+		}
+		count`, "6"},
+		{`let rows = csv.rows("` + dir + `/missing.csv")
+		for row = range rows {
+			row
+		}`, "error: error opening csv file: open " + dir + "/missing.csv: no such file or directory"},
+		{"csv.parse(`a,b\n1,\"2`)", "error: error parsing csv: parse error on line 2, column 5: extraneous or missing \" in quoted-field"},
+		{"csv.parse(`a\nb,c`)", "error: error parsing csv: record on line 2: wrong number of fields"},
+		{`csv.parse("a", {"delimiter": ";;"})`, "error: expected the delimiter option of csv.parse to be a single character, got ;;"},
+		{"csv.stringify([[\"a\", \"b\"]], {\"delimiter\": `\"`})", "error: invalid delimiter \"\\\"\" for csv.stringify"},
+		{"csv.parse(`a`, {\"delimiter\": `\n`})", "error: invalid delimiter \"\\n\" for csv.parse"},
+		{`csv.parse("a", {"headers": true})`, "error: unknown option 'headers' for csv.parse"},
+		{`csv.stringify([1])`, "error: expected the row 0 of csv.stringify to be an 'Array' or a 'Hash', got *object.Int"},
+		{`csv.rows(1)`, "error: expected csv.rows to receive argument of type 'String', got *object.Int"},
+	}
+
+	testInspect(t, "import csv\n", tests)
+}
+
+func TestEval_FSModule(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("one\r\ntwo\n\nthree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(notes, 0o644); err != nil { // regardless of the umask
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []inspectTest{
+		{`fs.list(dir)`, "[notes.txt, sub]"},
+		{`let content = fs.read(dir + "/notes.txt")
+		content.lines()`, "[one, two, , three]"},
+		{`let lines = []
+		for line = range fs.lines(dir + "/notes.txt") {
+			lines.push([index, line])
+		}
+		lines`, "[[0, one], [1, two], [2, ], [3, three]]"},
+		{`let lines = fs.lines(dir + "/notes.txt")
+		let count = 0
+		for line = range lines {
+			count = count + 1
+		}
+		for line = range lines {
+			count = count + 1
+		}
+		count`, "8"},
+		{`fs.write(dir + "/out.txt", "a")
+		fs.append(dir + "/out.txt", "b")
+		fs.append(dir + "/new.txt", "c")
+		[fs.read(dir + "/out.txt"), fs.read(dir + "/new.txt")]`, "[ab, c]"},
+		{`fs.write(dir + "/out.txt", "a")
+		fs.write(dir + "/out.txt", "z")
+		fs.read(dir + "/out.txt")`, "z"},
+		{`[fs.exists(dir + "/notes.txt"), fs.exists(dir + "/sub"), fs.exists(dir + "/none")]`, "[true, true, false]"},
+		{`let info = fs.stat(dir + "/notes.txt")
+		[info["name"], info["size"], info["mode"], info["is_dir"], info["mtime"].type()]`, "[notes.txt, 15, -rw-r--r--, false, TIME]"},
+		{`fs.mkdir(dir + "/a/b/c", {"parents": true})
+		fs.mkdir(dir + "/a/b", {"parents": true})
+		fs.write(dir + "/a/b/c/d.txt", "d")
+		let found = []
+		fs.walk(dir + "/a", func(path, info) { found.push([path.replace(dir, ""), info["is_dir"]]) })
+		found`, "[[/a, true], [/a/b, true], [/a/b/c, true], [/a/b/c/d.txt, false]]"},
+		{`fs.walk(dir + "/a", func(path) { 1 + "a" })`, "error: invalid infix operator + for (1) and (a)"},
+		{`fs.copy(dir + "/notes.txt", dir + "/copy.txt")
+		fs.rename(dir + "/copy.txt", dir + "/sub/moved.txt")
+		[fs.exists(dir + "/copy.txt"), fs.read(dir + "/sub/moved.txt") == fs.read(dir + "/notes.txt")]`, "[false, true]"},
+		{`match (fs.copy(dir + "/notes.txt", dir + "/sub/../notes.txt")) {
+			case fs.invalid: "invalid"
+		}`, "invalid"},
+		{`fs.copy(dir + "/notes.txt", dir + "/notes.txt")`, "error: cannot copy " + dir + "/notes.txt onto itself: invalid argument"},
+		{`fs.read(dir + "/notes.txt")`, "one\r\ntwo\n\nthree"}, // not truncated by the copies onto itself
+		{`fs.glob(dir + "/*.txt")`, "[" + dir + "/new.txt, " + dir + "/notes.txt, " + dir + "/out.txt]"},
+		{`fs.mkdir(dir + "/gone/x", {"parents": true})
+		fs.remove(dir + "/gone", {"recursive": true})
+		fs.exists(dir + "/gone")`, "false"},
+		{`let tmp = fs.temp_dir("ede-test")
+		let exists = fs.exists(tmp)
+		fs.remove(tmp)
+		[exists, fs.exists(tmp)]`, "[true, false]"},
+		{`match (fs.read(dir + "/none")) {
+			case fs.permission: "denied"
+			case fs.not_found: "missing"
+		}`, "missing"},
+		{`match (fs.mkdir(dir + "/sub")) {
+			case fs.not_found: "missing"
+			case fs.exists: "exists"
+		}`, "exists"},
+		{`fs.read(dir + "/none")`, "error: open " + dir + "/none: no such file or directory"},
+		{`let lines = fs.lines(dir + "/none")
+		for line = range lines {
+			line
+		}`, "error: open " + dir + "/none: no such file or directory"},
+		{`fs.remove(dir + "/sub")`, "error: remove " + dir + "/sub: directory not empty"},
+		{`fs.remove(dir + "/none", {"recursive": true})`, "error: lstat " + dir + "/none: no such file or directory"},
+		{`fs.mkdir(dir + "/x", {"parent": true})`, "error: unknown option 'parent' for fs.mkdir"},
+		{`fs.mkdir(dir + "/x", {"parents": true, "bogus": 1})`, "error: unknown option 'bogus' for fs.mkdir"},
+		{`fs.write(dir + "/x", 1)`, "error: expected fs.write to receive argument of type 'String', got *object.Int"},
+		{`fs.glob("[")`, "error: invalid pattern \"[\" for fs.glob: syntax error in pattern"},
+		{`fs.timeout`, "error: unknown value 'timeout' for module 'fs'"},
+	}
+
+	testInspect(t, "import fs\nlet dir = \""+dir+"\"\n", tests)
+}
+
+func TestEval_PathModule(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []inspectTest{
+		{`path.join("a", "b/", "", "../c", "d.txt")`, "a/c/d.txt"},
+		{`path.join()`, ""},
+		{`path.base("/a/b/c.tar.gz")`, "c.tar.gz"},
+		{`path.dir("/a/b/c.txt")`, "/a/b"},
+		{`path.ext("/a/b/c.tar.gz")`, ".gz"},
+		{`path.ext("/a/b/c")`, ""},
+		{`path.stem("/a/b/c.tar.gz")`, "c.tar"},
+		{`path.stem(".bashrc")`, ""},
+		{`path.clean("a//b/./c/..")`, "a/b"},
+		{`path.split("/a/b/c.txt")`, "[/a/b/, c.txt]"},
+		{`path.rel("/a", "/a/b/c")`, "b/c"},
+		{`path.rel("/a/x", "/a/b")`, "../b"},
+		{`path.abs("/a/../b")`, "/b"},
+		{`path.abs("x")`, filepath.Join(wd, "x")},
+		{`path.match("*.ede", "main.ede")`, "true"},
+		{`path.match("*.ede", "dir/main.ede")`, "false"},
+		{`path.script_dir()`, wd},
+		{`path.rel("a", "/b")`, "error: Rel: can't make /b relative to a"},
+		{`path.match("[", "a")`, "error: invalid pattern \"[\" for path.match: syntax error in pattern"},
+		{`path.join("a", 1)`, "error: expected path.join to receive argument of type 'String', got *object.Int"},
+		{`path.base()`, "error: expected 1 argument(s), got 0"},
+	}
+
+	testInspect(t, "import path\n", tests)
+}
+
+func TestEval_ExecModule(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("the tests run /bin/sh and /bin/echo")
+	}
+	dir := t.TempDir()
+
+	tests := []inspectTest{
+		{`let r = exec.run("/bin/echo", ["hello", "world"])
+		[r["stdout"], r["stderr"], r["code"], r.get("duration") > 0.0]`, "[hello world\n, , 0, true]"},
+		{`exec.output("/bin/echo", ["a b"])`, "a b"},
+		{`exec.output("/bin/echo")`, ""},
+		{`exec.output("/bin/sh", ["-c", "printf $GREETING"], {"env": {"GREETING": "hi"}})`, "hi"},
+		{`exec.output("/bin/sh", ["-c", "pwd"], {"cwd": "` + dir + `"})`, dir},
+		{`exec.output("/bin/sh", ["-c", "tr a-z A-Z"], {"stdin": "shout"})`, "SHOUT"},
+		{`let r = exec.run("/bin/sh", ["-c", "echo oops >&2; exit 3"], {"check": false})
+		[r["code"], r["stderr"]]`, "[3, oops\n]"},
+		{`exec.run("/bin/sh", ["-c", "echo oops >&2; exit 3"])`, `error: command "/bin/sh" exited with code 3: oops`},
+		{`exec.output("/bin/sh", ["-c", "exit 1"])`, `error: command "/bin/sh" exited with code 1`},
+		{`exec.run("/bin/sh", ["-c", "sleep 5"], {"timeout": 0.05})`, `error: command "/bin/sh" timed out after 50ms`},
+		{`match (exec.run("/bin/sh", ["-c", "exit 2"])) {
+			case exec.timeout: "timeout"
+			case exec.exit: "exit"
+		}`, "exit"},
+		{`match (exec.run("/bin/sh", ["-c", "sleep 5"], {"timeout": 0.05})) {
+			case exec.exit: "exit"
+			case exec.timeout: "timeout"
+		}`, "timeout"},
+		{`match (exec.run("ede-no-such-command")) {
+			case exec.not_found: "not found"
+		}`, "not found"},
+		{`match (exec.run("/bin/sh", [], {"cwd": "` + dir + `/none"})) {
+			case exec.not_found: "not found"
+		}`, "not found"},
+		{`let out = []
+		let r = exec.spawn("/bin/sh", ["-c", "echo a; echo b >&2; echo c"], func(line, stream) { out.push(stream + ":" + line) })
+		let ordered = out.index_of("stdout:a") < out.index_of("stdout:c")
+		[out.length(), out.contains("stderr:b"), ordered, r["code"], r.length()]`, "[3, true, true, 0, 2]"},
+		{`let out = []
+		exec.spawn("/bin/sh", ["-c", "echo 1; echo 2; sleep 5; echo 3"], func(line) {
+			out.push(line)
+			if (line == "2") {
+				return 1 + "a"
+			}
+		})`, "error: invalid infix operator + for (1) and (a)"},
+		{`exec.spawn("/bin/sh", ["-c", "sleep 5"], func(line) { line }, {"timeout": 0.05})`, `error: command "/bin/sh" timed out after 50ms`},
+		{`exec.spawn("/bin/sh", ["-c", "exit 4"], func(line) { line })`, `error: command "/bin/sh" exited with code 4`},
+		{`exec.run("/bin/echo", [1])`, "error: expected exec.run to receive argument of type 'String', got *object.Int"},
+		{`exec.run("/bin/echo", [], {"timeout": -1})`, "error: expected the timeout option of exec.run to be a positive number of seconds, got -1"},
+		{`exec.run("/bin/echo", [], {"shell": true})`, "error: unknown option 'shell' for exec.run"},
+		{`exec.spawn("/bin/echo", [], 1)`, "error: expected exec.spawn to receive argument of type 'Function', got *object.Int"},
+	}
+
+	testInspect(t, "import exec\n", tests)
+
+	t.Run("os.exit in exec.spawn", func(t *testing.T) {
+		goroutines := runtime.NumGoroutine()
+		input := `
+		import exec
+		import os
+		exec.spawn("/bin/sh", ["-c", "while true; do echo y; done"], func(line) { os.exit(3) })
+		`
+		_, _, evaluated := testEvalWithIO(input, "")
+		exit, ok := evaluated.(*object.Exit)
+		if !ok || exit.Code != 3 {
+			t.Fatalf("expected an exit with code 3, got %v", evaluated.Inspect())
+		}
+		// the readers of the killed command return after the exit
+		for i := 0; runtime.NumGoroutine() > goroutines; i++ {
+			if i == 100 {
+				t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
 }
 
 func TestEval_Method_Error(t *testing.T) {
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"ede/ast"
+	"ede/object"
+)
+
+// Hook observes the evaluation of a program, e.g. to debug or audit it. The
+// methods are called synchronously, so the evaluation waits for them to return.
+// The evaluation is not slowed down when no hook is added.
+type Hook interface {
+	// Enter is called before a node is evaluated in the environment
+	Enter(node ast.Node, env *object.Environment)
+	// Exit is called after a node is evaluated, with its value
+	Exit(node ast.Node, result object.Object)
+	// Statement is called before a statement is evaluated in the environment
+	Statement(stmt ast.Statement, env *object.Environment)
+	// Call is called before the body of a function is evaluated. env holds the
+	// parameters of the function. call is nil when the function is called with Apply.
+	Call(call *ast.CallExpression, fn *object.Function, args []object.Object, env *object.Environment)
+	// Return is called after the body of the function called is evaluated
+	Return(call *ast.CallExpression, fn *object.Function, result object.Object)
+	// ModuleCall is called after a function of an imported module is called, e.g.
+	// json.parse(s)
+	ModuleCall(call *ast.CallExpression, imp *object.Import, args []object.Object, result object.Object)
+	// Error is called when the evaluation of a node results in an error. It is
+	// called once per error, for the innermost node, and not again as the error
+	// is returned by the enclosing nodes.
+	Error(node ast.Node, err *object.Error)
+	// Branch is called when a branch of an if statement, a match expression or a
+	// for loop is taken. For an *ast.IfStmt, branch is 0 for the consequence, i+1
+	// for Alternatives[i] and len(Alternatives)+1 when no branch is taken. For an
+	// *ast.MatchExpression, it is the index of the case matched, or len(Cases) for
+	// the default. For an *ast.ForLoopStmt, it is 0 each time the body runs, and 1
+	// when the loop ends without running it.
+	Branch(node ast.Node, branch int)
+}
+
+// NopHook is a Hook doing nothing. It can be embedded by the hooks that only
+// need some of the methods.
+type NopHook struct{}
+
+func (NopHook) Enter(ast.Node, *object.Environment)                                              {}
+func (NopHook) Exit(ast.Node, object.Object)                                                     {}
+func (NopHook) Statement(ast.Statement, *object.Environment)                                     {}
+func (NopHook) Call(*ast.CallExpression, *object.Function, []object.Object, *object.Environment) {}
+func (NopHook) Return(*ast.CallExpression, *object.Function, object.Object)                      {}
+func (NopHook) ModuleCall(*ast.CallExpression, *object.Import, []object.Object, object.Object)   {}
+func (NopHook) Error(ast.Node, *object.Error)                                                    {}
+func (NopHook) Branch(ast.Node, int)                                                             {}
+
+// AddHook adds a hook called during the evaluation
+func (e *Evaluator) AddHook(h Hook) {
+	e.hooks = append(e.hooks, h)
+}
+
+// evalStatement evaluates the statement of a program or block, calling the hooks first
+func (e *Evaluator) evalStatement(stmt ast.Statement, env *object.Environment) object.Object {
+	if _, isComment := stmt.(*ast.CommentStmt); !isComment {
+		for _, h := range e.hooks {
+			h.Statement(stmt, env)
+		}
+	}
+	return e.Eval(stmt, env)
+}
+
+// branch calls the hooks for the branch taken of the node
+func (e *Evaluator) branch(node ast.Node, branch int) {
+	for _, h := range e.hooks {
+		h.Branch(node, branch)
+	}
+}
+
+// evalHooked evaluates the node between the calls of the Enter and Exit hooks,
+// and calls the Error hooks for the errors it creates
+func (e *Evaluator) evalHooked(node ast.Node, env *object.Environment) object.Object {
+	for _, h := range e.hooks {
+		h.Enter(node, env)
+	}
+	result := e.eval(node, env)
+	if err, ok := result.(*object.Error); ok && err != e.reported {
+		e.reported = err
+		for _, h := range e.hooks {
+			h.Error(node, err)
+		}
+	}
+	for _, h := range e.hooks {
+		h.Exit(node, result)
+	}
+	return result
+}
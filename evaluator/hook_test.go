@@ -0,0 +1,185 @@
+package evaluator
+
+import (
+	"ede/ast"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingHook records the events of the evaluation
+type recordingHook struct {
+	NopHook
+	events []string
+}
+
+func (h *recordingHook) Statement(stmt ast.Statement, env *object.Environment) {
+	h.events = append(h.events, fmt.Sprintf("stmt %d", stmt.Pos().Line))
+}
+
+func (h *recordingHook) Call(call *ast.CallExpression, fn *object.Function, args []object.Object, env *object.Environment) {
+	h.events = append(h.events, fmt.Sprintf("call %s(%s)", call.Function.Literal(), strings.Join(env.Names(), ", ")))
+}
+
+func (h *recordingHook) Return(call *ast.CallExpression, fn *object.Function, result object.Object) {
+	h.events = append(h.events, fmt.Sprintf("return %s", result.Inspect()))
+}
+
+func (h *recordingHook) Branch(node ast.Node, branch int) {
+	h.events = append(h.events, fmt.Sprintf("branch %d:%d", node.Pos().Line, branch))
+}
+
+func TestHooks(t *testing.T) {
+	input := `let double = func(n) {
+    return n * 2
+}
+// comments are not statements
+for i = range [1..2] {
+    println(double(i))
+}
+if (false) {
+    println(1)
+} else {
+    println(0)
+}
+`
+	hook := &recordingHook{}
+	ev := &Evaluator{Stdout: new(strings.Builder)}
+	ev.AddHook(hook)
+	ev.Eval(parser.New(lexer.New(input)).Parse(), object.NewEnvironment(nil))
+
+	expected := []string{
+		"stmt 1",
+		"stmt 5",
+		"branch 5:0", "stmt 6", "call double(n)", "stmt 2", "return 2",
+		"branch 5:0", "stmt 6", "call double(n)", "stmt 2", "return 4",
+		"stmt 8", "branch 8:1",
+		"stmt 11", // the single statement of the else branch
+	}
+	if got := strings.Join(hook.events, "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("wrong events.\nexpected=%q\ngot=     %q", expected, hook.events)
+	}
+}
+
+func TestHooks_Branches(t *testing.T) {
+	input := `let a = 2
+if (a == 1) {
+    println(1)
+} else if (a == 3) {
+    println(3)
+}
+let b = match (a) {
+    case 1: "one"
+    case 2: "two"
+}
+let c = match (a) {
+    case 1: "one"
+    default: "other"
+}
+let empty = []
+for i = range empty {
+    println(i)
+}
+`
+	hook := &recordingHook{}
+	ev := &Evaluator{Stdout: new(strings.Builder)}
+	ev.AddHook(hook)
+	ev.Eval(parser.New(lexer.New(input)).Parse(), object.NewEnvironment(nil))
+
+	var branches []string
+	for _, event := range hook.events {
+		if strings.HasPrefix(event, "branch") {
+			branches = append(branches, event)
+		}
+	}
+	expected := []string{"branch 2:2", "branch 7:1", "branch 11:1", "branch 16:1"}
+	if got := strings.Join(branches, "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("wrong branches.\nexpected=%q\ngot=     %q", expected, branches)
+	}
+}
+
+// nodeHook records the nodes entered and exited, the module calls and the errors
+type nodeHook struct {
+	NopHook
+	depth, maxDepth int
+	modules, errors []string
+}
+
+func (h *nodeHook) Enter(node ast.Node, env *object.Environment) {
+	h.depth++
+	if h.depth > h.maxDepth {
+		h.maxDepth = h.depth
+	}
+}
+
+func (h *nodeHook) Exit(node ast.Node, result object.Object) {
+	h.depth--
+}
+
+func (h *nodeHook) ModuleCall(call *ast.CallExpression, imp *object.Import, args []object.Object, result object.Object) {
+	h.modules = append(h.modules, fmt.Sprintf("%s.%s(%d) = %s", imp.Name, call.Function.Literal(), len(args), result.Inspect()))
+}
+
+func (h *nodeHook) Error(node ast.Node, err *object.Error) {
+	h.errors = append(h.errors, fmt.Sprintf("%d: %s", node.Pos().Line, err.Message))
+}
+
+func TestHooks_Nodes(t *testing.T) {
+	input := `import json
+let encode = func(h) {
+    return json.string(h)
+}
+let a = encode({"a": 1})
+let b = 1 + a
+`
+	hook := &nodeHook{}
+	ev := &Evaluator{Stdout: new(strings.Builder)}
+	ev.AddHook(hook)
+	ev.Eval(parser.New(lexer.New(input)).Parse(), object.NewEnvironment(nil))
+
+	if hook.depth != 0 {
+		t.Errorf("expected every node entered to be exited, got depth %d", hook.depth)
+	}
+	if hook.maxDepth < 5 {
+		t.Errorf("expected the nested nodes to be entered, got depth %d", hook.maxDepth)
+	}
+	expected := []string{`json.string(1) = {"a":1}`}
+	if got := strings.Join(hook.modules, "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("wrong module calls.\nexpected=%q\ngot=     %q", expected, hook.modules)
+	}
+	// the error is reported once, by the innermost node, not by the statement and program
+	if len(hook.errors) != 1 || !strings.HasPrefix(hook.errors[0], "6: ") {
+		t.Errorf("expected one error on line 6, got %q", hook.errors)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	input := `let double = func(n) {
+    return n * 2
+}
+if (double(2) > 3) {
+    1 + true
+}
+`
+	var out strings.Builder
+	ev := &Evaluator{Stdout: new(strings.Builder)}
+	ev.AddHook(NewTracer(&out))
+	ev.Eval(parser.New(lexer.New(input)).Parse(), object.NewEnvironment(nil))
+
+	expected := []string{
+		`{"event":"statement","line":1,"column":1,"depth":0,"node":"LetStmt"}`,
+		`{"event":"statement","line":4,"column":1,"depth":0,"node":"IfStmt"}`,
+		`{"event":"call","line":4,"column":5,"depth":0,"name":"double","args":["2"]}`,
+		`{"event":"statement","line":2,"column":5,"depth":1,"node":"ReturnExpression"}`,
+		`{"event":"return","line":4,"column":5,"depth":0,"name":"double","result":"4"}`,
+		`{"event":"branch","line":4,"column":1,"depth":0,"node":"IfStmt","branch":0}`,
+		`{"event":"statement","line":5,"column":5,"depth":0,"node":"ExpressionStmt"}`,
+		`{"event":"error","line":5,"column":7,"depth":0,"node":"InfixExpression","error":"error: invalid infix operator + for (1) and (true)"}`,
+	}
+	if got := strings.TrimSpace(out.String()); got != strings.Join(expected, "\n") {
+		t.Errorf("wrong trace.\nexpected=%s\ngot=     %s", strings.Join(expected, "\n"), got)
+	}
+}
@@ -0,0 +1,178 @@
+package evaluator
+
+import (
+	"ede/ast"
+	"ede/object"
+	"ede/token"
+	"fmt"
+	"strconv"
+)
+
+const (
+	quoteName   = "quote"
+	unquoteName = "unquote"
+)
+
+// Expand expands the macros of the program: the macros defined at its top level
+// are removed, and their calls replaced by the code they return
+func Expand(prog *ast.Program) *object.Error {
+	env := object.NewEnvironment(nil)
+	DefineMacros(prog, env)
+	return New().ExpandMacros(prog, env)
+}
+
+// DefineMacros removes the macros defined at the top level of the program, with
+// let name = macro(params) { ... }, and binds them in the environment
+func DefineMacros(prog *ast.Program, env *object.Environment) {
+	stmts := prog.Statements[:0]
+	for _, stmt := range prog.Statements {
+		if let, ok := stmt.(*ast.LetStmt); ok {
+			if lit, ok := let.Expr.(*ast.MacroLiteral); ok {
+				env.Set(let.Name.Value, &object.Macro{Params: lit.Params, Body: lit.Body, Env: env})
+				continue
+			}
+		}
+		stmts = append(stmts, stmt)
+	}
+	prog.Statements = stmts
+}
+
+// ExpandMacros replaces the calls of the macros of the environment by the code
+// their bodies quote. The arguments of a call are passed to the macro unevaluated,
+// as quotes. It returns the first error of a macro.
+func (e *Evaluator) ExpandMacros(prog *ast.Program, env *object.Environment) *object.Error {
+	var expandErr *object.Error
+	ast.Apply(prog, nil, func(c *ast.Cursor) bool {
+		call, ok := c.Node().(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+		macro, ok := e.macro(call, env)
+		if !ok {
+			return true
+		}
+		if len(call.Args) != len(macro.Params) {
+			expandErr = e.EvalError(fmt.Sprintf("macro %s expects %d arguments, got %d", call.Function.Literal(), len(macro.Params), len(call.Args)), call.Function.Pos())
+			return false
+		}
+
+		macroEnv := object.NewEnvironment(macro.Env)
+		for i, param := range macro.Params {
+			macroEnv.Set(param.Value, &object.Quote{Node: call.Args[i]})
+		}
+		switch result := unwrapReturnValue(e.Eval(macro.Body, macroEnv)).(type) {
+		case *object.Quote:
+			c.Replace(result.Node)
+			return true
+		case *object.Error:
+			expandErr = result
+		default:
+			expandErr = e.EvalError(fmt.Sprintf("macro %s must return a quote, got %s", call.Function.Literal(), typeName(result)), call.Function.Pos())
+		}
+		return false
+	})
+	return expandErr
+}
+
+// macro returns the macro called, if any
+func (e *Evaluator) macro(call *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+// isCallOf reports whether the node is a call of the function with the name
+func isCallOf(node ast.Node, name string) (*ast.CallExpression, bool) {
+	call, ok := node.(*ast.CallExpression)
+	if !ok {
+		return nil, false
+	}
+	ident, ok := call.Function.(*ast.Identifier)
+	return call, ok && ident.Value == name
+}
+
+// isFormOf reports whether the node is a call of the quote or unquote form of the
+// name, i.e. one that the program does not bind to a value of its own
+func isFormOf(node ast.Node, name string, env *object.Environment) (*ast.CallExpression, bool) {
+	call, ok := isCallOf(node, name)
+	if !ok {
+		return nil, false
+	}
+	_, bound := env.Get(name)
+	return call, !bound
+}
+
+// quote returns the argument of quote(...) unevaluated, with its unquote(...)
+// calls replaced by their evaluated arguments
+func (e *Evaluator) quote(call *ast.CallExpression, env *object.Environment) object.Object {
+	if len(call.Args) != 1 {
+		return withPos(object.CountArgumentError("1", len(call.Args)), call)
+	}
+	var quoteErr object.Object
+	node := ast.Apply(ast.Copy(call.Args[0]), nil, func(c *ast.Cursor) bool {
+		unquote, ok := isFormOf(c.Node(), unquoteName, env)
+		if !ok {
+			return true
+		}
+		if len(unquote.Args) != 1 {
+			quoteErr = withPos(object.CountArgumentError("1", len(unquote.Args)), unquote)
+			return false
+		}
+		obj := e.Eval(unquote.Args[0], env)
+		if e.isError(obj) {
+			quoteErr = obj
+			return false
+		}
+		expr, ok := objectToExpr(obj, unquote.Pos())
+		if !ok {
+			quoteErr = e.EvalError(fmt.Sprintf("cannot unquote a value of type %s", typeName(obj)), unquote.Function.Pos())
+			return false
+		}
+		c.Replace(expr)
+		return true
+	})
+	if quoteErr != nil {
+		return quoteErr
+	}
+	return &object.Quote{Node: node}
+}
+
+// objectToExpr returns the expression of the value of an unquote. A quote is
+// unquoted to its code.
+func objectToExpr(obj object.Object, pos token.Pos) (ast.Expression, bool) {
+	switch obj := obj.(type) {
+	case *object.Quote:
+		expr, ok := ast.Copy(obj.Node).(ast.Expression)
+		return expr, ok
+	case *object.Int:
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: obj.Inspect(), Pos: pos}, Value: obj.Value}, true
+	case *object.Float:
+		lit := strconv.FormatFloat(obj.Value, 'f', -1, 64)
+		return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: lit, Pos: pos}, Value: obj.Value}, true
+	case *object.String:
+		return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: obj.Value, Pos: pos}, Value: obj.Value}, true
+	case *object.Boolean:
+		typ := token.TokenType(token.FALSE)
+		if obj.Value {
+			typ = token.TRUE
+		}
+		return &ast.BooleanLiteral{Token: token.Token{Type: typ, Literal: obj.Inspect(), Pos: pos}, Value: obj.Value}, true
+	case *object.Nil:
+		return &ast.NilLiteral{Token: token.Token{Type: token.NIL, Literal: "nil", Pos: pos}, Value: "nil"}, true
+	}
+	return nil, false
+}
+
+func typeName(obj object.Object) string {
+	if obj == nil {
+		return "nothing"
+	}
+	return string(obj.Type())
+}
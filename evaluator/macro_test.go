@@ -0,0 +1,241 @@
+package evaluator
+
+import (
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `5 + 8`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `foobar + barfoo`},
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `8 + 8`},
+		{`quote(unquote(4 + 4) + 8)`, `8 + 8`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote("a" + "b"))`, `"ab"`},
+		{`quote(unquote(1.5))`, `1.5`},
+		{`quote(unquote(nil))`, `nil`},
+		{`quote(unquote(quote(4 + 4)))`, `4 + 4`},
+		{`let quoted = quote(4 + 4); quote(unquote(4 + 4) + unquote(quoted))`, `8 + (4 + 4)`},
+		// a function of the program named unquote is quoted as any other call
+		{`let unquote = func(x) { x + 1 }; quote(unquote(4))`, `unquote(4)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			quote, ok := testEval(tt.input).(*object.Quote)
+			if !ok {
+				t.Fatalf("expected *object.Quote, got %T (%+v)", testEval(tt.input), testEval(tt.input))
+			}
+			if got := quote.Node.String(); got != tt.expected {
+				t.Errorf("wrong quote. expected=%q, got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQuote_Error(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(1, 2)`, "expected 1 argument(s), got 2"},
+		{`quote(unquote([1]))`, "cannot unquote a value of type ARRAY"},
+		{`quote(unquote(1 + true))`, "invalid infix operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err, ok := testEval(tt.input).(*object.Error)
+			if !ok {
+				t.Fatalf("expected *object.Error, got %T", testEval(tt.input))
+			}
+			if !strings.Contains(err.Message, tt.expected) {
+				t.Errorf("expected the error to contain %q, got %q", tt.expected, err.Message)
+			}
+		})
+	}
+}
+
+func TestQuote_Shadowed(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let quote = func(x) { x * 2 }\nquote(4)", "8"},
+		{"let f = func(quote) { quote(1) }\nf(func(x) { x + 1 })", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			if evaluated == nil {
+				t.Fatalf("expected %s, got nothing", tt.expected)
+			}
+			if got := evaluated.Inspect(); got != tt.expected {
+				t.Errorf("wrong result. expected=%q, got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+let number = 1
+let function = func(x, y) { x + y }
+let mymacro = macro(x, y) { x + y; }
+`
+	env := object.NewEnvironment(nil)
+	prog := parser.New(lexer.New(input)).Parse()
+	DefineMacros(prog, env)
+
+	if len(prog.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(prog.Statements))
+	}
+	if _, ok := env.Get("number"); ok {
+		t.Errorf("number should not be defined")
+	}
+	if _, ok := env.Get("function"); ok {
+		t.Errorf("function should not be defined")
+	}
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment")
+	}
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+	if len(macro.Params) != 2 || macro.Params[0].Value != "x" || macro.Params[1].Value != "y" {
+		t.Errorf("wrong macro parameters. got=%v", macro.Inspect())
+	}
+	if expected := "x + y"; strings.TrimSpace(macro.Body.Statements[0].String()) != expected {
+		t.Errorf("body is not %q. got=%q", expected, macro.Body.Statements[0].String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infix = macro() { quote(1 + 2) }
+infix()`,
+			`1 + 2`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)) }
+reverse(2 + 2, 10 - 5)`,
+			`10 - 5 - (2 + 2)`,
+		},
+		{
+			`let unless = macro(cond, cons, alt) {
+    quote(match (unquote(cond)) {
+        case false: unquote(cons)
+        default: unquote(alt)
+    })
+}
+unless(10 > 5, puts("not greater"), puts("greater"))`,
+			`match (10 > 5) {
+    case false: puts("not greater")
+    default: puts("greater")
+}`,
+		},
+		{
+			// each call is expanded from the macro, not from the previous expansion
+			`let twice = macro(x) { quote(unquote(x) * 2) }
+let a = twice(1)
+let b = twice(c)`,
+			"let a = 1 * 2\nlet b = c * 2",
+		},
+		{
+			// the macros in the arguments are expanded first
+			`let twice = macro(x) { quote(unquote(x) * 2) }
+twice(twice(1))`,
+			`1 * 2 * 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			prog := parser.New(lexer.New(tt.input)).Parse()
+			if prog.ParseErrors != nil {
+				t.Fatalf("failed to parse: %s", prog.ParseErrors)
+			}
+			if err := Expand(prog); err != nil {
+				t.Fatalf("failed to expand: %s", err.Message)
+			}
+			if got := strings.TrimSpace(prog.String()); got != tt.expected {
+				t.Errorf("wrong expansion.\nexpected=%q\ngot=     %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandMacros_Error(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let m = macro(x) { quote(x) }\nm()", "macro m expects 1 arguments, got 0"},
+		{"let m = macro(x) { 1 }\nm(2)", "macro m must return a quote, got INT"},
+		{"let m = macro(x) { 1 + true }\nm(2)", "invalid infix operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			prog := parser.New(lexer.New(tt.input)).Parse()
+			err := Expand(prog)
+			if err == nil || !strings.Contains(err.Message, tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestEval_Macros(t *testing.T) {
+	input := `let unless = macro(cond, cons, alt) {
+    quote(match (unquote(cond)) {
+        case false: unquote(cons)
+        default: unquote(alt)
+    })
+}
+let square = macro(x) { quote(unquote(x) * unquote(x)) }
+let calls = 0
+let next = func() {
+    calls++
+    return calls
+}
+let results = [unless(1 > 2, "less", "greater"), square(next()), calls]
+`
+	prog := parser.New(lexer.New(input)).Parse()
+	if err := Expand(prog); err != nil {
+		t.Fatalf("failed to expand: %s", err.Message)
+	}
+	env := object.NewEnvironment(nil)
+	if result := New().Eval(prog, env); result != nil && result.Type() == object.ERROR_OBJ {
+		t.Fatalf("failed to evaluate: %s", result.Inspect())
+	}
+	results, _ := env.Get("results")
+	// the argument of square is evaluated twice, as it is copied twice
+	testObject(t, results, []any{"less", int64(2), int64(2)})
+
+	// a macro not at the top level is not expanded
+	if _, ok := testEval("if (true) { let m = macro() { quote(1) } }").(*object.Error); !ok {
+		t.Errorf("expected an error for a nested macro")
+	}
+}
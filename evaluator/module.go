@@ -5,13 +5,28 @@ import (
 	"ede/object"
 )
 
-var modules = map[string]object.Module{}
-
-func InitModules(eval object.Evaluator, env *object.Environment) {
-	modules["json"] = &module.JSONModule{}
-	modules["time"] = &module.TimeModule{}
+// InitModules registers the modules that can be imported by the program
+func InitModules(e *Evaluator, env *object.Environment) {
+	e.modules = map[string]object.Module{
+		"json":    &module.JSONModule{},
+		"csv":     &module.CSVModule{},
+		"fs":      &module.FSModule{},
+		"time":    &module.TimeModule{},
+		"math":    &module.MathModule{},
+		"random":  &module.RandomModule{},
+		"os":      &module.OSModule{Args: e.Args},
+		"path":    &module.PathModule{File: e.File},
+		"env":     &module.EnvModule{},
+		"exec":    &module.ExecModule{},
+		"testing": &module.TestingModule{},
+	}
 
-	for _, mod := range modules {
-		mod.Init(eval, env)
+	for _, mod := range e.modules {
+		mod.Init(e, env)
 	}
 }
+
+// Modules returns the modules registered by InitModules, by name
+func (e *Evaluator) Modules() map[string]object.Module {
+	return e.modules
+}
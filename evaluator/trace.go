@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"ede/ast"
+	"ede/object"
+	"ede/token"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tracer is a hook writing the events of the evaluation as JSON lines: the
+// statements, the calls and returns of functions, the calls of module functions,
+// the branches taken and the errors
+type Tracer struct {
+	NopHook
+	// Nodes adds the events of entering and exiting every node, which are many
+	Nodes bool
+
+	enc   *json.Encoder
+	depth int // the depth of the call stack
+	err   error
+}
+
+// TraceEvent is a line of a trace
+type TraceEvent struct {
+	Event  string   `json:"event"` // enter, exit, statement, call, return, module, branch or error
+	Line   int      `json:"line"`
+	Column int      `json:"column"`
+	Depth  int      `json:"depth"`
+	Node   string   `json:"node,omitempty"` // the type of the node, e.g. LetStmt
+	Name   string   `json:"name,omitempty"` // the function called
+	Args   []string `json:"args,omitempty"`
+	Result string   `json:"result,omitempty"`
+	Branch *int     `json:"branch,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// NewTracer returns a tracer writing to the writer
+func NewTracer(w io.Writer) *Tracer {
+	return &Tracer{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the trace
+func (t *Tracer) Err() error {
+	return t.err
+}
+
+func (t *Tracer) write(ev TraceEvent, pos token.Pos) {
+	if t.err != nil {
+		return
+	}
+	ev.Line, ev.Column, ev.Depth = pos.Line, pos.Column, t.depth
+	t.err = t.enc.Encode(ev)
+}
+
+func (t *Tracer) Enter(node ast.Node, env *object.Environment) {
+	if t.Nodes {
+		t.write(TraceEvent{Event: "enter", Node: nodeType(node)}, node.Pos())
+	}
+}
+
+func (t *Tracer) Exit(node ast.Node, result object.Object) {
+	if t.Nodes {
+		t.write(TraceEvent{Event: "exit", Node: nodeType(node), Result: inspect(result)}, node.Pos())
+	}
+}
+
+func (t *Tracer) Statement(stmt ast.Statement, env *object.Environment) {
+	t.write(TraceEvent{Event: "statement", Node: nodeType(stmt)}, stmt.Pos())
+}
+
+func (t *Tracer) Call(call *ast.CallExpression, fn *object.Function, args []object.Object, env *object.Environment) {
+	name, pos := callee(call, fn)
+	t.write(TraceEvent{Event: "call", Name: name, Args: inspectAll(args)}, pos)
+	t.depth++
+}
+
+func (t *Tracer) Return(call *ast.CallExpression, fn *object.Function, result object.Object) {
+	t.depth--
+	name, pos := callee(call, fn)
+	t.write(TraceEvent{Event: "return", Name: name, Result: inspect(result)}, pos)
+}
+
+func (t *Tracer) ModuleCall(call *ast.CallExpression, imp *object.Import, args []object.Object, result object.Object) {
+	name := imp.Name + "." + call.Function.Literal()
+	t.write(TraceEvent{Event: "module", Name: name, Args: inspectAll(args), Result: inspect(result)}, call.Function.Pos())
+}
+
+func (t *Tracer) Branch(node ast.Node, branch int) {
+	t.write(TraceEvent{Event: "branch", Node: nodeType(node), Branch: &branch}, node.Pos())
+}
+
+func (t *Tracer) Error(node ast.Node, err *object.Error) {
+	pos := err.Pos
+	if pos == (token.Pos{}) {
+		pos = node.Pos()
+	}
+	t.write(TraceEvent{Event: "error", Node: nodeType(node), Error: strings.TrimSpace(err.Message)}, pos)
+}
+
+// callee returns the name of the function called, and the position of the call
+func callee(call *ast.CallExpression, fn *object.Function) (string, token.Pos) {
+	if call == nil {
+		return "func", fn.Body.Pos()
+	}
+	if ident, ok := call.Function.(*ast.Identifier); ok {
+		return ident.Value, call.Function.Pos()
+	}
+	return "func", call.Function.Pos()
+}
+
+func nodeType(node ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return obj.Inspect()
+}
+
+func inspectAll(objs []object.Object) []string {
+	values := make([]string, len(objs))
+	for i, obj := range objs {
+		values[i] = inspect(obj)
+	}
+	return values
+}
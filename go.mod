@@ -7,4 +7,6 @@ require (
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/samber/lo v1.37.0
 	golang.org/x/exp v0.0.0-20221217163422-3c43f8badb15
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/term v0.10.0
 )
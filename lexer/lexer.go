@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"bytes"
 	"ede/token"
 	"unicode"
 )
@@ -21,10 +22,24 @@ type Lexer struct {
 
 func New(input string) *Lexer {
 	l := &Lexer{input: []byte(input), line: 1}
+	l.skipShebang()
 	l.readChar()
 	return l
 }
 
+// skipShebang skips an interpreter line (e.g. #!/usr/bin/env ede) at the start of
+// the input. The line ending is kept, so that positions are not shifted
+func (l *Lexer) skipShebang() {
+	if !bytes.HasPrefix(l.input, []byte("#!")) {
+		return
+	}
+	if end := bytes.IndexByte(l.input, '\n'); end >= 0 {
+		l.readPos = end
+	} else {
+		l.readPos = len(l.input)
+	}
+}
+
 func (l *Lexer) readChar() {
 	if l.readPos >= len(l.input) {
 		l.char = byte(0)
@@ -59,8 +74,8 @@ func (l *Lexer) readNChars(n int) {
 
 func (l *Lexer) readIdent() []byte {
 	start := l.currPos
-	for l.isIdentifier(l.peekChar()) {
-		// if l.char == 34
+	// the identifiers can contain digits after their first letter, e.g. log2
+	for l.isIdentifier(l.peekChar()) || unicode.IsDigit(rune(l.peekChar())) {
 		l.readChar()
 	}
 	return l.input[start:l.readPos]
@@ -103,11 +118,15 @@ func (l *Lexer) readReturn() []byte {
 func (l *Lexer) readSingleComment() []byte {
 	l.readNChars(2) // read '//'
 	start := l.currPos
-	for !(l.peekCharIs(';') || l.peekCharIs('\n')) {
+	// read up to the closing char (\n or ;), or the end of the input
+	for !(l.currCharIs(';') || l.currCharIs('\n') || l.currCharIs(0)) {
 		l.readChar()
 	}
-	l.readChar() // read closing char (\n or ;)
-	return l.input[start:l.readPos]
+	end := l.currPos
+	if !l.currCharIs(0) {
+		end++ // the closing char is part of the comment
+	}
+	return l.input[start:end]
 }
 
 func (l *Lexer) readStruct() []byte {
@@ -368,3 +368,115 @@ func TestNextTokenOperators(t *testing.T) {
 // 		}
 // 	}
 // }
+
+func TestNextTokenShebang(t *testing.T) {
+	input := "#!/usr/bin/env ede\nlet a = 1"
+
+	tests := []struct {
+		expType    token.TokenType
+		expLiteral string
+		expLine    int
+	}{
+		{token.NEWLINE, "\n", 1},
+		{token.LET, "let", 2},
+		{token.IDENT, "a", 2},
+		{token.ASSIGN, "=", 2},
+		{token.INT, "1", 2},
+		{token.EOF, "", 2},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expType, tok.Type)
+		}
+		if tok.Literal != tt.expLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expLiteral, tok.Literal)
+		}
+		if tok.Line != tt.expLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d",
+				i, tt.expLine, tok.Line)
+		}
+	}
+
+	if tok := New("#!/usr/bin/env ede").NextToken(); tok.Type != token.EOF {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.EOF, tok.Type)
+	}
+}
+
+func TestNextTokenComments(t *testing.T) {
+	input := "let a = 1 // one\n//\nlet b; // end"
+
+	tests := []struct {
+		expType    token.TokenType
+		expLiteral string
+		expLine    int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "a", 1},
+		{token.ASSIGN, "=", 1},
+		{token.INT, "1", 1},
+		{token.SINGLE_COMMENT, " one\n", 1},
+		{token.SINGLE_COMMENT, "\n", 2},
+		{token.LET, "let", 3},
+		{token.IDENT, "b", 3},
+		{token.SEMICOLON, ";", 3},
+		{token.SINGLE_COMMENT, " end", 3},
+		{token.EOF, "", 3},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expType, tok.Type)
+		}
+		if tok.Literal != tt.expLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expLiteral, tok.Literal)
+		}
+		if tok.Line != tt.expLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d",
+				i, tt.expLine, tok.Line)
+		}
+	}
+}
+
+func TestNextTokenIdentDigits(t *testing.T) {
+	input := "math.log2(x1) 2a"
+
+	tests := []struct {
+		expType    token.TokenType
+		expLiteral string
+	}{
+		{token.IDENT, "math"},
+		{token.DOT, "."},
+		{token.IDENT, "log2"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x1"},
+		{token.RPAREN, ")"},
+		{token.INT, "2"},
+		{token.IDENT, "a"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expType, tok.Type)
+		}
+		if tok.Literal != tt.expLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"ede/ast"
+	"ede/object"
+	"ede/scope"
+	"strings"
+)
+
+func (l *linter) check(prog *ast.Program) {
+	ast.Inspect(prog, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			l.statements(node.Statements)
+		case *ast.BlockStmt:
+			l.statements(node.Statements)
+		}
+		return true
+	})
+	l.scope(l.info.Scope)
+	l.assignments()
+	l.moduleCalls()
+	l.calls()
+}
+
+// statements records the ede:ignore comments of the statements, and reports the
+// first statement following a return
+func (l *linter) statements(stmts []ast.Statement) {
+	returned := false
+	for i, stmt := range stmts {
+		if c, ok := stmt.(*ast.CommentStmt); ok {
+			l.comment(c, i > 0 && stmts[i-1].Pos().Line == c.Pos().Line)
+			continue
+		}
+		if returned {
+			// only the first statement is reported
+			l.report(stmt.Pos(), RuleUnreachable, "unreachable code after return")
+			for _, rest := range stmts[i+1:] {
+				if c, ok := rest.(*ast.CommentStmt); ok {
+					l.comment(c, false)
+				}
+			}
+			return
+		}
+		if isReturn(stmt) {
+			returned = true
+		}
+	}
+}
+
+func isReturn(stmt ast.Statement) bool {
+	if expr, ok := stmt.(*ast.ExpressionStmt); ok {
+		_, ok := expr.Expr.(*ast.ReturnExpression)
+		return ok
+	}
+	_, ok := stmt.(*ast.ReturnExpression)
+	return ok
+}
+
+// scope checks the declarations of the scope and of the scopes it contains
+func (l *linter) scope(s *scope.Scope) {
+	for _, obj := range s.Objects() {
+		l.unused(obj)
+		l.shadow(obj)
+		if _, ok := l.modules[obj.Name]; obj.Kind == scope.Import && !ok {
+			l.report(obj.Pos, RuleUnknownModule, "unknown module %s", obj.Name)
+		}
+	}
+	for _, child := range s.Children {
+		l.scope(child)
+	}
+}
+
+// unused reports the let bindings and imports without references. Names starting
+// with _, and the tests of the program, are not reported.
+func (l *linter) unused(obj *scope.Object) {
+	switch {
+	case obj.Kind != scope.Var && obj.Kind != scope.Func && obj.Kind != scope.Import,
+		strings.HasPrefix(obj.Name, "_"),
+		obj.Scope == l.info.Scope && obj.Kind == scope.Func && strings.HasPrefix(obj.Name, "test_"),
+		len(l.info.References(obj)) > 0:
+		return
+	}
+	if obj.Kind == scope.Import {
+		if _, ok := l.modules[obj.Name]; !ok {
+			return // reported as an unknown module
+		}
+		l.report(obj.Pos, RuleUnused, "module %s is imported but not used", obj.Name)
+		return
+	}
+	l.report(obj.Pos, RuleUnused, "%s %s is declared but not used", obj.Kind, obj.Name)
+}
+
+// shadow reports the declarations hiding a name declared before them in an
+// enclosing scope
+func (l *linter) shadow(obj *scope.Object) {
+	if obj.Kind == scope.Implicit || obj.Kind == scope.Import || obj.Scope.Parent == nil {
+		return
+	}
+	outer := obj.Scope.Parent.Lookup(obj.Name)
+	if outer == nil || outer.Kind == scope.Implicit || !scope.Before(outer.Pos, obj.Pos) {
+		return
+	}
+	l.report(obj.Pos, RuleShadow, "%s %s shadows the %s declared at line %d", obj.Kind, obj.Name, outer.Kind, outer.Pos.Line)
+}
+
+// assignments reports the reassignments of names that are not declared, which
+// fail when the program runs
+func (l *linter) assignments() {
+	for _, ident := range l.info.Assigned {
+		if _, ok := l.info.Uses[ident]; !ok {
+			l.report(ident.Pos(), RuleUndeclared, "assignment to undeclared name %s, declare it with let", ident.Value)
+		}
+	}
+}
+
+// moduleCalls reports the calls of functions that the modules do not have
+func (l *linter) moduleCalls() {
+	for method, expr := range l.info.Methods {
+		ident, ok := expr.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		obj := l.info.Uses[ident]
+		if obj == nil || obj.Kind != scope.Import {
+			continue
+		}
+		functions, ok := l.modules[obj.Name]
+		if !ok || functions[method.Value] || isCommonMethod(method.Value) {
+			continue
+		}
+		l.report(method.Pos(), RuleUnknownFunction, "module %s has no function %s", obj.Name, method.Value)
+	}
+}
+
+func isCommonMethod(name string) bool {
+	for _, m := range object.CommonMethods {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// calls reports the calls of functions with the wrong number of arguments. Only
+// the functions bound by let, and never reassigned, are checked.
+func (l *linter) calls() {
+	reassigned := map[*scope.Object]bool{}
+	for _, ident := range l.info.Assigned {
+		if obj := l.info.Uses[ident]; obj != nil {
+			reassigned[obj] = true
+		}
+	}
+
+	for _, call := range l.info.Calls {
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		obj := l.info.Uses[ident]
+		if obj == nil || obj.Kind != scope.Func || reassigned[obj] {
+			continue
+		}
+		lit := obj.Value.(*ast.FunctionLiteral)
+		if len(call.Args) != len(lit.Params) {
+			l.report(ident.Pos(), RuleArity, "%s expects %d argument(s), got %d", ident.Value, len(lit.Params), len(call.Args))
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package lint reports the likely mistakes of a program that would otherwise only
+// show up when it runs, e.g. a misspelled module function.
+//
+// A diagnostic is silenced by a comment on its line, or on the line before, naming
+// its rule:
+//
+//	let unused = 1 // ede:ignore unused
+//
+// A comment without rules, "// ede:ignore", silences every rule.
+package lint
+
+import (
+	"ede/ast"
+	"ede/evaluator"
+	"ede/object"
+	"ede/scope"
+	"ede/token"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The rules of the diagnostics
+const (
+	RuleUndeclared      = "undeclared"
+	RuleUnused          = "unused"
+	RuleShadow          = "shadow"
+	RuleUnreachable     = "unreachable"
+	RuleUnknownModule   = "unknown-module"
+	RuleUnknownFunction = "unknown-function"
+	RuleArity           = "arity"
+)
+
+// Rules describes the rules, by ID
+var Rules = map[string]string{
+	RuleUndeclared:      "assignment to a name that is not declared",
+	RuleUnused:          "let binding or import that is never used",
+	RuleShadow:          "declaration hiding a name of an enclosing scope",
+	RuleUnreachable:     "statement after a return",
+	RuleUnknownModule:   "import of a module that does not exist",
+	RuleUnknownFunction: "call of a function a module does not have",
+	RuleArity:           "call of a function with the wrong number of arguments",
+}
+
+// Diagnostic is a problem found in a program
+type Diagnostic struct {
+	Pos     token.Pos
+	Rule    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Pos.Line, d.Pos.Column, d.Message, d.Rule)
+}
+
+// Lint returns the diagnostics of the program, in the order of the source. The
+// program should have no parse errors.
+func Lint(prog *ast.Program) []Diagnostic {
+	l := &linter{info: scope.Resolve(prog), modules: modules()}
+	l.check(prog)
+
+	var diags []Diagnostic
+	for _, d := range l.diags {
+		if !l.ignored(d) {
+			diags = append(diags, d)
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool { return scope.Before(diags[i].Pos, diags[j].Pos) })
+	return diags
+}
+
+// modules returns the function and value names of each module, by module name
+func modules() map[string]map[string]bool {
+	e := evaluator.New()
+	evaluator.InitModules(e, object.NewEnvironment(nil))
+	mods := make(map[string]map[string]bool)
+	for name, mod := range e.Modules() {
+		mods[name] = make(map[string]bool)
+		for _, fn := range object.MemberNames(mod) {
+			mods[name][fn] = true
+		}
+	}
+	return mods
+}
+
+type linter struct {
+	info    *scope.Info
+	modules map[string]map[string]bool
+
+	diags    []Diagnostic
+	comments []commentLine
+}
+
+// commentLine is an ede:ignore comment, and the lines it applies to
+type commentLine struct {
+	rules      []string // all the rules if empty
+	start, end int
+}
+
+func (l *linter) report(pos token.Pos, rule, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{Pos: pos, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// ignored reports whether an ede:ignore comment silences the diagnostic
+func (l *linter) ignored(d Diagnostic) bool {
+	for _, c := range l.comments {
+		if d.Pos.Line < c.start || d.Pos.Line > c.end {
+			continue
+		}
+		if len(c.rules) == 0 {
+			return true
+		}
+		for _, rule := range c.rules {
+			if rule == d.Rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// comment records the comment if it is an ede:ignore comment. A comment following
+// a statement on its line applies to that line, otherwise it applies to the next one.
+func (l *linter) comment(c *ast.CommentStmt, trailing bool) {
+	text := strings.TrimSpace(c.Value)
+	if !strings.HasPrefix(text, "ede:ignore") {
+		return
+	}
+	rest := strings.TrimPrefix(text, "ede:ignore")
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return // e.g. ede:ignored
+	}
+	line := c.Pos().Line
+	cl := commentLine{rules: strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }), start: line, end: line}
+	if !trailing {
+		cl.end = line + 1
+	}
+	l.comments = append(l.comments, cl)
+}
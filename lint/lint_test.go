@@ -0,0 +1,54 @@
+package lint
+
+import (
+	"ede/lexer"
+	"ede/parser"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"clean", "import json\nlet a = json.parse(\"1\")\nprintln(a)\n", nil},
+		{"undeclared", "let a = 1\na = 2\nb = 3\n", []string{"3:1: assignment to undeclared name b, declare it with let (undeclared)"}},
+		{"unused", "let f = func() {\n    let a = 1\n    let _b = 2\n}\nf()\n", []string{"2:9: variable a is declared but not used (unused)"}},
+		{"unused import", "import json\n", []string{"1:1: module json is imported but not used (unused)"}},
+		{"unused tests are not reported", "let test_a = func() {}\n", nil},
+		{"shadow", "let a = 1\nlet f = func(a) {\n    return a\n}\nf(a)\n", []string{"2:14: parameter a shadows the variable declared at line 1 (shadow)"}},
+		{"shadow in a loop", "let x = 1\nfor i = range [1..2] {\n    let x = i\n    println(x)\n}\nprintln(x)\n", []string{"3:9: variable x shadows the variable declared at line 1 (shadow)"}},
+		{"unreachable", "let f = func() {\n    return 1\n    println(2)\n    println(3)\n}\nf()\n", []string{"3:5: unreachable code after return (unreachable)"}},
+		{"unreachable in loop", "let xs = [1]\nfor x = range xs {\n    return x\n    println(x)\n}\n", []string{"4:5: unreachable code after return (unreachable)"}},
+		{"unknown module", "import foo\n", []string{"1:1: unknown module foo (unknown-module)"}},
+		{"unknown function", "import json\njson.prase(\"{}\")\njson.type()\n", []string{"2:6: module json has no function prase (unknown-function)"}},
+		{"arity", "let add = func(a, b) {\n    return a + b\n}\nadd(1)\nadd(1, 2)\nadd(1, 2, 3)\n", []string{
+			"4:1: add expects 2 argument(s), got 1 (arity)",
+			"6:1: add expects 2 argument(s), got 3 (arity)",
+		}},
+		{"arity of a reassigned function", "let f = func(a) {\n    return a\n}\nf = func() {\n    return 1\n}\nf()\n", nil},
+		{"ignore on the line", "let f = func() {\n    let a = 1 // ede:ignore unused\n}\nf()\n", nil},
+		{"ignore on the line before", "let f = func() {\n    // ede:ignore shadow, unused\n    let a = 1\n}\nf()\n", nil},
+		{"ignore all rules", "// ede:ignore\nimport foo\n", nil},
+		{"ignore another rule", "let f = func() {\n    let a = 1 // ede:ignore shadow\n}\nf()\n", []string{"2:9: variable a is declared but not used (unused)"}},
+		{"ignore applies to one line", "// ede:ignore\n\nimport foo\n", []string{"3:1: unknown module foo (unknown-module)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog := parser.New(lexer.New(tt.input)).Parse()
+			if prog.ParseErrors != nil {
+				t.Fatal(prog.ParseErrors)
+			}
+			var got []string
+			for _, d := range Lint(prog) {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("wrong diagnostics.\nexpected=%q\ngot=     %q", tt.expected, got)
+			}
+		})
+	}
+}
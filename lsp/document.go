@@ -0,0 +1,100 @@
+package lsp
+
+import (
+	"ede/ast"
+	"ede/lexer"
+	"ede/parser"
+	"ede/scope"
+	"ede/token"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is an open text document and the analysis of its program
+type document struct {
+	uri   string
+	text  string
+	lines []string
+
+	errs []*parser.ParseError
+	// info is the resolution of the last version of the document that parsed.
+	// It is kept while the document has errors, so that completion works while typing.
+	info *scope.Info
+}
+
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = strings.Split(text, "\n")
+
+	prog := parser.New(lexer.New(text)).Parse()
+	d.errs = parser.ErrorList(prog.ParseErrors)
+	if prog.ParseErrors == nil {
+		d.info = scope.Resolve(prog)
+	}
+}
+
+func (d *document) diagnostics() []Diagnostic {
+	diags := make([]Diagnostic, 0, len(d.errs))
+	for _, err := range d.errs {
+		start := d.position(err.Pos())
+		end := start
+		if line := d.line(start.Line); start.Character < utf16Len(line) {
+			end.Character++
+		}
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: SeverityError,
+			Source:   "ede",
+			Message:  err.Unwrap().Error(),
+		})
+	}
+	return diags
+}
+
+func (d *document) line(n int) string {
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(d.lines[n], "\r")
+}
+
+// position converts a position in the source to a position of the protocol
+func (d *document) position(pos token.Pos) Position {
+	line := d.line(pos.Line - 1)
+	col := pos.Column - 1
+	if col > len(line) {
+		col = len(line)
+	}
+	if col < 0 {
+		col = 0
+	}
+	return Position{Line: pos.Line - 1, Character: utf16Len(line[:col])}
+}
+
+// pos converts a position of the protocol to a position in the source
+func (d *document) pos(p Position) token.Pos {
+	line := d.line(p.Line)
+	offset, units := 0, 0
+	for offset < len(line) && units < p.Character {
+		r, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return token.Pos{Line: p.Line + 1, Column: offset + 1}
+}
+
+// identRange returns the range of the identifier
+func (d *document) identRange(ident *ast.Identifier) Range {
+	start := d.position(ident.Pos())
+	end := d.position(token.Pos{Line: ident.Pos().Line, Column: ident.Pos().Column + len(ident.Value)})
+	return Range{Start: start, End: end}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
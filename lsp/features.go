@@ -0,0 +1,208 @@
+package lsp
+
+import (
+	"ede/ast"
+	"ede/object"
+	"ede/scope"
+	"ede/token"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func (s *Server) hover(doc *document, p Position) *Hover {
+	if doc.info == nil {
+		return nil
+	}
+	pos := doc.pos(p)
+	ident, obj := doc.info.ObjectAt(pos)
+
+	var text string
+	switch {
+	case obj != nil:
+		text = describe(doc.info, obj)
+	case ident != nil && s.isBuiltin(ident.Value):
+		text = "(builtin) " + ident.Value
+	default:
+		ident, text = s.describeMethod(doc.info, pos)
+	}
+	if text == "" {
+		return nil
+	}
+	rng := doc.identRange(ident)
+	return &Hover{Contents: markupContent{Kind: "markdown", Value: "```ede\n" + text + "\n```"}, Range: &rng}
+}
+
+// describe returns the description of a name shown on hover, e.g. (variable) a: int
+func describe(info *scope.Info, obj *scope.Object) string {
+	if obj.Kind == scope.Func {
+		if fn, ok := obj.Value.(*ast.FunctionLiteral); ok {
+			return fmt.Sprintf("(function) %s(%s)", obj.Name, params(fn))
+		}
+	}
+	text := fmt.Sprintf("(%s) %s", obj.Kind, obj.Name)
+	if obj.Kind == scope.Import {
+		return text
+	}
+	if typ := info.ObjectType(obj); typ != "" {
+		text += ": " + typeName(typ)
+	}
+	return text
+}
+
+// describeMethod describes the method name at the position, e.g. (method) length
+func (s *Server) describeMethod(info *scope.Info, pos token.Pos) (*ast.Identifier, string) {
+	if ident, obj := info.MethodAt(pos); ident != nil {
+		if recv, ok := obj.(*ast.Identifier); ok {
+			if imp := info.Uses[recv]; imp != nil && imp.Kind == scope.Import {
+				return ident, fmt.Sprintf("(function) %s.%s", imp.Name, ident.Value)
+			}
+		}
+		if typ := info.TypeOf(obj); typ != "" {
+			return ident, fmt.Sprintf("(method) %s.%s", typeName(typ), ident.Value)
+		}
+		return ident, "(method) " + ident.Value
+	}
+	return nil, ""
+}
+
+func params(fn *ast.FunctionLiteral) string {
+	names := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		names[i] = param.Value
+	}
+	return strings.Join(names, ", ")
+}
+
+// typeName returns the name of the type shown to the user, e.g. int
+func typeName(typ object.Type) string {
+	return strings.ToLower(string(typ))
+}
+
+func (s *Server) definition(doc *document, p Position) []Location {
+	if doc.info == nil {
+		return nil
+	}
+	_, obj := doc.info.ObjectAt(doc.pos(p))
+	if obj == nil || obj.Kind == scope.Implicit {
+		return nil
+	}
+	return []Location{s.declaration(doc, obj)}
+}
+
+func (s *Server) declaration(doc *document, obj *scope.Object) Location {
+	if obj.Ident != nil {
+		return Location{URI: doc.uri, Range: doc.identRange(obj.Ident)}
+	}
+	start := doc.position(obj.Pos)
+	return Location{URI: doc.uri, Range: Range{Start: start, End: start}}
+}
+
+func (s *Server) references(doc *document, p Position, includeDecl bool) []Location {
+	if doc.info == nil {
+		return nil
+	}
+	_, obj := doc.info.ObjectAt(doc.pos(p))
+	if obj == nil {
+		return nil
+	}
+	locs := []Location{}
+	if includeDecl && obj.Kind != scope.Implicit {
+		locs = append(locs, s.declaration(doc, obj))
+	}
+	for _, ident := range doc.info.References(obj) {
+		locs = append(locs, Location{URI: doc.uri, Range: doc.identRange(ident)})
+	}
+	return locs
+}
+
+func (s *Server) completion(doc *document, p Position) []CompletionItem {
+	line := doc.line(p.Line)
+	before := line[:doc.pos(p).Column-1]
+	prefix := before[len(strings.TrimRightFunc(before, isIdentChar)):]
+	before = strings.TrimSuffix(before, prefix)
+
+	items := []CompletionItem{}
+	add := func(label string, kind int, detail string) {
+		if strings.HasPrefix(label, prefix) {
+			items = append(items, CompletionItem{Label: label, Kind: kind, Detail: detail})
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(before, "."):
+		s.completeMember(doc, p, strings.TrimSuffix(before, "."), add)
+	case strings.TrimSpace(before) == "import":
+		for _, name := range s.moduleNames() {
+			add(name, completionModule, "module")
+		}
+	default:
+		for _, kw := range token.Keywords() {
+			add(kw, completionKeyword, "keyword")
+		}
+		for _, name := range s.builtins {
+			add(name, completionFunction, "builtin")
+		}
+		if doc.info != nil {
+			for _, obj := range doc.info.Scope.Innermost(doc.pos(p)).Visible(doc.pos(p)) {
+				kind := completionVariable
+				switch obj.Kind {
+				case scope.Func:
+					kind = completionFunction
+				case scope.Import:
+					kind = completionModule
+				}
+				add(obj.Name, kind, describe(doc.info, obj))
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// completeMember completes the member of the expression before the dot, i.e. the
+// functions of a module or the methods of a value
+func (s *Server) completeMember(doc *document, p Position, recv string, add func(string, int, string)) {
+	var typ object.Type
+	name := recv[len(strings.TrimRightFunc(recv, isIdentChar)):]
+	var obj *scope.Object
+	if doc.info != nil && name != "" {
+		obj = doc.info.Scope.Innermost(doc.pos(p)).Lookup(name)
+	}
+	switch {
+	case strings.HasSuffix(recv, `"`), strings.HasSuffix(recv, "`"):
+		typ = object.STRING_OBJ
+	case strings.HasSuffix(recv, "]"):
+		typ = object.ARRAY_OBJ
+	case obj != nil && obj.Kind != scope.Import:
+		typ = doc.info.ObjectType(obj)
+	case s.modules[name] != nil:
+		// the import may be missing from the analysis when it is the first
+		// version of the document being typed
+		for _, fn := range s.modules[name] {
+			add(fn, completionFunction, name+"."+fn)
+		}
+		return
+	}
+
+	methods := object.MethodNames[typ]
+	if methods == nil { // unknown type, offer the methods of every type
+		for _, names := range object.MethodNames {
+			methods = append(methods, names...)
+		}
+	}
+	seen := map[string]bool{}
+	for _, names := range [][]string{methods, object.CommonMethods} {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				add(name, completionMethod, "method")
+			}
+		}
+	}
+}
+
+func isIdentChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,199 @@
+// Package lsp implements a Language Server Protocol server for ede. It publishes
+// the parse errors as diagnostics, and provides hover, go to definition, find
+// references and completion.
+package lsp
+
+import (
+	"ede/evaluator"
+	"ede/object"
+	"ede/rpc"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client asks the server
+// to exit before shutting it down
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Server is a language server reading requests from a stream, and writing
+// the responses to another
+type Server struct {
+	reader *rpc.Reader
+	writer *rpc.Writer
+
+	docs     map[string]*document
+	builtins []string
+	modules  map[string][]string // the function names of each module
+	shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		reader:  rpc.NewReader(in),
+		writer:  rpc.NewWriter(out),
+		docs:    make(map[string]*document),
+		modules: make(map[string][]string),
+	}
+
+	e := evaluator.New()
+	evaluator.InitModules(e, object.NewEnvironment(nil))
+	s.builtins = e.BuiltinNames()
+	for name, mod := range e.Modules() {
+		s.modules[name] = object.MemberNames(mod)
+		sort.Strings(s.modules[name])
+	}
+	return s
+}
+
+// Serve handles the requests until the client asks the server to exit, or the
+// input stream ends
+func (s *Server) Serve() error {
+	for {
+		body, err := s.reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.replyError(nil, codeParseError, fmt.Sprintf("invalid message: %s", err))
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) error {
+	var result any
+	var err error
+	switch req.Method {
+	case "initialize":
+		result = initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				ReferencesProvider: true,
+				CompletionProvider: completionOptions{TriggerCharacters: []string{"."}},
+			},
+			ServerInfo: serverInfo{Name: "ede"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			doc := &document{uri: params.TextDocument.URI}
+			s.docs[doc.uri] = doc
+			doc.update(params.TextDocument.Text)
+			return s.publishDiagnostics(doc)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			doc, ok := s.docs[params.TextDocument.URI]
+			if !ok || len(params.ContentChanges) == 0 {
+				return nil
+			}
+			// the changes hold the whole document, see textDocumentSyncFull
+			doc.update(params.ContentChanges[len(params.ContentChanges)-1].Text)
+			return s.publishDiagnostics(doc)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			delete(s.docs, params.TextDocument.URI)
+			return s.writer.Write(notification{
+				JSONRPC: "2.0",
+				Method:  "textDocument/publishDiagnostics",
+				Params:  publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}},
+			})
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if doc, ok := s.docs[params.TextDocument.URI]; ok {
+				if hover := s.hover(doc, params.Position); hover != nil {
+					result = hover
+				}
+			}
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if doc, ok := s.docs[params.TextDocument.URI]; ok {
+				result = s.definition(doc, params.Position)
+			}
+		}
+	case "textDocument/references":
+		var params referenceParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if doc, ok := s.docs[params.TextDocument.URI]; ok {
+				result = s.references(doc, params.Position, params.Context.IncludeDeclaration)
+			}
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if doc, ok := s.docs[params.TextDocument.URI]; ok {
+				result = s.completion(doc, params.Position)
+			}
+		}
+	default:
+		if req.ID != nil { // notifications without a handler are ignored
+			return s.replyError(req.ID, codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method))
+		}
+		return nil
+	}
+
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		return s.replyError(req.ID, codeInvalidParams, err.Error())
+	}
+	return s.writer.Write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, msg string) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return s.writer.Write(errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: msg}})
+}
+
+func (s *Server) publishDiagnostics(doc *document) error {
+	return s.writer.Write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: doc.uri, Diagnostics: doc.diagnostics()},
+	})
+}
+
+func (s *Server) isBuiltin(name string) bool {
+	i := sort.SearchStrings(s.builtins, name)
+	return i < len(s.builtins) && s.builtins[i] == name
+}
+
+func (s *Server) moduleNames() []string {
+	names := make([]string, 0, len(s.modules))
+	for name := range s.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"bytes"
+	"ede/object"
+	"ede/rpc"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testURI = "file:///main.ede"
+
+const testSource = `import json
+
+let count = 10
+let greet = func(name) {
+    return "hello " + name
+}
+let words = ["a", "b"]
+for w = range words {
+    println(greet(w), count)
+}
+json.parse("{}")
+`
+
+// session runs the server on the requests, and returns the messages it wrote
+func session(t *testing.T, requests ...any) []map[string]any {
+	t.Helper()
+	in := new(bytes.Buffer)
+	w := rpc.NewWriter(in)
+	for _, req := range requests {
+		if err := w.Write(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := new(bytes.Buffer)
+	if err := NewServer(in, out).Serve(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var msgs []map[string]any
+	r := rpc.NewReader(out)
+	for {
+		body, err := r.Read()
+		if err == io.EOF {
+			return msgs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func call(id int, method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func didOpen(text string) map[string]any {
+	return notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": testURI, "languageId": "ede", "version": 1, "text": text},
+	})
+}
+
+func at(line, char int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     map[string]any{"line": line, "character": char},
+	}
+}
+
+// result returns the result of the response to the request with the id
+func result(t *testing.T, msgs []map[string]any, id int) any {
+	t.Helper()
+	for _, msg := range msgs {
+		if msgID, ok := msg["id"].(float64); ok && int(msgID) == id {
+			if msg["error"] != nil {
+				t.Fatalf("unexpected error response: %v", msg["error"])
+			}
+			return msg["result"]
+		}
+	}
+	t.Fatalf("no response to request %d", id)
+	return nil
+}
+
+func toJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	msgs := session(t,
+		call(1, "initialize", map[string]any{"capabilities": map[string]any{}}),
+		notify("initialized", map[string]any{}),
+		call(2, "unknown/method", nil),
+		call(3, "shutdown", nil),
+		notify("exit", nil),
+	)
+
+	caps := toJSON(result(t, msgs, 1))
+	for _, exp := range []string{`"hoverProvider":true`, `"definitionProvider":true`, `"referencesProvider":true`, `"triggerCharacters":["."]`} {
+		if !strings.Contains(caps, exp) {
+			t.Errorf("capabilities %s do not contain %s", caps, exp)
+		}
+	}
+	if code := msgs[1]["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("expected method not found error, got %v", code)
+	}
+
+	in := new(bytes.Buffer)
+	rpc.NewWriter(in).Write(notify("exit", nil))
+	if err := NewServer(in, io.Discard).Serve(); err != ErrExitWithoutShutdown {
+		t.Errorf("expected %v, got %v", ErrExitWithoutShutdown, err)
+	}
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	msgs := session(t,
+		didOpen("let a = 1\nlet = 2\n"),
+		notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": testURI, "version": 2},
+			"contentChanges": []any{map[string]any{"text": "let a = 1\n"}},
+		}),
+	)
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(msgs))
+	}
+
+	diags := msgs[0]["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	diag := toJSON(diags[0])
+	for _, exp := range []string{`"message":"expected token IDENT, got ="`, `"start":{"character":0,"line":1}`, `"severity":1`} {
+		if !strings.Contains(diag, exp) {
+			t.Errorf("diagnostic %s does not contain %s", diag, exp)
+		}
+	}
+
+	if diags := msgs[1]["params"].(map[string]any)["diagnostics"].([]any); len(diags) != 0 {
+		t.Errorf("expected the diagnostics to be cleared, got %v", diags)
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	tests := []struct {
+		line, char int
+		expected   string
+	}{
+		{2, 5, "(variable) count: int"},
+		{3, 5, "(function) greet(name)"},
+		{4, 25, "(parameter) name"},
+		{7, 4, "(loop variable) w"},
+		{8, 8, "(builtin) println"},
+		{10, 1, "(module) json"},
+		{10, 6, "(function) json.parse"},
+		{6, 6, "(variable) words: array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			msgs := session(t, didOpen(testSource), call(1, "textDocument/hover", at(tt.line, tt.char)))
+			hover, ok := result(t, msgs, 1).(map[string]any)
+			if !ok {
+				t.Fatalf("expected a hover, got %v", msgs)
+			}
+			value := hover["contents"].(map[string]any)["value"].(string)
+			if !strings.Contains(value, tt.expected) {
+				t.Errorf("hover %q does not contain %q", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServer_DefinitionAndReferences(t *testing.T) {
+	msgs := session(t,
+		didOpen(testSource),
+		call(1, "textDocument/definition", at(8, 14)), // greet(w)
+		call(2, "textDocument/references", map[string]any{
+			"textDocument": map[string]any{"uri": testURI},
+			"position":     map[string]any{"line": 2, "character": 4}, // let count
+			"context":      map[string]any{"includeDeclaration": true},
+		}),
+		call(3, "textDocument/definition", at(8, 18)), // w
+	)
+
+	if got, exp := toJSON(result(t, msgs, 1)), `[{"range":{"end":{"character":9,"line":3},"start":{"character":4,"line":3}},"uri":"file:///main.ede"}]`; got != exp {
+		t.Errorf("wrong definition.\nexpected=%s\ngot=     %s", exp, got)
+	}
+
+	refs := result(t, msgs, 2).([]any)
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 references, got %s", toJSON(refs))
+	}
+	for i, line := range []float64{2, 8} {
+		if got := refs[i].(map[string]any)["range"].(map[string]any)["start"].(map[string]any)["line"]; got != line {
+			t.Errorf("references[%d] - wrong line. expected=%v, got=%v", i, line, got)
+		}
+	}
+
+	if got, exp := toJSON(result(t, msgs, 3)), `"start":{"character":4,"line":7}`; !strings.Contains(got, exp) {
+		t.Errorf("definition %s does not contain %s", got, exp)
+	}
+}
+
+func TestServer_Completion(t *testing.T) {
+	labels := func(text string, line, char int) string {
+		msgs := session(t, didOpen(text), call(1, "textDocument/completion", at(line, char)))
+		var names []string
+		for _, item := range result(t, msgs, 1).([]any) {
+			names = append(names, item.(map[string]any)["label"].(string))
+		}
+		return strings.Join(names, " ")
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		line     int
+		char     int
+		expected string
+	}{
+		{"module functions", "import json\njson.\n", 1, 5, "lines parse pretty string"},
+		{"string methods", "let s = \"a\"\ns.re\n", 1, 4, "repeat replace reverse"},
+		{"array methods", "let a = [1]\na.p\n", 1, 3, "partition pop push"},
+		{"names in scope", "let count = 1\nlet f = func(cost) {\n  co\n}\nlet cow = 1\n", 2, 4, "cost count cow"},
+		{"keywords and builtins", "pri\n", 0, 3, "print printf println"},
+		{"modules", "import \n", 0, 7, "csv env exec fs json math os path random testing time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labels(tt.text, tt.line, tt.char); got != tt.expected {
+				t.Errorf("wrong completion. expected=%q, got=%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestMethodNames checks the method names offered by completion exist
+func TestMethodNames(t *testing.T) {
+	values := map[object.Type]object.Object{
+		object.STRING_OBJ: object.NewString(""),
+		object.INT_OBJ:    object.NewInt(0),
+		object.FLOAT_OBJ:  object.NewFloat(0),
+		object.ARRAY_OBJ:  object.NewArray(nil),
+		object.HASH_OBJ:   object.NewHash(nil),
+		object.SET_OBJ:    &object.Set{},
+		object.TIME_OBJ:   &object.Time{Value: time.Now()},
+	}
+	for typ, names := range object.MethodNames {
+		value, ok := values[typ].(interface {
+			GetMethod(string, object.Evaluator) *object.Builtin
+		})
+		if !ok {
+			t.Errorf("no value with methods for type %s", typ)
+			continue
+		}
+		for _, name := range names {
+			if value.GetMethod(name, nil) == nil {
+				t.Errorf("%s has no method %q", typ, fmt.Sprint(name))
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package module
+
+import (
+	"ede/object"
+	"sort"
+	"strconv"
+)
+
+// The helpers shared by the modules to read the arguments of their functions
+
+// number returns the value of an Int or a Float argument of the function, e.g.
+// math.sqrt
+func number(fn string, arg object.Object) (float64, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Int:
+		return float64(arg.Value), nil
+	case *object.Float:
+		return arg.Value, nil
+	}
+	return 0, object.NewErrorWithMsg("expected %s to receive argument of type 'Int' or 'Float', got %T", fn, arg)
+}
+
+// hashKeys returns the keys of the hash, in order
+func hashKeys(hash *object.Hash) []string {
+	keys := make([]string, 0, len(hash.Entries))
+	for key := range hash.Entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringArgs returns the String arguments of the function, e.g. fs.read, of which
+// there are n
+func stringArgs(fn string, args []object.Object, n int) ([]string, *object.Error) {
+	if len(args) != n {
+		return nil, object.CountArgumentError(strconv.Itoa(n), len(args))
+	}
+	values := make([]string, n)
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, object.NewErrorWithMsg("expected %s to receive argument of type 'String', got %T", fn, arg)
+		}
+		values[i] = str.Value
+	}
+	return values, nil
+}
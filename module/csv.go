@@ -0,0 +1,301 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"ede/object"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+// CSVModule reads and writes comma-separated values. The fields are read as
+// strings; with the header option, the rows are hashes keyed by the columns of
+// the first record, otherwise arrays.
+type CSVModule struct {
+	functions   map[string]*object.Builtin
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *CSVModule) Name() string { return "csv" }
+
+func (j *CSVModule) Functions() map[string]*object.Builtin { return j.functions }
+
+func (j *CSVModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"parse":     j.Parse(),
+		"stringify": j.Stringify(),
+		"rows":      j.Rows(),
+	}
+}
+
+// csvOptions are the options of the functions of the module, passed as a hash
+type csvOptions struct {
+	header    bool
+	delimiter rune
+	columns   []string
+}
+
+// parseOptions sets the options of the hash argument of the function, which are
+// those in allowed, on the defaults opts
+func parseOptions(fn string, arg object.Object, opts csvOptions, allowed ...string) (csvOptions, *object.Error) {
+	hash, ok := arg.(*object.Hash)
+	if !ok {
+		return opts, object.NewErrorWithMsg("expected %s to receive argument of type 'Hash', got %T", fn, arg)
+	}
+	for _, key := range hashKeys(hash) {
+		if !slices.Contains(allowed, key) {
+			return opts, object.NewErrorWithMsg("unknown option '%s' for %s", key, fn)
+		}
+		switch value := hash.Entries[key]; key {
+		case "header":
+			b, ok := value.(*object.Boolean)
+			if !ok {
+				return opts, object.NewErrorWithMsg("expected the header option of %s to be a 'Boolean', got %T", fn, value)
+			}
+			opts.header = b.Value
+		case "delimiter":
+			s, ok := value.(*object.String)
+			if !ok || utf8.RuneCountInString(s.Value) != 1 {
+				return opts, object.NewErrorWithMsg("expected the delimiter option of %s to be a single character, got %s", fn, value.Inspect())
+			}
+			opts.delimiter, _ = utf8.DecodeRuneInString(s.Value)
+			// the characters that encoding/csv rejects, which quote or end the fields
+			if strings.ContainsRune("\"\r\n"+string(utf8.RuneError), opts.delimiter) {
+				return opts, object.NewErrorWithMsg("invalid delimiter %q for %s", s.Value, fn)
+			}
+		case "columns":
+			arr, ok := value.(*object.Array)
+			if !ok {
+				return opts, object.NewErrorWithMsg("expected the columns option of %s to be an 'Array', got %T", fn, value)
+			}
+			opts.columns = make([]string, len(*arr.Entries))
+			for i, el := range *arr.Entries {
+				s, ok := el.(*object.String)
+				if !ok {
+					return opts, object.NewErrorWithMsg("expected the columns of %s to be strings, got %T", fn, el)
+				}
+				opts.columns[i] = s.Value
+			}
+		}
+	}
+	return opts, nil
+}
+
+// csvReader reads the records of r as rows, the first record being the header
+// with the header option
+type csvReader struct {
+	reader  *csv.Reader
+	header  bool
+	columns []string
+}
+
+func newCSVReader(r io.Reader, opts csvOptions) *csvReader {
+	reader := csv.NewReader(r)
+	reader.Comma = opts.delimiter
+	return &csvReader{reader: reader, header: opts.header}
+}
+
+// next returns the next row, or io.EOF if there are no more rows. A malformed
+// record is a *csv.ParseError, which has its line.
+func (c *csvReader) next() (object.Object, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if c.header && c.columns == nil {
+		c.columns = record
+		return c.next()
+	}
+
+	if c.header {
+		entries := make(map[string]object.Object, len(record))
+		for i, field := range record {
+			entries[c.columns[i]] = object.NewString(field)
+		}
+		return &object.Hash{Entries: entries}, nil
+	}
+	entries := make([]object.Object, len(record))
+	for i, field := range record {
+		entries[i] = object.NewString(field)
+	}
+	return &object.Array{Entries: &entries}, nil
+}
+
+// Parse parses a CSV string, with the options header (false by default) and
+// delimiter (a comma by default), and returns the array of its rows
+func (j *CSVModule) Parse() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected csv.parse to receive argument of type 'String', got %T", args[0])
+			}
+			opts := csvOptions{delimiter: ','}
+			if len(args) == 2 {
+				var err *object.Error
+				if opts, err = parseOptions("csv.parse", args[1], opts, "header", "delimiter"); err != nil {
+					return err
+				}
+			}
+
+			reader := newCSVReader(bytes.NewBufferString(str.Value), opts)
+			rows := []object.Object{}
+			for {
+				row, err := reader.next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					return object.NewErrorWithMsg("error parsing csv: %s", err)
+				}
+				rows = append(rows, row)
+			}
+			return &object.Array{Entries: &rows}
+		},
+	}
+}
+
+// Rows returns an iterator over the rows of a CSV file, which are read as the loop
+// goes, with the same options as parse. The file is read again by each loop.
+func (j *CSVModule) Rows() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected csv.rows to receive argument of type 'String', got %T", args[0])
+			}
+			opts := csvOptions{delimiter: ','}
+			if len(args) == 2 {
+				var err *object.Error
+				if opts, err = parseOptions("csv.rows", args[1], opts, "header", "delimiter"); err != nil {
+					return err
+				}
+			}
+
+			read := func(r *bufio.Reader) func() (object.Object, bool) {
+				reader := newCSVReader(r, opts)
+				return func() (object.Object, bool) {
+					row, err := reader.next()
+					if errors.Is(err, io.EOF) {
+						return nil, false
+					}
+					if err != nil {
+						return object.NewErrorWithMsg("error parsing csv file %s: %s", path.Value, err), true
+					}
+					return row, true
+				}
+			}
+			return fileIterator("csv.rows", path.Value, read, func(err error) object.Object {
+				return object.NewErrorWithMsg("error opening csv file: %s", err)
+			})
+		},
+	}
+}
+
+// Stringify writes an array of rows as CSV, with the options columns, header
+// (true by default) and delimiter. The rows are arrays, or hashes whose values
+// are written in the order of the columns, all their keys in order by default.
+// The columns are written as the first record, unless the header option is false.
+func (j *CSVModule) Stringify() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return object.NewErrorWithMsg("expected csv.stringify to receive argument of type 'Array', got %T", args[0])
+			}
+			opts := csvOptions{header: true, delimiter: ','}
+			if len(args) == 2 {
+				var err *object.Error
+				if opts, err = parseOptions("csv.stringify", args[1], opts, "header", "delimiter", "columns"); err != nil {
+					return err
+				}
+			}
+
+			rows := *arr.Entries
+			if opts.columns == nil {
+				opts.columns = hashColumns(rows)
+			}
+			buf := new(bytes.Buffer)
+			writer := csv.NewWriter(buf)
+			writer.Comma = opts.delimiter
+			if opts.header && len(opts.columns) > 0 {
+				if err := writer.Write(opts.columns); err != nil {
+					return object.NewErrorWithMsg("error writing csv: %s", err)
+				}
+			}
+			for i, row := range rows {
+				var record []string
+				switch row := row.(type) {
+				case *object.Array:
+					record = make([]string, len(*row.Entries))
+					for i, el := range *row.Entries {
+						record[i] = csvField(el)
+					}
+				case *object.Hash:
+					record = make([]string, len(opts.columns))
+					for i, column := range opts.columns {
+						record[i] = csvField(row.Entries[column])
+					}
+				default:
+					return object.NewErrorWithMsg("expected the row %d of csv.stringify to be an 'Array' or a 'Hash', got %T", i, row)
+				}
+				if err := writer.Write(record); err != nil {
+					return object.NewErrorWithMsg("error writing csv: %s", err)
+				}
+			}
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return object.NewErrorWithMsg("error writing csv: %s", err)
+			}
+			return object.NewString(buf.String())
+		},
+	}
+}
+
+// hashColumns returns the keys of the hash rows, in order
+func hashColumns(rows []object.Object) []string {
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, row := range rows {
+		if hash, ok := row.(*object.Hash); ok {
+			for key := range hash.Entries {
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// csvField returns the field of a value: a string as is, nil as an empty field,
+// and the other values as inspected
+func csvField(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil, *object.Nil:
+		return ""
+	case *object.String:
+		return obj.Value
+	}
+	return obj.Inspect()
+}
@@ -0,0 +1,85 @@
+package module
+
+import (
+	"ede/object"
+	"os"
+	"strings"
+)
+
+type EnvModule struct {
+	functions   map[string]*object.Builtin
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *EnvModule) Name() string { return "env" }
+
+func (j *EnvModule) Functions() map[string]*object.Builtin { return j.functions }
+
+func (j *EnvModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"get":  j.Get(),
+		"set":  j.Set(),
+		"list": j.List(),
+	}
+}
+
+// Get returns the value of the environment variable, or the default
+// value (nil if not given) when the variable is not set
+func (j *EnvModule) Get() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected env.get to receive argument of type 'String', got %T", args[0])
+			}
+			if val, found := os.LookupEnv(key.Value); found {
+				return object.NewString(val)
+			}
+			if len(args) == 2 {
+				return args[1]
+			}
+			return object.NIL
+		},
+	}
+}
+
+func (j *EnvModule) Set() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected env.set to receive argument of type 'String', got %T", args[0])
+			}
+			if err := os.Setenv(key.Value, args[1].Inspect()); err != nil {
+				return object.NewErrorWithMsg("error setting environment variable: %s", err)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// List returns all environment variables as a hash
+func (j *EnvModule) List() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.CountArgumentError("0", len(args))
+			}
+			hash := &object.Hash{Entries: make(map[string]object.Object)}
+			for _, entry := range os.Environ() {
+				key, val, _ := strings.Cut(entry, "=")
+				hash.Entries[key] = object.NewString(val)
+			}
+			return hash
+		},
+	}
+}
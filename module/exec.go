@@ -0,0 +1,406 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"ede/object"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecModule runs external commands. A command which cannot be started, times out
+// or exits with a non-zero code is an error of the kind not_found, timeout or exit,
+// which a match can handle with the values of the module, e.g. case exec.timeout.
+type ExecModule struct {
+	functions   map[string]*object.Builtin
+	values      map[string]object.Object
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *ExecModule) Name() string { return "exec" }
+
+func (j *ExecModule) Functions() map[string]*object.Builtin { return j.functions }
+
+// Values returns the kinds of the errors of the module, e.g. exec.timeout
+func (j *ExecModule) Values() map[string]object.Object { return j.values }
+
+func (j *ExecModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"run":    j.Run(),
+		"output": j.Output(),
+		"spawn":  j.Spawn(),
+	}
+	j.values = map[string]object.Object{}
+	for _, kind := range []string{"not_found", "timeout", "exit"} {
+		j.values[kind] = &object.ErrorKind{Name: kind}
+	}
+}
+
+// execOptions are the options of a command, passed as a hash
+type execOptions struct {
+	cwd     string
+	env     []string // the variables added to the environment, as key=value
+	stdin   string
+	timeout time.Duration // no timeout if zero
+	check   bool          // whether a non-zero exit code is an error
+}
+
+// command returns the name and the arguments of the command of the function, e.g.
+// exec.run, from its arguments cmd and the optional array args
+func command(fn string, args []object.Object) (string, []string, *object.Error) {
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return "", nil, object.NewErrorWithMsg("expected %s to receive argument of type 'String', got %T", fn, args[0])
+	}
+	if len(args) == 1 {
+		return name.Value, nil, nil
+	}
+	arr, ok := args[1].(*object.Array)
+	if !ok {
+		return "", nil, object.NewErrorWithMsg("expected %s to receive argument of type 'Array', got %T", fn, args[1])
+	}
+	cmdArgs, err := stringArgs(fn, *arr.Entries, len(*arr.Entries))
+	if err != nil {
+		return "", nil, err
+	}
+	return name.Value, cmdArgs, nil
+}
+
+// commandOptions returns the options cwd, env, stdin, timeout (in seconds) and
+// check (true by default) of the hash argument of the function
+func commandOptions(fn string, arg object.Object) (execOptions, *object.Error) {
+	opts := execOptions{check: true}
+	if arg == nil {
+		return opts, nil
+	}
+	hash, ok := arg.(*object.Hash)
+	if !ok {
+		return opts, object.NewErrorWithMsg("expected %s to receive argument of type 'Hash', got %T", fn, arg)
+	}
+	for _, key := range hashKeys(hash) {
+		switch value := hash.Entries[key]; key {
+		case "cwd", "stdin":
+			s, ok := value.(*object.String)
+			if !ok {
+				return opts, object.NewErrorWithMsg("expected the %s option of %s to be a 'String', got %T", key, fn, value)
+			}
+			if key == "cwd" {
+				opts.cwd = s.Value
+			} else {
+				opts.stdin = s.Value
+			}
+		case "env":
+			env, ok := value.(*object.Hash)
+			if !ok {
+				return opts, object.NewErrorWithMsg("expected the env option of %s to be a 'Hash', got %T", fn, value)
+			}
+			for _, name := range hashKeys(env) {
+				s, ok := env.Entries[name].(*object.String)
+				if !ok {
+					return opts, object.NewErrorWithMsg("expected the variables of %s to be strings, got %T", fn, env.Entries[name])
+				}
+				opts.env = append(opts.env, name+"="+s.Value)
+			}
+		case "timeout":
+			seconds, err := number(fn, value)
+			if err != nil || seconds <= 0 {
+				return opts, object.NewErrorWithMsg("expected the timeout option of %s to be a positive number of seconds, got %s", fn, value.Inspect())
+			}
+			opts.timeout = time.Duration(seconds * float64(time.Second))
+		case "check":
+			b, ok := value.(*object.Boolean)
+			if !ok {
+				return opts, object.NewErrorWithMsg("expected the check option of %s to be a 'Boolean', got %T", fn, value)
+			}
+			opts.check = b.Value
+		default:
+			return opts, object.NewErrorWithMsg("unknown option '%s' for %s", key, fn)
+		}
+	}
+	return opts, nil
+}
+
+// newCmd returns the command with the options, its context, which is done at the
+// timeout, and the function cancelling the context, which kills the command
+func (opts execOptions) newCmd(name string, args []string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if opts.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), opts.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = opts.cwd
+	if opts.env != nil {
+		cmd.Env = append(os.Environ(), opts.env...)
+	}
+	if opts.stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.stdin)
+	}
+	return cmd, ctx, cancel
+}
+
+// commandError returns the error of the command which ran with the error err, or
+// nil if the command succeeded. The error of a non-zero exit code has the stderr
+// of the command, if any.
+func commandError(ctx context.Context, name string, opts execOptions, err error, stderr string) *object.Error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		obj := object.NewErrorWithMsg("command %q timed out after %s", name, opts.timeout)
+		obj.Kind = "timeout"
+		return obj
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if !opts.check {
+			return nil
+		}
+		msg := fmt.Sprintf("command %q exited with code %d", name, exitErr.ExitCode())
+		if stderr = strings.TrimSpace(stderr); stderr != "" {
+			msg += ": " + stderr
+		}
+		obj := object.NewErrorWithMsg("%s", msg)
+		obj.Kind = "exit"
+		return obj
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		obj := object.NewErrorWithMsg("%s", err)
+		obj.Kind = "not_found"
+		return obj
+	}
+	return fsError(err)
+}
+
+// result returns the hash of the result of a command, with its exit code and its
+// duration in seconds, like the difference of two times
+func result(cmd *exec.Cmd, duration time.Duration) *object.Hash {
+	return &object.Hash{Entries: map[string]object.Object{
+		"code":     object.NewInt(int64(cmd.ProcessState.ExitCode())),
+		"duration": object.NewFloat(duration.Seconds()),
+	}}
+}
+
+// stream runs the command, calling read with each of its output streams, "stdout"
+// and "stderr", in goroutines of their own, and returns the error of the command.
+// When the context is done first, e.g. at the timeout, the killed command is not
+// waited for its streams to be read to the end, as a process it started may keep
+// them open.
+func stream(ctx context.Context, cmd *exec.Cmd, read func(r io.Reader, name string)) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		read(stdout, "stdout")
+	}()
+	go func() {
+		defer wg.Done()
+		read(stderr, "stderr")
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	err = cmd.Wait() // closes the streams, which ends the reads
+	<-done
+	return err
+}
+
+// run runs the command of the arguments of the function, and returns its result
+// with its stdout and stderr
+func run(fn string, args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, object.CountArgumentError("1, 2 or 3", len(args))
+	}
+	var optsArg object.Object
+	if len(args) == 3 {
+		optsArg = args[2]
+	}
+	opts, err := commandOptions(fn, optsArg)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 3 {
+		args = args[:2]
+	}
+	name, cmdArgs, err := command(fn, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, ctx, cancel := opts.newCmd(name, cmdArgs)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	start := time.Now()
+	runErr := stream(ctx, cmd, func(r io.Reader, name string) {
+		if name == "stdout" {
+			io.Copy(&stdout, r)
+		} else {
+			io.Copy(&stderr, r)
+		}
+	})
+	duration := time.Since(start)
+	if err := commandError(ctx, name, opts, runErr, stderr.String()); err != nil {
+		return nil, err
+	}
+	hash := result(cmd, duration)
+	hash.Entries["stdout"] = object.NewString(stdout.String())
+	hash.Entries["stderr"] = object.NewString(stderr.String())
+	return hash, nil
+}
+
+// Run runs a command with the array of its arguments and the options cwd, env (a
+// hash of variables added to the environment), stdin, timeout (in seconds) and
+// check (false to return the result of a non-zero exit code rather than an
+// error). It returns the hash of the stdout, stderr, code and duration of the
+// command.
+func (j *ExecModule) Run() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			hash, err := run("exec.run", args)
+			if err != nil {
+				return err
+			}
+			return hash
+		},
+	}
+}
+
+// Output runs a command like run, and returns its stdout without the trailing
+// newlines
+func (j *ExecModule) Output() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			hash, err := run("exec.output", args)
+			if err != nil {
+				return err
+			}
+			stdout := hash.Entries["stdout"].(*object.String).Value
+			return object.NewString(strings.TrimRight(stdout, "\r\n"))
+		},
+	}
+}
+
+// outputLine is a line written by a spawned command to its stream, "stdout" or
+// "stderr"
+type outputLine struct {
+	text, stream string
+}
+
+// Spawn runs a command with the array of its arguments, and calls a function with
+// each line of its output as it is written, without the line ending. A function
+// with two parameters also receives the stream of the line, "stdout" or "stderr".
+// The options are those of run. It returns the hash of the code and duration of
+// the command; if the function returns an error, the command is killed and the
+// error is returned.
+func (j *ExecModule) Spawn() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return object.CountArgumentError("3 or 4", len(args))
+			}
+			name, cmdArgs, err := command("exec.spawn", args[:2])
+			if err != nil {
+				return err
+			}
+			fn := args[2]
+			switch fn.(type) {
+			case *object.Function, *object.Builtin:
+			default:
+				return object.NewErrorWithMsg("expected exec.spawn to receive argument of type 'Function', got %T", fn)
+			}
+			var optsArg object.Object
+			if len(args) == 4 {
+				optsArg = args[3]
+			}
+			opts, err := commandOptions("exec.spawn", optsArg)
+			if err != nil {
+				return err
+			}
+
+			cmd, ctx, cancel := opts.newCmd(name, cmdArgs)
+			defer cancel()
+			start := time.Now()
+			// the lines of both streams are passed to the function by this goroutine,
+			// as the evaluator is not safe for concurrent use
+			lines := make(chan outputLine)
+			stop := make(chan struct{}) // closed when the lines are no longer received
+			errc := make(chan error, 1)
+			go func() {
+				errc <- stream(ctx, cmd, func(r io.Reader, name string) {
+					reader := bufio.NewReader(r)
+					for {
+						line, err := reader.ReadString('\n')
+						if line != "" {
+							select {
+							case lines <- outputLine{text: strings.TrimRight(line, "\r\n"), stream: name}:
+							case <-stop:
+								return
+							}
+						}
+						if err != nil {
+							return
+						}
+					}
+				})
+				close(lines)
+			}()
+			// when the function returns an error, or panics, e.g. with os.exit, the
+			// command is killed and waited for
+			waited := false
+			defer func() {
+				if !waited {
+					close(stop)
+					cancel()
+					<-errc
+				}
+			}()
+
+			for line := range lines {
+				fnArgs := []object.Object{object.NewString(line.text)}
+				if f, ok := fn.(*object.Function); ok && len(f.Params) == 2 {
+					fnArgs = append(fnArgs, object.NewString(line.stream))
+				}
+				if obj, ok := j.evaluator.Apply(fn, fnArgs...).(*object.Error); ok {
+					return obj
+				}
+			}
+			waitErr := <-errc
+			waited = true
+			duration := time.Since(start)
+			if err := commandError(ctx, name, opts, waitErr, ""); err != nil {
+				return err
+			}
+			return result(cmd, duration)
+		},
+	}
+}
@@ -0,0 +1,471 @@
+package module
+
+import (
+	"bufio"
+	"ede/object"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FSModule reads and writes the files of the filesystem. Its errors have the kind
+// of the OS error, which a match can handle with the values of the module, e.g.
+// case fs.not_found.
+type FSModule struct {
+	functions   map[string]*object.Builtin
+	values      map[string]object.Object
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+// errStopWalk stops fs.walk when its function returns an error
+var errStopWalk = errors.New("stop walk")
+
+// errorKinds are the kinds of the errors of the module, by name
+var errorKinds = []struct {
+	name string
+	err  error
+}{
+	{"not_found", fs.ErrNotExist},
+	{"exists", fs.ErrExist},
+	{"permission", fs.ErrPermission},
+	{"invalid", fs.ErrInvalid},
+}
+
+func (j *FSModule) Name() string { return "fs" }
+
+func (j *FSModule) Functions() map[string]*object.Builtin { return j.functions }
+
+// Values returns the kinds of the errors of the module, e.g. fs.not_found
+func (j *FSModule) Values() map[string]object.Object { return j.values }
+
+func (j *FSModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"read":     j.Read(),
+		"write":    j.write("write", os.O_TRUNC),
+		"append":   j.write("append", os.O_APPEND),
+		"exists":   j.Exists(),
+		"stat":     j.Stat(),
+		"list":     j.List(),
+		"walk":     j.Walk(),
+		"glob":     j.Glob(),
+		"mkdir":    j.Mkdir(),
+		"remove":   j.Remove(),
+		"rename":   j.Rename(),
+		"copy":     j.Copy(),
+		"temp_dir": j.TempDir(),
+		"lines":    j.Lines(),
+	}
+	j.values = map[string]object.Object{}
+	for _, kind := range errorKinds {
+		j.values[kind.name] = &object.ErrorKind{Name: kind.name}
+	}
+}
+
+// fsError returns the error of the OS error, with its kind
+func fsError(err error) *object.Error {
+	obj := object.NewErrorWithMsg("%s", err)
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			obj.Kind = kind.name
+			break
+		}
+	}
+	return obj
+}
+
+// flag returns the Boolean option of the hash argument of the function, false by
+// default
+func flag(fn string, arg object.Object, name string) (bool, *object.Error) {
+	hash, ok := arg.(*object.Hash)
+	if !ok {
+		return false, object.NewErrorWithMsg("expected fs.%s to receive argument of type 'Hash', got %T", fn, arg)
+	}
+	for _, key := range hashKeys(hash) {
+		if key != name {
+			return false, object.NewErrorWithMsg("unknown option '%s' for fs.%s", key, fn)
+		}
+	}
+	value, ok := hash.Entries[name]
+	if !ok {
+		return false, nil
+	}
+	b, ok := value.(*object.Boolean)
+	if !ok {
+		return false, object.NewErrorWithMsg("expected the %s option of fs.%s to be a 'Boolean', got %T", name, fn, value)
+	}
+	return b.Value, nil
+}
+
+// fileInfo returns the hash describing the file: its name, size, mode, mtime and
+// whether it is a directory
+func fileInfo(info fs.FileInfo) *object.Hash {
+	return &object.Hash{Entries: map[string]object.Object{
+		"name":   object.NewString(info.Name()),
+		"size":   object.NewInt(info.Size()),
+		"mode":   object.NewString(info.Mode().String()),
+		"mtime":  object.NewTime(info.ModTime(), ""),
+		"is_dir": object.NewBoolean(info.IsDir()),
+	}}
+}
+
+// Read returns the content of a file
+func (j *FSModule) Read() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.read", args, 1)
+			if err != nil {
+				return err
+			}
+			data, readErr := os.ReadFile(p[0])
+			if readErr != nil {
+				return fsError(readErr)
+			}
+			return object.NewString(string(data))
+		},
+	}
+}
+
+// write returns the function writing a string to a file, which is created if it
+// does not exist. The mode is os.O_TRUNC to replace the content of the file, or
+// os.O_APPEND to add to it.
+func (j *FSModule) write(name string, mode int) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			p, err := stringArgs("fs."+name, args[:1], 1)
+			if err != nil {
+				return err
+			}
+			data, ok := args[1].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected fs.%s to receive argument of type 'String', got %T", name, args[1])
+			}
+			file, openErr := os.OpenFile(p[0], os.O_WRONLY|os.O_CREATE|mode, 0o644)
+			if openErr != nil {
+				return fsError(openErr)
+			}
+			if _, writeErr := file.WriteString(data.Value); writeErr != nil {
+				file.Close()
+				return fsError(writeErr)
+			}
+			if closeErr := file.Close(); closeErr != nil {
+				return fsError(closeErr)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// Exists reports whether a file exists
+func (j *FSModule) Exists() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.exists", args, 1)
+			if err != nil {
+				return err
+			}
+			_, statErr := os.Stat(p[0])
+			if errors.Is(statErr, fs.ErrNotExist) {
+				return object.FALSE
+			}
+			if statErr != nil {
+				return fsError(statErr)
+			}
+			return object.TRUE
+		},
+	}
+}
+
+// Stat returns the hash describing a file, with its name, size, mode, mtime and
+// is_dir
+func (j *FSModule) Stat() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.stat", args, 1)
+			if err != nil {
+				return err
+			}
+			info, statErr := os.Stat(p[0])
+			if statErr != nil {
+				return fsError(statErr)
+			}
+			return fileInfo(info)
+		},
+	}
+}
+
+// List returns the names of the entries of a directory, in order
+func (j *FSModule) List() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.list", args, 1)
+			if err != nil {
+				return err
+			}
+			entries, readErr := os.ReadDir(p[0])
+			if readErr != nil {
+				return fsError(readErr)
+			}
+			names := make([]object.Object, len(entries))
+			for i, entry := range entries {
+				names[i] = object.NewString(entry.Name())
+			}
+			return &object.Array{Entries: &names}
+		},
+	}
+}
+
+// Walk calls a function with the path of each file of a directory and its
+// subdirectories, in order, starting with the directory itself. A function with
+// two parameters also receives the hash of fs.stat of the file. The walk stops
+// at the first error, which is returned.
+func (j *FSModule) Walk() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			p, err := stringArgs("fs.walk", args[:1], 1)
+			if err != nil {
+				return err
+			}
+			fn := args[1]
+			switch fn.(type) {
+			case *object.Function, *object.Builtin:
+			default:
+				return object.NewErrorWithMsg("expected fs.walk to receive argument of type 'Function', got %T", fn)
+			}
+
+			var result object.Object = object.NIL
+			walkErr := filepath.WalkDir(p[0], func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				fnArgs := []object.Object{object.NewString(path)}
+				if f, ok := fn.(*object.Function); ok && len(f.Params) == 2 {
+					info, err := entry.Info()
+					if err != nil {
+						return err
+					}
+					fnArgs = append(fnArgs, fileInfo(info))
+				}
+				if obj, ok := j.evaluator.Apply(fn, fnArgs...).(*object.Error); ok {
+					result = obj
+					return errStopWalk
+				}
+				return nil
+			})
+			if walkErr != nil && walkErr != errStopWalk {
+				return fsError(walkErr)
+			}
+			return result
+		},
+	}
+}
+
+// Glob returns the paths matching a pattern, in order, e.g. "*.ede"
+func (j *FSModule) Glob() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.glob", args, 1)
+			if err != nil {
+				return err
+			}
+			matches, globErr := filepath.Glob(p[0])
+			if globErr != nil {
+				return object.NewErrorWithMsg("invalid pattern %q for fs.glob: %s", p[0], globErr)
+			}
+			sort.Strings(matches)
+			entries := make([]object.Object, len(matches))
+			for i, match := range matches {
+				entries[i] = object.NewString(match)
+			}
+			return &object.Array{Entries: &entries}
+		},
+	}
+}
+
+// Mkdir creates a directory. With the option parents, the missing parents are
+// also created, and an existing directory is not an error.
+func (j *FSModule) Mkdir() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			p, err := stringArgs("fs.mkdir", args[:1], 1)
+			if err != nil {
+				return err
+			}
+			parents := false
+			if len(args) == 2 {
+				if parents, err = flag("mkdir", args[1], "parents"); err != nil {
+					return err
+				}
+			}
+			mkdir := os.Mkdir
+			if parents {
+				mkdir = os.MkdirAll
+			}
+			if mkdirErr := mkdir(p[0], 0o755); mkdirErr != nil {
+				return fsError(mkdirErr)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// Remove removes a file or an empty directory. With the option recursive, a
+// directory is removed with its content.
+func (j *FSModule) Remove() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			p, err := stringArgs("fs.remove", args[:1], 1)
+			if err != nil {
+				return err
+			}
+			recursive := false
+			if len(args) == 2 {
+				if recursive, err = flag("remove", args[1], "recursive"); err != nil {
+					return err
+				}
+			}
+			if recursive {
+				// RemoveAll does not fail if the path does not exist
+				if _, statErr := os.Lstat(p[0]); statErr != nil {
+					return fsError(statErr)
+				}
+				if removeErr := os.RemoveAll(p[0]); removeErr != nil {
+					return fsError(removeErr)
+				}
+				return object.NIL
+			}
+			if removeErr := os.Remove(p[0]); removeErr != nil {
+				return fsError(removeErr)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// Rename moves a file to a new path
+func (j *FSModule) Rename() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.rename", args, 2)
+			if err != nil {
+				return err
+			}
+			if renameErr := os.Rename(p[0], p[1]); renameErr != nil {
+				return fsError(renameErr)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// Copy copies a file to a new path, with its mode
+func (j *FSModule) Copy() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.copy", args, 2)
+			if err != nil {
+				return err
+			}
+			if copyErr := copyFile(p[0], p[1]); copyErr != nil {
+				return fsError(copyErr)
+			}
+			return object.NIL
+		},
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	// opening the destination truncates it, which would lose the source
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return fmt.Errorf("cannot copy %s onto itself: %w", src, fs.ErrInvalid)
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// TempDir creates a new temporary directory and returns its path. Its name starts
+// with the prefix, "ede" by default.
+func (j *FSModule) TempDir() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.CountArgumentError("0 or 1", len(args))
+			}
+			prefix := "ede"
+			if len(args) == 1 {
+				p, err := stringArgs("fs.temp_dir", args, 1)
+				if err != nil {
+					return err
+				}
+				prefix = p[0]
+			}
+			dir, err := os.MkdirTemp("", prefix)
+			if err != nil {
+				return fsError(err)
+			}
+			return object.NewString(dir)
+		},
+	}
+}
+
+// Lines returns an iterator over the lines of a file, without their line endings,
+// which are read as the loop goes. The file is read again by each loop.
+func (j *FSModule) Lines() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("fs.lines", args, 1)
+			if err != nil {
+				return err
+			}
+			read := func(r *bufio.Reader) func() (object.Object, bool) {
+				return func() (object.Object, bool) {
+					line, err := r.ReadString('\n')
+					if errors.Is(err, io.EOF) && line == "" {
+						return nil, false
+					}
+					if err != nil && !errors.Is(err, io.EOF) {
+						return fsError(err), true
+					}
+					line = strings.TrimSuffix(line, "\n")
+					return object.NewString(strings.TrimSuffix(line, "\r")), true
+				}
+			}
+			return fileIterator("fs.lines", p[0], read, func(err error) object.Object { return fsError(err) })
+		},
+	}
+}
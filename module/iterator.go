@@ -0,0 +1,36 @@
+package module
+
+import (
+	"bufio"
+	"ede/object"
+	"os"
+)
+
+// fileIterator returns an iterator over the items read from the file at the path.
+// The file is opened by the first Next of a loop and closed when the loop is over,
+// so that an iterator holds no file until it is ranged, and can be ranged again.
+// read returns the function reading the next item of the buffered file, and
+// openError the error of a file which cannot be opened.
+func fileIterator(name, path string, read func(*bufio.Reader) func() (object.Object, bool), openError func(error) object.Object) *object.Iterator {
+	var file *os.File
+	var next func() (object.Object, bool)
+	return &object.Iterator{
+		Name: name,
+		Next: func() (object.Object, bool) {
+			if file == nil {
+				f, err := os.Open(path)
+				if err != nil {
+					return openError(err), true
+				}
+				file, next = f, read(bufio.NewReader(f))
+			}
+			return next()
+		},
+		Close: func() {
+			if file != nil {
+				file.Close()
+				file, next = nil, nil
+			}
+		},
+	}
+}
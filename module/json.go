@@ -1,8 +1,18 @@
 package module
 
 import (
+	"bufio"
+	"bytes"
 	"ede/object"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type JSONModule struct {
@@ -21,9 +31,13 @@ func (j *JSONModule) Init(evaluator object.Evaluator, env *object.Environment) {
 	j.functions = map[string]*object.Builtin{
 		"parse":  j.Parse(),
 		"string": j.String(),
+		"pretty": j.Pretty(),
+		"lines":  j.Lines(),
 	}
 }
 
+// Parse parses a JSON value of any type. The integers are parsed as Int, the other
+// numbers as Float, and null as nil.
 func (j *JSONModule) Parse() *object.Builtin {
 	return &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
@@ -31,44 +45,244 @@ func (j *JSONModule) Parse() *object.Builtin {
 				return object.CountArgumentError("1", len(args))
 			}
 
-			key, ok := args[0].(*object.String)
+			str, ok := args[0].(*object.String)
 			if !ok {
 				return object.NewErrorWithMsg("expected json.parse to receive argument of type 'String', got %T", args[0])
 			}
-			var obj map[string]any
-			if err := json.Unmarshal([]byte(key.Value), &obj); err != nil {
-				return object.NewErrorWithMsg("error parsing string as json: %s", err)
+			obj, offset, err := decodeJSON(str.Value)
+			if err != nil {
+				line, column := lineColumn(str.Value, offset)
+				return object.NewErrorWithMsg("error parsing string as json at line %d, column %d (offset %d): %s", line, column, offset, err)
+			}
+			return obj
+		},
+	}
+}
+
+// String encodes a value as JSON
+func (j *JSONModule) String() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			return encodeJSON(args[0], "")
+		},
+	}
+}
+
+// Pretty encodes a value as indented JSON. The indent is a number of spaces or a
+// string, two spaces by default.
+func (j *JSONModule) Pretty() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
 			}
-			hash := &object.Hash{Entries: make(map[string]object.Object)}
-			for k, v := range obj {
-				val := object.New(v)
-				if val.Type() == object.ERROR_OBJ {
-					return val
+			indent := "  "
+			if len(args) == 2 {
+				switch arg := args[1].(type) {
+				case *object.Int:
+					if arg.Value < 0 {
+						return object.NewErrorWithMsg("expected the indent of json.pretty to be non-negative, got %d", arg.Value)
+					}
+					indent = strings.Repeat(" ", int(arg.Value))
+				case *object.String:
+					indent = arg.Value
+				default:
+					return object.NewErrorWithMsg("expected json.pretty to receive argument of type 'Int' or 'String', got %T", args[1])
 				}
-				hash.Entries[k] = val
 			}
-			return hash
+			return encodeJSON(args[0], indent)
 		},
 	}
 }
 
-func (j *JSONModule) String() *object.Builtin {
+// Lines returns an iterator over the values of a JSON Lines file, i.e. a JSON value
+// per line, which are decoded as the loop goes. The blank lines are skipped, and the
+// file is read again by each loop.
+func (j *JSONModule) Lines() *object.Builtin {
 	return &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.CountArgumentError("1", len(args))
 			}
 
-			obj, ok := args[0].(*object.Hash)
+			path, ok := args[0].(*object.String)
 			if !ok {
-				return object.NewErrorWithMsg("expected json.string to receive argument of type 'Hash', got %T", args[0])
+				return object.NewErrorWithMsg("expected json.lines to receive argument of type 'String', got %T", args[0])
 			}
-			res, err := json.Marshal(obj.Native())
-			if err != nil {
-				return object.NewErrorWithMsg("error parsing string as json: %s", err)
+			read := func(r *bufio.Reader) func() (object.Object, bool) {
+				line, start := 0, 0
+				return func() (object.Object, bool) {
+					for {
+						text, err := r.ReadString('\n')
+						if errors.Is(err, io.EOF) && text == "" {
+							return nil, false
+						}
+						if err != nil && !errors.Is(err, io.EOF) {
+							return object.NewErrorWithMsg("error reading json lines file %s: %s", path.Value, err), true
+						}
+						lineStart := start
+						line++
+						start += len(text)
+						if strings.TrimSpace(text) == "" {
+							continue
+						}
+						obj, offset, err := decodeJSON(text)
+						if err != nil {
+							return object.NewErrorWithMsg("error parsing json line at line %d, column %d (offset %d): %s", line, offset+1, lineStart+offset, err), true
+						}
+						return obj, true
+					}
+				}
 			}
-
-			return object.NewString(string(res))
+			return fileIterator("json.lines", path.Value, read, func(err error) object.Object {
+				return object.NewErrorWithMsg("error opening json lines file: %s", err)
+			})
 		},
 	}
 }
+
+// decodeJSON decodes the JSON value of the data. On error, it returns the byte
+// offset of the error in the data.
+func decodeJSON(data string) (object.Object, int, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset := int(syntaxErr.Offset)
+			if strings.HasPrefix(syntaxErr.Error(), "invalid character") {
+				offset-- // the offset is after the invalid character
+			}
+			return nil, offset, err
+		case err == io.EOF:
+			return nil, len(data), errors.New("unexpected end of JSON input")
+		}
+		return nil, int(dec.InputOffset()), err
+	}
+
+	// only white space can follow the value
+	end := int(dec.InputOffset())
+	if rest := strings.TrimLeft(data[end:], " \t\r\n"); rest != "" {
+		return nil, len(data) - len(rest), fmt.Errorf("invalid character %q after top-level value", rest[0])
+	}
+	return fromJSON(value), 0, nil
+}
+
+// lineColumn returns the line and the column, both from 1, of the byte offset
+func lineColumn(data string, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	return strings.Count(before, "\n") + 1, offset - strings.LastIndex(before, "\n")
+}
+
+// fromJSON converts a value decoded with json.Number numbers to an object
+func fromJSON(value any) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return object.NIL
+	case bool:
+		return object.NewBoolean(value)
+	case string:
+		return object.NewString(value)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return object.NewInt(n)
+		}
+		f, _ := value.Float64()
+		return object.NewFloat(f)
+	case []any:
+		entries := make([]object.Object, len(value))
+		for i, v := range value {
+			entries[i] = fromJSON(v)
+		}
+		return &object.Array{Entries: &entries}
+	case map[string]any:
+		entries := make(map[string]object.Object, len(value))
+		for k, v := range value {
+			entries[k] = fromJSON(v)
+		}
+		return &object.Hash{Entries: entries}
+	}
+	return object.NewErrorWithMsg("unsupported json value %v", value)
+}
+
+// encodeJSON encodes the object as JSON, indented with the indent if not empty
+func encodeJSON(obj object.Object, indent string) object.Object {
+	value, err := toJSON(obj)
+	if err != nil {
+		return object.NewErrorWithMsg("error encoding value as json: %s", err)
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(value); err != nil {
+		return object.NewErrorWithMsg("error encoding value as json: %s", err)
+	}
+	return object.NewString(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// toJSON converts the object to a value encoded by encoding/json. The sets are
+// encoded as sorted arrays, and the times as RFC 3339 strings.
+func toJSON(obj object.Object) (any, error) {
+	switch obj := obj.(type) {
+	case nil, *object.Nil:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Int:
+		return obj.Value, nil
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, fmt.Errorf("unsupported number %v", obj.Value)
+		}
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Time:
+		return obj.Value.Format(time.RFC3339Nano), nil
+	case *object.Array:
+		values := make([]any, len(*obj.Entries))
+		for i, el := range *obj.Entries {
+			value, err := toJSON(el)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *object.Hash:
+		values := make(map[string]any, len(obj.Entries))
+		for k, el := range obj.Entries {
+			value, err := toJSON(el)
+			if err != nil {
+				return nil, err
+			}
+			values[k] = value
+		}
+		return values, nil
+	case *object.Set:
+		keys := make([]object.HashKey, 0, len(obj.Entries))
+		for key := range obj.Entries {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Value < keys[j].Value })
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			value, err := toJSON(object.FromHashKey(key))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", obj.Type())
+}
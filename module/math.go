@@ -0,0 +1,365 @@
+package module
+
+import (
+	"ede/object"
+	"math"
+)
+
+type MathModule struct {
+	functions   map[string]*object.Builtin
+	values      map[string]object.Object
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *MathModule) Name() string { return "math" }
+
+func (j *MathModule) Functions() map[string]*object.Builtin { return j.functions }
+
+// Values returns the constants of the module, e.g. math.pi
+func (j *MathModule) Values() map[string]object.Object { return j.values }
+
+func (j *MathModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"abs":    j.Abs(),
+		"floor":  j.rounding("floor", math.Floor),
+		"ceil":   j.rounding("ceil", math.Ceil),
+		"round":  j.Round(),
+		"sqrt":   j.unary("sqrt", math.Sqrt),
+		"pow":    j.binary("pow", math.Pow),
+		"exp":    j.unary("exp", math.Exp),
+		"log":    j.Log(),
+		"log2":   j.unary("log2", math.Log2),
+		"log10":  j.unary("log10", math.Log10),
+		"sin":    j.unary("sin", math.Sin),
+		"cos":    j.unary("cos", math.Cos),
+		"tan":    j.unary("tan", math.Tan),
+		"asin":   j.unary("asin", math.Asin),
+		"acos":   j.unary("acos", math.Acos),
+		"atan":   j.unary("atan", math.Atan),
+		"atan2":  j.binary("atan2", math.Atan2),
+		"min":    j.extremum("min", func(a, b float64) bool { return a < b }),
+		"max":    j.extremum("max", func(a, b float64) bool { return a > b }),
+		"clamp":  j.Clamp(),
+		"gcd":    j.integer("gcd", gcd),
+		"lcm":    j.integer("lcm", lcm),
+		"is_nan": j.predicate("is_nan", math.IsNaN),
+		"is_inf": j.predicate("is_inf", func(x float64) bool { return math.IsInf(x, 0) }),
+	}
+	j.values = map[string]object.Object{
+		"pi":  object.NewFloat(math.Pi),
+		"e":   object.NewFloat(math.E),
+		"inf": object.NewFloat(math.Inf(1)),
+	}
+}
+
+// unary returns the function applying f to its argument, as a float
+func (j *MathModule) unary(name string, f func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			x, err := number("math."+name, args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewFloat(f(x))
+		},
+	}
+}
+
+// binary returns the function applying f to its two arguments, as floats
+func (j *MathModule) binary(name string, f func(float64, float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			x, err := number("math."+name, args[0])
+			if err != nil {
+				return err
+			}
+			y, err := number("math."+name, args[1])
+			if err != nil {
+				return err
+			}
+			return object.NewFloat(f(x, y))
+		},
+	}
+}
+
+// rounding returns the function rounding a float with f. An Int is returned as is.
+func (j *MathModule) rounding(name string, f func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			if _, ok := args[0].(*object.Int); ok {
+				return args[0]
+			}
+			x, err := number("math."+name, args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewFloat(f(x))
+		},
+	}
+}
+
+// predicate returns the function reporting whether f holds for its argument
+func (j *MathModule) predicate(name string, f func(float64) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			x, err := number("math."+name, args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBoolean(f(x))
+		},
+	}
+}
+
+// integer returns the function applying f to its two Int arguments. f returns
+// false if the result overflows an Int.
+func (j *MathModule) integer(name string, f func(int64, int64) (int64, bool)) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			a, ok := args[0].(*object.Int)
+			if !ok {
+				return object.NewErrorWithMsg("expected math.%s to receive argument of type 'Int', got %T", name, args[0])
+			}
+			b, ok := args[1].(*object.Int)
+			if !ok {
+				return object.NewErrorWithMsg("expected math.%s to receive argument of type 'Int', got %T", name, args[1])
+			}
+			n, ok := f(a.Value, b.Value)
+			if !ok {
+				return object.NewErrorWithMsg("math.%s(%d, %d) overflows an 'Int'", name, a.Value, b.Value)
+			}
+			return object.NewInt(n)
+		},
+	}
+}
+
+// extremum returns the function returning the argument x for which less(x, y)
+// holds for all the other arguments y. The arguments can also be passed as an array.
+func (j *MathModule) extremum(name string, less func(float64, float64) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 1 {
+				if arr, ok := args[0].(*object.Array); ok {
+					args = *arr.Entries
+				}
+			}
+			if len(args) == 0 {
+				return object.NewErrorWithMsg("expected math.%s to receive at least 1 number", name)
+			}
+
+			var result object.Object
+			var best float64
+			for _, arg := range args {
+				x, err := number("math."+name, arg)
+				if err != nil {
+					return err
+				}
+				if result == nil || less(x, best) {
+					result, best = arg, x
+				}
+			}
+			return result
+		},
+	}
+}
+
+func (j *MathModule) Abs() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			if n, ok := args[0].(*object.Int); ok {
+				if n.Value == math.MinInt64 {
+					return object.NewErrorWithMsg("math.abs(%d) overflows an 'Int'", n.Value)
+				}
+				if n.Value < 0 {
+					return object.NewInt(-n.Value)
+				}
+				return n
+			}
+			x, err := number("math.abs", args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewFloat(math.Abs(x))
+		},
+	}
+}
+
+// Round rounds a number to the given number of decimal places, 0 by default. The
+// places can be negative, e.g. round(1234, -2) is 1200.
+func (j *MathModule) Round() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			x, err := number("math.round", args[0])
+			if err != nil {
+				return err
+			}
+			var places int64
+			if len(args) == 2 {
+				p, ok := args[1].(*object.Int)
+				if !ok {
+					return object.NewErrorWithMsg("expected math.round to receive argument of type 'Int', got %T", args[1])
+				}
+				places = p.Value
+			}
+
+			if n, ok := args[0].(*object.Int); ok {
+				if places >= 0 {
+					return n
+				}
+				rounded, ok := roundInt(n.Value, -places)
+				if !ok {
+					return object.NewErrorWithMsg("math.round(%d, %d) overflows an 'Int'", n.Value, places)
+				}
+				return object.NewInt(rounded)
+			}
+
+			var rounded float64
+			if places >= 0 {
+				scale := math.Pow(10, float64(places))
+				if scaled := x * scale; math.IsInf(scaled, 0) || math.IsNaN(scaled) {
+					rounded = x // the places are beyond the precision of x
+				} else {
+					rounded = math.Round(scaled) / scale
+				}
+			} else {
+				scale := math.Pow(10, float64(-places))
+				if !math.IsInf(scale, 0) {
+					rounded = math.Round(x/scale) * scale
+				}
+			}
+			return object.NewFloat(rounded)
+		},
+	}
+}
+
+// Log returns the natural logarithm of a number, or its logarithm in the base given
+// as second argument
+func (j *MathModule) Log() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			x, err := number("math.log", args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return object.NewFloat(math.Log(x))
+			}
+			base, err := number("math.log", args[1])
+			if err != nil {
+				return err
+			}
+			return object.NewFloat(math.Log(x) / math.Log(base))
+		},
+	}
+}
+
+// Clamp limits a number to the range [min, max]
+func (j *MathModule) Clamp() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return object.CountArgumentError("3", len(args))
+			}
+			var bounds [3]float64
+			for i, arg := range args {
+				x, err := number("math.clamp", arg)
+				if err != nil {
+					return err
+				}
+				bounds[i] = x
+			}
+			x, lo, hi := bounds[0], bounds[1], bounds[2]
+			switch {
+			case lo > hi:
+				return object.NewErrorWithMsg("expected the minimum of math.clamp to be at most its maximum, got %s and %s", args[1].Inspect(), args[2].Inspect())
+			case x < lo:
+				return args[1]
+			case x > hi:
+				return args[2]
+			}
+			return args[0]
+		},
+	}
+}
+
+// roundInt rounds n to a multiple of 10^digits, half away from zero like
+// math.Round. It returns false if the result overflows an Int.
+func roundInt(n, digits int64) (int64, bool) {
+	const half19 = 5_000_000_000_000_000_000 // half of 10^19, the first power beyond an Int
+	switch {
+	case digits > 19:
+		return 0, true
+	case digits == 19:
+		return 0, n < half19 && n > -half19
+	}
+	scale := int64(1)
+	for i := int64(0); i < digits; i++ {
+		scale *= 10
+	}
+	q, r := n/scale, n%scale
+	switch {
+	case r > 0 && r >= scale-r:
+		q++
+	case r < 0 && -r >= scale+r:
+		q--
+	}
+	if q > math.MaxInt64/scale || q < math.MinInt64/scale {
+		return 0, false
+	}
+	return q * scale, true
+}
+
+func gcd(a, b int64) (int64, bool) {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		a = -a
+	}
+	return a, a >= 0 // the gcd of math.MinInt64 and 0 has no positive Int
+}
+
+func lcm(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	g, ok := gcd(a, b)
+	if !ok {
+		return 0, false
+	}
+	l := a / g * b
+	if l/b != a/g {
+		return 0, false
+	}
+	if l < 0 {
+		l = -l
+	}
+	return l, l >= 0
+}
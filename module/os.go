@@ -0,0 +1,62 @@
+package module
+
+import (
+	"ede/object"
+)
+
+type OSModule struct {
+	// Args are the arguments passed to the script, starting with the script name
+	Args []string
+
+	functions   map[string]*object.Builtin
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *OSModule) Name() string { return "os" }
+
+func (j *OSModule) Functions() map[string]*object.Builtin { return j.functions }
+
+func (j *OSModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"args": j.ArgsFn(),
+		"exit": j.Exit(),
+	}
+}
+
+func (j *OSModule) ArgsFn() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.CountArgumentError("0", len(args))
+			}
+			entries := make([]object.Object, len(j.Args))
+			for i, arg := range j.Args {
+				entries[i] = object.NewString(arg)
+			}
+			return &object.Array{Entries: &entries}
+		},
+	}
+}
+
+// Exit stops the program with the given exit code, which defaults to 0
+func (j *OSModule) Exit() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.CountArgumentError("0 or 1", len(args))
+			}
+			code := int64(0)
+			if len(args) == 1 {
+				codeObj, ok := args[0].(*object.Int)
+				if !ok {
+					return object.NewErrorWithMsg("expected os.exit to receive argument of type 'Int', got %T", args[0])
+				}
+				code = codeObj.Value
+			}
+			return &object.Exit{Code: int(code)}
+		},
+	}
+}
@@ -0,0 +1,164 @@
+package module
+
+import (
+	"ede/object"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathModule manipulates the paths of files with the separator of the system
+type PathModule struct {
+	// File is the path of the script run, empty if the program is not read from a
+	// file
+	File string
+
+	functions   map[string]*object.Builtin
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *PathModule) Name() string { return "path" }
+
+func (j *PathModule) Functions() map[string]*object.Builtin { return j.functions }
+
+func (j *PathModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.functions = map[string]*object.Builtin{
+		"join":       j.Join(),
+		"base":       j.unary("base", filepath.Base),
+		"dir":        j.unary("dir", filepath.Dir),
+		"ext":        j.unary("ext", filepath.Ext),
+		"clean":      j.unary("clean", filepath.Clean),
+		"stem":       j.unary("stem", stem),
+		"abs":        j.Abs(),
+		"rel":        j.Rel(),
+		"split":      j.Split(),
+		"match":      j.Match(),
+		"script_dir": j.ScriptDir(),
+	}
+}
+
+// stem returns the last element of the path without its extension
+func stem(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// unary returns the function applying f to its path argument
+func (j *PathModule) unary(name string, f func(string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("path."+name, args, 1)
+			if err != nil {
+				return err
+			}
+			return object.NewString(f(p[0]))
+		},
+	}
+}
+
+// Join joins any number of elements into a cleaned path, ignoring the empty ones
+func (j *PathModule) Join() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			elems, err := stringArgs("path.join", args, len(args))
+			if err != nil {
+				return err
+			}
+			return object.NewString(filepath.Join(elems...))
+		},
+	}
+}
+
+// Abs returns the absolute path of a path, relative to the working directory
+func (j *PathModule) Abs() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("path.abs", args, 1)
+			if err != nil {
+				return err
+			}
+			abs, absErr := filepath.Abs(p[0])
+			if absErr != nil {
+				return object.NewErrorWithMsg("error making path %q absolute: %s", p[0], absErr)
+			}
+			return object.NewString(abs)
+		},
+	}
+}
+
+// Rel returns the path of the target relative to the base, e.g. rel("/a", "/a/b/c")
+// is "b/c"
+func (j *PathModule) Rel() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("path.rel", args, 2)
+			if err != nil {
+				return err
+			}
+			rel, relErr := filepath.Rel(p[0], p[1])
+			if relErr != nil {
+				return object.NewErrorWithMsg("%s", relErr)
+			}
+			return object.NewString(rel)
+		},
+	}
+}
+
+// Split returns the directory, with its trailing separator, and the file name of
+// a path, as an array
+func (j *PathModule) Split() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("path.split", args, 1)
+			if err != nil {
+				return err
+			}
+			dir, file := filepath.Split(p[0])
+			return &object.Array{Entries: &[]object.Object{object.NewString(dir), object.NewString(file)}}
+		},
+	}
+}
+
+// Match reports whether a name matches a shell pattern, e.g. "*.ede"
+func (j *PathModule) Match() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			p, err := stringArgs("path.match", args, 2)
+			if err != nil {
+				return err
+			}
+			matched, matchErr := filepath.Match(p[0], p[1])
+			if matchErr != nil {
+				return object.NewErrorWithMsg("invalid pattern %q for path.match: %s", p[0], matchErr)
+			}
+			return object.NewBoolean(matched)
+		},
+	}
+}
+
+// ScriptDir returns the absolute path of the directory of the script run, or the
+// working directory if the program is not read from a file
+func (j *PathModule) ScriptDir() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.CountArgumentError("0", len(args))
+			}
+			if j.File == "" {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fsError(err)
+				}
+				return object.NewString(wd)
+			}
+			abs, err := filepath.Abs(j.File)
+			if err != nil {
+				return object.NewErrorWithMsg("error making path %q absolute: %s", j.File, err)
+			}
+			return object.NewString(filepath.Dir(abs))
+		},
+	}
+}
@@ -0,0 +1,283 @@
+package module
+
+import (
+	crand "crypto/rand"
+	"ede/object"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+// RandomModule generates pseudo-random values from a source of its own, which
+// random.seed makes reproducible. The crypto_int and bytes functions use
+// crypto/rand instead, and are not affected by the seed.
+type RandomModule struct {
+	functions   map[string]*object.Builtin
+	source      *rand.Rand
+	environment *object.Environment
+	evaluator   object.Evaluator
+}
+
+func (j *RandomModule) Name() string { return "random" }
+
+func (j *RandomModule) Functions() map[string]*object.Builtin { return j.functions }
+
+func (j *RandomModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	j.evaluator = evaluator
+	j.environment = env
+	j.source = rand.New(rand.NewSource(time.Now().UnixNano()))
+	j.functions = map[string]*object.Builtin{
+		"seed":       j.Seed(),
+		"int":        j.Int(),
+		"float":      j.Float(),
+		"bool":       j.Bool(),
+		"normal":     j.Normal(),
+		"choice":     j.Choice(),
+		"shuffle":    j.Shuffle(),
+		"sample":     j.Sample(),
+		"crypto_int": j.CryptoInt(),
+		"bytes":      j.Bytes(),
+	}
+}
+
+// bounds returns the Int arguments lo and hi of the function, with lo <= hi
+func bounds(fn string, args []object.Object) (int64, int64, *object.Error) {
+	if len(args) != 2 {
+		return 0, 0, object.CountArgumentError("2", len(args))
+	}
+	lo, ok := args[0].(*object.Int)
+	if !ok {
+		return 0, 0, object.NewErrorWithMsg("expected random.%s to receive argument of type 'Int', got %T", fn, args[0])
+	}
+	hi, ok := args[1].(*object.Int)
+	if !ok {
+		return 0, 0, object.NewErrorWithMsg("expected random.%s to receive argument of type 'Int', got %T", fn, args[1])
+	}
+	if lo.Value > hi.Value {
+		return 0, 0, object.NewErrorWithMsg("expected the lower bound of random.%s to be at most its upper bound, got %d and %d", fn, lo.Value, hi.Value)
+	}
+	return lo.Value, hi.Value, nil
+}
+
+// array returns the Array argument of the function
+func array(fn string, arg object.Object) ([]object.Object, *object.Error) {
+	arr, ok := arg.(*object.Array)
+	if !ok {
+		return nil, object.NewErrorWithMsg("expected random.%s to receive argument of type 'Array', got %T", fn, arg)
+	}
+	return *arr.Entries, nil
+}
+
+// Seed resets the source of the module, so that the values which follow are the
+// same on each run with the seed
+func (j *RandomModule) Seed() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			n, ok := args[0].(*object.Int)
+			if !ok {
+				return object.NewErrorWithMsg("expected random.seed to receive argument of type 'Int', got %T", args[0])
+			}
+			j.source = rand.New(rand.NewSource(n.Value))
+			return object.NIL
+		},
+	}
+}
+
+// Int returns an integer between lo and hi, both included
+func (j *RandomModule) Int() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			lo, hi, err := bounds("int", args)
+			if err != nil {
+				return err
+			}
+			var n uint64
+			switch span := uint64(hi) - uint64(lo); {
+			case span < math.MaxInt64:
+				n = uint64(j.source.Int63n(int64(span) + 1))
+			case span == math.MaxUint64:
+				n = j.source.Uint64()
+			default:
+				n = j.source.Uint64() % (span + 1)
+			}
+			return object.NewInt(lo + int64(n))
+		},
+	}
+}
+
+// Float returns a float in [0, 1)
+func (j *RandomModule) Float() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.CountArgumentError("0", len(args))
+			}
+			return object.NewFloat(j.source.Float64())
+		},
+	}
+}
+
+// Bool returns true with the probability p, 0.5 by default
+func (j *RandomModule) Bool() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.CountArgumentError("0 or 1", len(args))
+			}
+			p := 0.5
+			if len(args) == 1 {
+				x, err := number("random.bool", args[0])
+				if err != nil {
+					return err
+				}
+				if x < 0 || x > 1 {
+					return object.NewErrorWithMsg("expected the probability of random.bool to be between 0 and 1, got %s", args[0].Inspect())
+				}
+				p = x
+			}
+			return object.NewBoolean(j.source.Float64() < p)
+		},
+	}
+}
+
+// Normal returns a float of the normal distribution with the mean mu and the
+// standard deviation sigma, 0 and 1 by default
+func (j *RandomModule) Normal() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 && len(args) != 2 {
+				return object.CountArgumentError("0 or 2", len(args))
+			}
+			mu, sigma := 0.0, 1.0
+			if len(args) == 2 {
+				var err *object.Error
+				if mu, err = number("random.normal", args[0]); err != nil {
+					return err
+				}
+				if sigma, err = number("random.normal", args[1]); err != nil {
+					return err
+				}
+			}
+			return object.NewFloat(mu + sigma*j.source.NormFloat64())
+		},
+	}
+}
+
+// Choice returns an element of a non-empty array
+func (j *RandomModule) Choice() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			entries, err := array("choice", args[0])
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return object.NewErrorWithMsg("random.choice of an empty array")
+			}
+			return entries[j.source.Intn(len(entries))]
+		},
+	}
+}
+
+// Shuffle returns a copy of the array with its elements in a random order
+func (j *RandomModule) Shuffle() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			entries, err := array("shuffle", args[0])
+			if err != nil {
+				return err
+			}
+			shuffled := append([]object.Object{}, entries...)
+			j.source.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+			return &object.Array{Entries: &shuffled}
+		},
+	}
+}
+
+// Sample returns n elements of the array at distinct indexes, in a random order
+func (j *RandomModule) Sample() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.CountArgumentError("2", len(args))
+			}
+			entries, err := array("sample", args[0])
+			if err != nil {
+				return err
+			}
+			n, ok := args[1].(*object.Int)
+			if !ok {
+				return object.NewErrorWithMsg("expected random.sample to receive argument of type 'Int', got %T", args[1])
+			}
+			if n.Value < 0 || n.Value > int64(len(entries)) {
+				return object.NewErrorWithMsg("expected random.sample to take between 0 and %d elements, got %d", len(entries), n.Value)
+			}
+			sample := make([]object.Object, n.Value)
+			for i, idx := range j.source.Perm(len(entries))[:n.Value] {
+				sample[i] = entries[idx]
+			}
+			return &object.Array{Entries: &sample}
+		},
+	}
+}
+
+// CryptoInt returns an integer between lo and hi, both included, from the
+// cryptographically secure generator
+func (j *RandomModule) CryptoInt() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			lo, hi, err := bounds("crypto_int", args)
+			if err != nil {
+				return err
+			}
+			n := new(big.Int).Sub(big.NewInt(hi), big.NewInt(lo))
+			n, randErr := crand.Int(crand.Reader, n.Add(n, big.NewInt(1)))
+			if randErr != nil {
+				return object.NewErrorWithMsg("error generating random number: %s", randErr)
+			}
+			return object.NewInt(lo + n.Int64())
+		},
+	}
+}
+
+// maxRandomBytes is the most bytes random.bytes returns, each being an Int of the
+// array
+const maxRandomBytes = 1 << 20
+
+// Bytes returns an array of n random bytes, as integers between 0 and 255, from the
+// cryptographically secure generator, at most 1 MiB
+func (j *RandomModule) Bytes() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.CountArgumentError("1", len(args))
+			}
+			n, ok := args[0].(*object.Int)
+			if !ok || n.Value < 0 {
+				return object.NewErrorWithMsg("expected random.bytes to receive a non-negative 'Int', got %s", args[0].Inspect())
+			}
+			if n.Value > maxRandomBytes {
+				return object.NewErrorWithMsg("expected random.bytes to take at most %d bytes, got %d", maxRandomBytes, n.Value)
+			}
+			buf := make([]byte, n.Value)
+			if _, err := crand.Read(buf); err != nil {
+				return object.NewErrorWithMsg("error generating random bytes: %s", err)
+			}
+			entries := make([]object.Object, len(buf))
+			for i, b := range buf {
+				entries[i] = object.NewInt(int64(b))
+			}
+			return &object.Array{Entries: &entries}
+		},
+	}
+}
@@ -0,0 +1,244 @@
+package module
+
+import (
+	"ede/object"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TestingModule provides the assertions of the tests run by `ede test`. A failed
+// assertion returns an error, which ends the test.
+type TestingModule struct {
+	functions   map[string]*object.Builtin
+	environment *object.Environment
+	evaluator   object.Evaluator
+
+	// skipped is the error returned by skip, to tell a skipped test from a failed one
+	skipped *object.Error
+}
+
+func (t *TestingModule) Name() string { return "testing" }
+
+func (t *TestingModule) Functions() map[string]*object.Builtin { return t.functions }
+
+func (t *TestingModule) Init(evaluator object.Evaluator, env *object.Environment) {
+	t.evaluator = evaluator
+	t.environment = env
+	t.functions = map[string]*object.Builtin{
+		"assert":       t.Assert(),
+		"assert_eq":    t.AssertEq(),
+		"assert_error": t.AssertError(),
+		"skip":         t.Skip(),
+	}
+}
+
+// Skipped returns the reason the test was skipped, if the result of the test is
+// the error returned by skip
+func (t *TestingModule) Skipped(result object.Object) (string, bool) {
+	if t.skipped == nil || result != t.skipped {
+		return "", false
+	}
+	return strings.TrimPrefix(t.skipped.Message, "skipped: "), true
+}
+
+// Assert fails if the condition is false. An optional message describes the failure.
+func (t *TestingModule) Assert() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			if err, ok := args[0].(*object.Error); ok {
+				return err
+			}
+			if object.ToBoolean(args[0]) {
+				return object.NIL
+			}
+			return failure("assertion failed", args[1:])
+		},
+	}
+}
+
+// AssertEq fails if the values are not equal, describing the entries that differ
+// for arrays and hashes
+func (t *TestingModule) AssertEq() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.CountArgumentError("2 or 3", len(args))
+			}
+			if err, ok := args[0].(*object.Error); ok {
+				return err
+			}
+			got, want := args[0], args[1]
+			diffs := diff("", got, want)
+			if len(diffs) == 0 {
+				return object.NIL
+			}
+			msg := "assert_eq failed: " + diffs[0]
+			if isContainer(got) && isContainer(want) {
+				// the values can span several lines, the entries that differ are clearer
+				msg = "assert_eq failed, the values differ at:\n\t" + strings.Join(diffs, "\n\t")
+			}
+			return failure(msg, args[2:])
+		},
+	}
+}
+
+// AssertError fails if the value is not an error, or if its message does not
+// contain the optional substring
+func (t *TestingModule) AssertError() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.CountArgumentError("1 or 2", len(args))
+			}
+			err, ok := args[0].(*object.Error)
+			if !ok {
+				return failure(fmt.Sprintf("assert_error failed: expected an error, got %s", show(args[0])), nil)
+			}
+			if len(args) == 1 {
+				return object.NIL
+			}
+			substr, ok := args[1].(*object.String)
+			if !ok {
+				return object.NewErrorWithMsg("expected testing.assert_error to receive argument of type 'String', got %T", args[1])
+			}
+			if !strings.Contains(err.Message, substr.Value) {
+				msg := strings.TrimSpace(err.Message)
+				return failure(fmt.Sprintf("assert_error failed: expected an error containing %q, got %q", substr.Value, msg), nil)
+			}
+			return object.NIL
+		},
+	}
+}
+
+// Skip ends the test, reporting it as skipped with the optional reason
+func (t *TestingModule) Skip() *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.CountArgumentError("0 or 1", len(args))
+			}
+			reason := ""
+			if len(args) == 1 {
+				reason = display(args[0])
+			}
+			t.skipped = &object.Error{Message: "skipped: " + reason}
+			return t.skipped
+		},
+	}
+}
+
+// failure returns the error of a failed assertion, with the optional message
+// passed to the assertion
+func failure(msg string, extra []object.Object) *object.Error {
+	if len(extra) > 0 {
+		msg = display(extra[0]) + ": " + msg
+	}
+	return &object.Error{Message: msg}
+}
+
+// diff returns the differences between the values, one per entry of the arrays
+// and hashes that differs, e.g. "[1]: got 2, want 3". It is empty if the values
+// are equal.
+func diff(path string, got, want object.Object) []string {
+	gotArr, ok1 := got.(*object.Array)
+	wantArr, ok2 := want.(*object.Array)
+	if ok1 && ok2 {
+		var diffs []string
+		g, w := *gotArr.Entries, *wantArr.Entries
+		for i := 0; i < len(g) || i < len(w); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(w):
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", p, show(g[i])))
+			case i >= len(g):
+				diffs = append(diffs, fmt.Sprintf("%s: missing, want %s", p, show(w[i])))
+			default:
+				diffs = append(diffs, diff(p, g[i], w[i])...)
+			}
+		}
+		return diffs
+	}
+
+	gotHash, ok1 := got.(*object.Hash)
+	wantHash, ok2 := want.(*object.Hash)
+	if ok1 && ok2 {
+		keys := map[string]bool{}
+		for k := range gotHash.Entries {
+			keys[k] = true
+		}
+		for k := range wantHash.Entries {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, k := range sorted {
+			p := fmt.Sprintf("%s[%q]", path, k)
+			g, inGot := gotHash.Entries[k]
+			w, inWant := wantHash.Entries[k]
+			switch {
+			case !inWant:
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", p, show(g)))
+			case !inGot:
+				diffs = append(diffs, fmt.Sprintf("%s: missing, want %s", p, show(w)))
+			default:
+				diffs = append(diffs, diff(p, g, w)...)
+			}
+		}
+		return diffs
+	}
+
+	if equal(got, want) {
+		return nil
+	}
+	if path == "" {
+		return []string{fmt.Sprintf("got %s, want %s", show(got), show(want))}
+	}
+	return []string{fmt.Sprintf("%s: got %s, want %s", path, show(got), show(want))}
+}
+
+func equal(a, b object.Object) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	return a.Equal(b)
+}
+
+func isContainer(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		return true
+	}
+	return false
+}
+
+// show returns the representation of the value in a message, quoting strings
+func show(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *object.String:
+		return strconv.Quote(obj.Value)
+	}
+	return obj.Inspect()
+}
+
+// display returns the text of the value, without quotes
+func display(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+	return obj.Inspect()
+}
@@ -14,6 +14,9 @@ import (
 type Array struct{ Entries *[]Object }
 type Evaluator interface {
 	Eval(node ast.Node, env *Environment) Object
+	Streams() Streams
+	// Apply calls the function or builtin with the arguments
+	Apply(fn Object, args ...Object) Object
 }
 
 func (*Array) Type() Type { return ARRAY_OBJ }
@@ -30,6 +33,9 @@ func (v *Array) Inspect() string {
 }
 func (v *Array) Equal(obj Object) bool {
 	if obj, ok := obj.(*Array); ok {
+		if len(*v.Entries) != len(*obj.Entries) {
+			return false
+		}
 		for idx, o := range *v.Entries {
 			if !o.Equal((*obj.Entries)[idx]) {
 				return false
@@ -103,6 +109,48 @@ func (a *Array) GetMethod(name string, eval Evaluator) *Builtin {
 		return a.Clear()
 	case "set":
 		return a.Set()
+	case "reduce":
+		return a.Reduce(eval)
+	case "any":
+		return a.Any(eval)
+	case "all":
+		return a.All(eval)
+	case "count":
+		return a.Count(eval)
+	case "sum":
+		return a.Sum()
+	case "min":
+		return a.Extremum(eval, name, -1)
+	case "max":
+		return a.Extremum(eval, name, 1)
+	case "index_of":
+		return a.IndexOf()
+	case "insert":
+		return a.Insert()
+	case "remove_at":
+		return a.RemoveAt()
+	case "slice":
+		return a.Slice()
+	case "unique":
+		return a.Unique()
+	case "flatten":
+		return a.Flatten()
+	case "zip":
+		return a.Zip()
+	case "chunk":
+		return a.Chunk()
+	case "group_by":
+		return a.GroupBy(eval)
+	case "partition":
+		return a.Partition(eval)
+	case "take":
+		return a.Take(name)
+	case "drop":
+		return a.Take(name)
+	case "each":
+		return a.Each(eval)
+	case "copy":
+		return a.Copy()
 	}
 	return nil
 }
@@ -214,20 +262,17 @@ func (a *Array) Find(evaluator Evaluator) *Builtin {
 			if len(args) != 1 {
 				return CountArgumentError("1", len(args))
 			}
-			fn, ok := args[0].(*Function)
-			if !ok {
-				return methodExpectArgumentError("find", "function", string(args[0].Type()))
-			}
-			if len(fn.Params) != 1 {
-				NewError(fmt.Errorf("function should have 1 argument, got %d", len(fn.Params)))
+			if err := checkCallback("find", args[0]); err != nil {
+				return err
 			}
 
 			arrs := make([]Object, 0)
 			result := &Array{Entries: &arrs}
-			for _, el := range *a.Entries {
-				env := NewEnvironment(fn.ParentEnv)
-				env.Set(fn.Params[0].Value, el)
-				obj := evaluator.Eval(fn.Body, env)
+			for idx, el := range *a.Entries {
+				obj := callback(evaluator, args[0], idx, el)
+				if isError(obj) {
+					return obj
+				}
 				if boolVal := ToBoolean(obj); boolVal {
 					*result.Entries = append(*result.Entries, el)
 					return el
@@ -245,21 +290,18 @@ func (a *Array) Map(evaluator Evaluator) *Builtin {
 			if len(args) != 1 {
 				return CountArgumentError("1", len(args))
 			}
-			fn, ok := args[0].(*Function)
-			if !ok {
-				return methodExpectArgumentError("map", "function", string(args[0].Type()))
-			}
-
-			if len(fn.Params) != 1 {
-				NewError(fmt.Errorf("function should have 1 argument, got %d", len(fn.Params)))
+			if err := checkCallback("map", args[0]); err != nil {
+				return err
 			}
 
 			arrs := make([]Object, 0)
 			result := &Array{Entries: &arrs}
-			for _, el := range *a.Entries {
-				env := NewEnvironment(fn.ParentEnv)
-				env.Set(fn.Params[0].Value, el)
-				*result.Entries = append(*result.Entries, evaluator.Eval(fn.Body, env))
+			for idx, el := range *a.Entries {
+				obj := callback(evaluator, args[0], idx, el)
+				if isError(obj) {
+					return obj
+				}
+				*result.Entries = append(*result.Entries, obj)
 			}
 			*a.Entries = *result.Entries
 			return a
@@ -273,22 +315,24 @@ func (a *Array) Filter(evaluator Evaluator) *Builtin {
 			if len(args) != 1 {
 				return CountArgumentError("1", len(args))
 			}
-			fn, ok := args[0].(*Function)
-			if !ok {
-				return methodExpectArgumentError("filter", "function", string(args[0].Type()))
-			}
-
-			if len(fn.Params) != 1 {
-				NewError(fmt.Errorf("function should have 1 argument, got %d", len(fn.Params)))
+			if err := checkCallback("filter", args[0]); err != nil {
+				return err
 			}
 
 			arrs := make([]Object, 0)
 			result := &Array{Entries: &arrs}
 			for idx, el := range *a.Entries {
-				env := NewEnvironment(fn.ParentEnv)
-				env.Set(token.IndexIdentifier, &Int{Value: int64(idx)})
-				env.Set(fn.Params[0].Value, el)
-				obj := evaluator.Eval(fn.Body, env)
+				fn := args[0]
+				// the index is also bound to the index identifier in the body of the filter
+				if f, ok := fn.(*Function); ok {
+					env := NewEnvironment(f.ParentEnv)
+					env.Set(token.IndexIdentifier, &Int{Value: int64(idx)})
+					fn = &Function{Params: f.Params, Body: f.Body, ParentEnv: env}
+				}
+				obj := callback(evaluator, fn, idx, el)
+				if isError(obj) {
+					return obj
+				}
 				if boolVal := ToBoolean(obj); boolVal {
 					*result.Entries = append(*result.Entries, el)
 				}
@@ -0,0 +1,607 @@
+package object
+
+// checkCallback returns an error if the argument of the method is not a function
+func checkCallback(method string, fn Object) *Error {
+	switch fn.(type) {
+	case *Function, *Builtin:
+		return nil
+	}
+	return methodExpectArgumentError(method, "function", string(fn.Type()))
+}
+
+// callback calls the function with the arguments through the evaluator. A function
+// with a parameter more than the arguments also receives the index of the element.
+func callback(eval Evaluator, fn Object, index int, args ...Object) Object {
+	return applyCallback(eval, fn, args, NewInt(int64(index)))
+}
+
+// applyCallback calls the function with the arguments through the evaluator, so
+// that its errors propagate. A function also receives the optional arguments it has
+// parameters for, e.g. the index of an element.
+func applyCallback(eval Evaluator, fn Object, args []Object, optional ...Object) Object {
+	if fn, ok := fn.(*Function); ok && len(fn.Params) > len(args) {
+		n := len(fn.Params) - len(args)
+		if n > len(optional) {
+			n = len(optional)
+		}
+		args = append(append([]Object{}, args...), optional[:n]...)
+	}
+	return eval.Apply(fn, args...)
+}
+
+func isError(obj Object) bool {
+	_, ok := obj.(*Error)
+	return ok
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or greater than b. Only
+// numbers, and strings, can be compared.
+func compare(a, b Object) (int, bool) {
+	if a, ok := a.(*String); ok {
+		b, ok := b.(*String)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a.Value < b.Value:
+			return -1, true
+		case a.Value > b.Value:
+			return 1, true
+		}
+		return 0, true
+	}
+	x, ok := toFloat(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := toFloat(b)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+func toFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Int:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+// intArgs returns the Int arguments of the method
+func intArgs(method string, args []Object) ([]int, *Error) {
+	ints := make([]int, len(args))
+	for i := range args {
+		n, err := intArg(method, args, i)
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+func newArray(entries []Object) *Array {
+	return &Array{Entries: &entries}
+}
+
+// Reduce folds the elements with the function, called with the accumulated value
+// and each element. The initial value is the second argument, or the first element.
+func (a *Array) Reduce(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 && len(args) != 2 {
+				return CountArgumentError("1 or 2", len(args))
+			}
+			if err := checkCallback("reduce", args[0]); err != nil {
+				return err
+			}
+			entries, start := *a.Entries, 0
+			var acc Object
+			if len(args) == 2 {
+				acc = args[1]
+			} else if len(entries) == 0 {
+				return NewErrorWithMsg("method 'reduce' of an empty array requires an initial value")
+			} else {
+				acc, start = entries[0], 1
+			}
+			for idx := start; idx < len(entries); idx++ {
+				acc = callback(evaluator, args[0], idx, acc, entries[idx])
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	}
+}
+
+// test returns the truthiness of the element, or of the function called with it if
+// there is one
+func (a *Array) test(evaluator Evaluator, args []Object, idx int) (bool, Object) {
+	el := (*a.Entries)[idx]
+	if len(args) == 0 {
+		return ToBoolean(el), nil
+	}
+	obj := callback(evaluator, args[0], idx, el)
+	if isError(obj) {
+		return false, obj
+	}
+	return ToBoolean(obj), nil
+}
+
+// Any reports whether an element, or the function called with it, is truthy
+func (a *Array) Any(evaluator Evaluator) *Builtin {
+	return a.quantifier(evaluator, "any", true)
+}
+
+// All reports whether all the elements, or the function called with them, are truthy
+func (a *Array) All(evaluator Evaluator) *Builtin {
+	return a.quantifier(evaluator, "all", false)
+}
+
+// quantifier returns the method returning stop as soon as an element tests as stop
+func (a *Array) quantifier(evaluator Evaluator, method string, stop bool) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 1 {
+				return CountArgumentError("0 or 1", len(args))
+			}
+			if len(args) == 1 {
+				if err := checkCallback(method, args[0]); err != nil {
+					return err
+				}
+			}
+			for idx := range *a.Entries {
+				ok, err := a.test(evaluator, args, idx)
+				if err != nil {
+					return err
+				}
+				if ok == stop {
+					return NewBoolean(stop)
+				}
+			}
+			return NewBoolean(!stop)
+		},
+	}
+}
+
+// Count returns the number of elements for which the function is truthy, or which
+// are equal to the argument if it is not a function
+func (a *Array) Count(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			count := 0
+			isValue := checkCallback("count", args[0]) != nil
+			for idx, el := range *a.Entries {
+				if isValue {
+					if el.Equal(args[0]) {
+						count++
+					}
+					continue
+				}
+				ok, err := a.test(evaluator, args, idx)
+				if err != nil {
+					return err
+				}
+				if ok {
+					count++
+				}
+			}
+			return NewInt(int64(count))
+		},
+	}
+}
+
+// Sum returns the sum of the numbers of the array, a Float if one of them is
+func (a *Array) Sum() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			var ints int64
+			var floats float64
+			isFloat := false
+			for idx, el := range *a.Entries {
+				switch el := el.(type) {
+				case *Int:
+					ints += el.Value
+				case *Float:
+					floats += el.Value
+					isFloat = true
+				default:
+					return NewErrorWithMsg("cannot sum non-number item at index %d", idx)
+				}
+			}
+			if isFloat {
+				return NewFloat(float64(ints) + floats)
+			}
+			return NewInt(ints)
+		},
+	}
+}
+
+// Extremum returns the method returning the smallest element if sign is -1, or the
+// largest if it is 1, compared by the key function if there is one. It returns nil
+// for an empty array.
+func (a *Array) Extremum(evaluator Evaluator, method string, sign int) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 1 {
+				return CountArgumentError("0 or 1", len(args))
+			}
+			if len(args) == 1 {
+				if err := checkCallback(method, args[0]); err != nil {
+					return err
+				}
+			}
+			var result, best Object = NIL, nil
+			for idx, el := range *a.Entries {
+				key := el
+				if len(args) == 1 {
+					if key = callback(evaluator, args[0], idx, el); isError(key) {
+						return key
+					}
+				}
+				if best != nil {
+					cmp, ok := compare(key, best)
+					if !ok {
+						return NewErrorWithMsg("method '%s' cannot compare %s and %s", method, key.Inspect(), best.Inspect())
+					}
+					if cmp != sign {
+						continue
+					}
+				}
+				result, best = el, key
+			}
+			return result
+		},
+	}
+}
+
+// IndexOf returns the index of the first element equal to the argument, or -1
+func (a *Array) IndexOf() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			for idx, el := range *a.Entries {
+				if el.Equal(args[0]) {
+					return NewInt(int64(idx))
+				}
+			}
+			return NewInt(-1)
+		},
+	}
+}
+
+// Insert inserts the element at the index, from 0 to the length of the array
+func (a *Array) Insert() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 2 {
+				return CountArgumentError("2", len(args))
+			}
+			idx, err := intArg("insert", args, 0)
+			if err != nil {
+				return err
+			}
+			entries := *a.Entries
+			if idx < 0 || idx > len(entries) {
+				return NewErrorWithMsg("index %d out of range for insert in an array of length %d", idx, len(entries))
+			}
+			entries = append(entries, nil)
+			copy(entries[idx+1:], entries[idx:])
+			entries[idx] = args[1]
+			*a.Entries = entries
+			return a
+		},
+	}
+}
+
+// RemoveAt removes the element at the index, and returns it
+func (a *Array) RemoveAt() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			idx, err := intArg("remove_at", args, 0)
+			if err != nil {
+				return err
+			}
+			entries := *a.Entries
+			if idx < 0 || idx >= len(entries) {
+				return NewErrorWithMsg("index %d out of range for an array of length %d", idx, len(entries))
+			}
+			el := entries[idx]
+			*a.Entries = append(entries[:idx], entries[idx+1:]...)
+			return el
+		},
+	}
+}
+
+// Slice returns the elements from the start index to the end index, excluded, or to
+// the end of the array
+func (a *Array) Slice() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 && len(args) != 2 {
+				return CountArgumentError("1 or 2", len(args))
+			}
+			bounds, err := intArgs("slice", args)
+			if err != nil {
+				return err
+			}
+			entries := *a.Entries
+			start, end := bounds[0], len(entries)
+			if len(bounds) == 2 {
+				end = bounds[1]
+			}
+			if start < 0 || end > len(entries) || start > end {
+				return NewErrorWithMsg("slice [%d:%d] out of range for an array of length %d", start, end, len(entries))
+			}
+			return newArray(append([]Object{}, entries[start:end]...))
+		},
+	}
+}
+
+// Unique returns the elements without the ones equal to a previous element
+func (a *Array) Unique() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			unique := []Object{}
+			seen := map[HashKey]bool{}
+		entries:
+			for _, el := range *a.Entries {
+				if key := ToHashKey(el); key != EmptyHashKey {
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+				} else {
+					for _, u := range unique {
+						if u.Equal(el) {
+							continue entries
+						}
+					}
+				}
+				unique = append(unique, el)
+			}
+			return newArray(unique)
+		},
+	}
+}
+
+// Flatten returns the array with its nested arrays replaced by their elements, to
+// the depth given, 1 by default
+func (a *Array) Flatten() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 1 {
+				return CountArgumentError("0 or 1", len(args))
+			}
+			depth := 1
+			if len(args) == 1 {
+				var err *Error
+				if depth, err = intArg("flatten", args, 0); err != nil {
+					return err
+				}
+				if depth < 0 {
+					return NewErrorWithMsg("method 'flatten' expects a non-negative depth, got %d", depth)
+				}
+			}
+			return newArray(flatten(*a.Entries, depth, []Object{}))
+		},
+	}
+}
+
+func flatten(entries []Object, depth int, result []Object) []Object {
+	for _, el := range entries {
+		if arr, ok := el.(*Array); ok && depth > 0 {
+			result = flatten(*arr.Entries, depth-1, result)
+		} else {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// Zip returns the arrays of the elements at the same index in the array and the
+// array arguments, as long as the shortest of them
+func (a *Array) Zip() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) < 1 {
+				return CountArgumentError(">=1", len(args))
+			}
+			arrays := [][]Object{*a.Entries}
+			length := len(*a.Entries)
+			for _, arg := range args {
+				arr, ok := arg.(*Array)
+				if !ok {
+					return methodExpectArgumentError("zip", "array", string(arg.Type()))
+				}
+				arrays = append(arrays, *arr.Entries)
+				if len(*arr.Entries) < length {
+					length = len(*arr.Entries)
+				}
+			}
+			zipped := make([]Object, length)
+			for idx := range zipped {
+				tuple := make([]Object, len(arrays))
+				for i, arr := range arrays {
+					tuple[i] = arr[idx]
+				}
+				zipped[idx] = newArray(tuple)
+			}
+			return newArray(zipped)
+		},
+	}
+}
+
+// Chunk returns the elements in arrays of n elements, the last one being shorter
+// if the length is not a multiple of n
+func (a *Array) Chunk() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			n, err := intArg("chunk", args, 0)
+			if err != nil {
+				return err
+			}
+			if n <= 0 {
+				return NewErrorWithMsg("method 'chunk' expects a positive size, got %d", n)
+			}
+			entries := *a.Entries
+			chunks := []Object{}
+			for start := 0; start < len(entries); start += n {
+				end := start + n
+				if end > len(entries) {
+					end = len(entries)
+				}
+				chunks = append(chunks, newArray(append([]Object{}, entries[start:end]...)))
+			}
+			return newArray(chunks)
+		},
+	}
+}
+
+// GroupBy returns a hash of the arrays of the elements by the key the function
+// returns for them
+func (a *Array) GroupBy(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			if err := checkCallback("group_by", args[0]); err != nil {
+				return err
+			}
+			groups := map[string]Object{}
+			for idx, el := range *a.Entries {
+				obj := callback(evaluator, args[0], idx, el)
+				if isError(obj) {
+					return obj
+				}
+				key := ToHashKey(obj)
+				if key == EmptyHashKey {
+					return NewErrorWithMsg("cannot group by non-hashable key %s", obj.Inspect())
+				}
+				group, ok := groups[key.Value].(*Array)
+				if !ok {
+					group = newArray(nil)
+					groups[key.Value] = group
+				}
+				*group.Entries = append(*group.Entries, el)
+			}
+			return &Hash{Entries: groups}
+		},
+	}
+}
+
+// Partition returns the array of the elements for which the function is truthy,
+// and the array of the others
+func (a *Array) Partition(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			if err := checkCallback("partition", args[0]); err != nil {
+				return err
+			}
+			pass, fail := []Object{}, []Object{}
+			for idx, el := range *a.Entries {
+				ok, err := a.test(evaluator, args, idx)
+				if err != nil {
+					return err
+				}
+				if ok {
+					pass = append(pass, el)
+				} else {
+					fail = append(fail, el)
+				}
+			}
+			return newArray([]Object{newArray(pass), newArray(fail)})
+		},
+	}
+}
+
+// Take returns the method returning the first n elements for take, or the elements
+// after them for drop
+func (a *Array) Take(method string) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			n, err := intArg(method, args, 0)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return NewErrorWithMsg("method '%s' expects a non-negative count, got %d", method, n)
+			}
+			entries := *a.Entries
+			if n > len(entries) {
+				n = len(entries)
+			}
+			if method == "drop" {
+				return newArray(append([]Object{}, entries[n:]...))
+			}
+			return newArray(append([]Object{}, entries[:n]...))
+		},
+	}
+}
+
+// Each calls the function with each element
+func (a *Array) Each(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			if err := checkCallback("each", args[0]); err != nil {
+				return err
+			}
+			for idx, el := range *a.Entries {
+				if obj := callback(evaluator, args[0], idx, el); isError(obj) {
+					return obj
+				}
+			}
+			return NIL
+		},
+	}
+}
+
+// Copy returns a shallow copy of the array
+func (a *Array) Copy() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			return newArray(append([]Object{}, *a.Entries...))
+		},
+	}
+}
@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 func NewEnvironment(outer *Environment) *Environment {
 	return &Environment{store: make(map[string]Object), outer: outer}
 }
@@ -37,3 +39,19 @@ func (e *Environment) Update(key string, val Object) bool {
 func (e *Environment) Set(key string, value Object) {
 	e.store[key] = value
 }
+
+// Outer returns the enclosing environment, or nil for the outermost one
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names returns the sorted names set in the environment, without those of the
+// enclosing environments
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
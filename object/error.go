@@ -13,3 +13,23 @@ func NewErrorWithMsg(msg string, format ...any) *Error {
 func NewError(msg error) *Error {
 	return NewErrorWithMsg(msg.Error())
 }
+
+// ErrorKind is the kind of the errors of a module, e.g. fs.not_found. It is equal
+// to the errors of its kind, so that a match can handle them.
+type ErrorKind struct{ Name string }
+
+var _ Object = (*ErrorKind)(nil)
+
+func (*ErrorKind) Type() Type        { return ERROR_KIND_OBJ }
+func (v *ErrorKind) Inspect() string { return v.Name }
+func (v *ErrorKind) Equal(obj Object) bool {
+	switch obj := obj.(type) {
+	case *ErrorKind:
+		return obj.Name == v.Name
+	case *Error:
+		return obj.Kind == v.Name
+	}
+	return false
+}
+
+func (v *ErrorKind) Native() any { return v.Name }
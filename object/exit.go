@@ -0,0 +1,22 @@
+package object
+
+import "fmt"
+
+// Exit is returned by builtins to request that the program stops with
+// the given exit code. It also implements error, so hosts can return it as is.
+type Exit struct{ Code int }
+
+var _ Object = (*Exit)(nil)
+
+func (*Exit) Type() Type        { return EXIT_OBJ }
+func (v *Exit) Inspect() string { return v.Error() }
+func (v *Exit) Equal(obj Object) bool {
+	if obj, ok := obj.(*Exit); ok {
+		return obj.Code == v.Code
+	}
+	return false
+}
+
+func (v *Exit) Native() any { return v.Code }
+
+func (v *Exit) Error() string { return fmt.Sprintf("exit status %d", v.Code) }
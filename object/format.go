@@ -0,0 +1,143 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format formats the arguments according to the format string, in the style of fmt.Sprintf.
+// Each verb is checked against the type of its argument, so that a mismatch is reported
+// as an error instead of Go's %!d(...) placeholders.
+//
+// Supported verbs are %v (any value), %s (strings, or the inspected value of any other object),
+// %q (strings), %d, %b, %o, %c (ints), %x, %X (ints and strings), %f, %F, %e, %E, %g, %G (ints and floats),
+// %t (booleans) and %% (a literal percent sign). The flags '-', '+', '#', ' ' and '0', as well
+// as width and precision are passed through to fmt.
+//
+// String literals are not unescaped by the lexer, so the escape sequences \n, \t, \\ and \"
+// are expanded in the format string.
+func Format(format string, args []Object) (string, *Error) {
+	buf := new(strings.Builder)
+	argIdx := 0
+	for i := 0; i < len(format); i++ {
+		char := format[i]
+		if char == '\\' && i+1 < len(format) {
+			if escaped, ok := escapes[format[i+1]]; ok {
+				buf.WriteByte(escaped)
+				i++
+				continue
+			}
+		}
+		if char != '%' {
+			buf.WriteByte(char)
+			continue
+		}
+
+		// read the directive, i.e. %[flags][width][.precision]verb
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+# 0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && isDigit(format[i]) {
+			i++
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			for i < len(format) && isDigit(format[i]) {
+				i++
+			}
+		}
+		if i >= len(format) {
+			return "", NewErrorWithMsg("format %q ends with an incomplete verb %q", format, format[start:])
+		}
+
+		verb := format[i]
+		spec := format[start : i+1]
+		if verb == '%' {
+			buf.WriteByte('%')
+			continue
+		}
+		if argIdx >= len(args) {
+			return "", NewErrorWithMsg("missing argument for verb %s in format %q", spec, format)
+		}
+		value, err := formatValue(spec, verb, args[argIdx])
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(fmt.Sprintf(spec, value))
+		argIdx++
+	}
+
+	if argIdx < len(args) {
+		return "", NewErrorWithMsg("too many arguments for format %q, expected %d, got %d", format, argIdx, len(args))
+	}
+	return buf.String(), nil
+}
+
+// formatValue returns the native value of the object to be formatted with the verb
+func formatValue(spec string, verb byte, arg Object) (any, *Error) {
+	if arg == nil {
+		arg = NIL
+	}
+	switch verb {
+	case 'v':
+		return arg.Inspect(), nil
+	case 's':
+		if str, ok := arg.(*String); ok {
+			return str.Value, nil
+		}
+		return arg.Inspect(), nil
+	case 'q':
+		if str, ok := arg.(*String); ok {
+			return str.Value, nil
+		}
+	case 'd', 'b', 'o', 'c':
+		if num, ok := arg.(*Int); ok {
+			return num.Value, nil
+		}
+	case 'x', 'X':
+		switch arg := arg.(type) {
+		case *Int:
+			return arg.Value, nil
+		case *String:
+			return arg.Value, nil
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		switch arg := arg.(type) {
+		case *Float:
+			return arg.Value, nil
+		case *Int:
+			return float64(arg.Value), nil
+		}
+	case 't':
+		if boolean, ok := arg.(*Boolean); ok {
+			return boolean.Value, nil
+		}
+	default:
+		return nil, NewErrorWithMsg("unknown verb %s", spec)
+	}
+	return nil, NewErrorWithMsg("verb %s expects %s argument, got %s", spec, verbArgType(verb), arg.Type())
+}
+
+func verbArgType(verb byte) string {
+	switch verb {
+	case 'q':
+		return "a STRING"
+	case 'd', 'b', 'o', 'c':
+		return "an INT"
+	case 'x', 'X':
+		return "an INT or STRING"
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		return "an INT or FLOAT"
+	case 't':
+		return "a BOOLEAN"
+	}
+	return "a supported"
+}
+
+var escapes = map[byte]byte{'n': '\n', 't': '\t', '\\': '\\', '"': '"'}
+
+func isDigit(char byte) bool {
+	return '0' <= char && char <= '9'
+}
@@ -3,6 +3,8 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/samber/lo"
@@ -25,6 +27,9 @@ func (v *Hash) Inspect() string {
 
 func (v *Hash) Equal(obj Object) bool {
 	if obj, ok := obj.(*Hash); ok {
+		if len(v.Entries) != len(obj.Entries) {
+			return false
+		}
 		for key, self := range v.Entries {
 			entry, found := obj.Entries[key]
 			if !found {
@@ -77,10 +82,48 @@ func (a *Hash) GetMethod(name string, eval Evaluator) *Builtin {
 		return a.Get()
 	case "set":
 		return a.Set()
+	case "delete":
+		return a.Delete()
+	case "values":
+		return a.Values()
+	case "length":
+		return a.Length()
+	case "merge":
+		return a.Merge(name, false)
+	case "deep_merge":
+		return a.Merge(name, true)
+	case "map_values":
+		return a.MapValues(eval)
+	case "filter":
+		return a.Filter(eval)
+	case "pick":
+		return a.Pick(name, true)
+	case "omit":
+		return a.Pick(name, false)
+	case "invert":
+		return a.Invert()
+	case "to_array":
+		return a.ToArray()
 	}
 	return nil
 }
 
+// sortedKeys returns the keys of the hash in order, so that the methods returning
+// arrays are deterministic
+func (a *Hash) sortedKeys() []string {
+	keys := lo.Keys(a.Entries)
+	sort.Strings(keys)
+	return keys
+}
+
+func (a *Hash) copy() *Hash {
+	entries := make(map[string]Object, len(a.Entries))
+	for key, value := range a.Entries {
+		entries[key] = value
+	}
+	return &Hash{Entries: entries}
+}
+
 func (a *Hash) Contains() *Builtin {
 	return &Builtin{
 		Fn: func(args ...Object) Object {
@@ -100,21 +143,59 @@ func (a *Hash) Contains() *Builtin {
 	}
 }
 
+// Get returns the value of the key, or the default value, nil if there is none. The
+// key can be a path in nested hashes and arrays, either dotted as "a.b.0" or an
+// array as ["a", "b", 0]; a key holding a dot is found as is first.
 func (a *Hash) Get() *Builtin {
 	return &Builtin{
 		Fn: func(args ...Object) Object {
-			if len(args) != 1 {
-				return CountArgumentError("1", len(args))
+			if len(args) != 1 && len(args) != 2 {
+				return CountArgumentError("1 or 2", len(args))
 			}
-			key := ToRawValue(args[0])
-			if key == "" {
-				return invalidKeyError(key)
+			def := Object(NIL)
+			if len(args) == 2 {
+				def = args[1]
 			}
-			entry, ok := a.Entries[key]
-			if !ok {
-				return NIL
+
+			var path []Object
+			switch key := args[0].(type) {
+			case *Array:
+				path = *key.Entries
+			case *String:
+				if entry, ok := a.Entries[key.Value]; ok {
+					return entry
+				}
+				for _, part := range strings.Split(key.Value, ".") {
+					path = append(path, NewString(part))
+				}
+			default:
+				path = []Object{key}
 			}
-			return entry
+
+			var current Object = a
+			for _, part := range path {
+				key := ToRawValue(part)
+				if key == "" {
+					return invalidKeyError(part.Inspect())
+				}
+				switch obj := current.(type) {
+				case *Hash:
+					entry, ok := obj.Entries[key]
+					if !ok {
+						return def
+					}
+					current = entry
+				case *Array:
+					idx, err := strconv.Atoi(key)
+					if err != nil || idx < 0 || idx >= len(*obj.Entries) {
+						return def
+					}
+					current = (*obj.Entries)[idx]
+				default:
+					return def
+				}
+			}
+			return current
 		},
 	}
 }
@@ -175,3 +256,197 @@ func (a *Hash) Clear() *Builtin {
 		},
 	}
 }
+
+// Delete removes the key from the hash, and returns its value, or nil
+func (a *Hash) Delete() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			key := ToRawValue(args[0])
+			if key == "" {
+				return invalidKeyError(key)
+			}
+			entry, ok := a.Entries[key]
+			if !ok {
+				return NIL
+			}
+			delete(a.Entries, key)
+			return entry
+		},
+	}
+}
+
+// Values returns the values of the hash, in the order of their keys
+func (a *Hash) Values() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 0 {
+				return CountArgumentError("0", len(args))
+			}
+			values := lo.Map(a.sortedKeys(), func(key string, i int) Object { return a.Entries[key] })
+			return &Array{Entries: &values}
+		},
+	}
+}
+
+func (a *Hash) Length() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 0 {
+				return CountArgumentError("0", len(args))
+			}
+			return NewInt(int64(len(a.Entries)))
+		},
+	}
+}
+
+// Merge returns a new hash with the entries of the hash and of the hash arguments,
+// the last one winning for the same key. The nested hashes are merged too if deep is
+// true. The hash is not changed.
+func (a *Hash) Merge(method string, deep bool) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) < 1 {
+				return CountArgumentError(">=1", len(args))
+			}
+			result := a.copy()
+			for _, arg := range args {
+				other, ok := arg.(*Hash)
+				if !ok {
+					return methodExpectArgumentError(method, "hash", string(arg.Type()))
+				}
+				merge(result, other, deep)
+			}
+			return result
+		},
+	}
+}
+
+func merge(dst, src *Hash, deep bool) {
+	for key, value := range src.Entries {
+		dstHash, isHash := dst.Entries[key].(*Hash)
+		if srcHash, ok := value.(*Hash); ok && isHash && deep {
+			merged := dstHash.copy()
+			merge(merged, srcHash, deep)
+			value = merged
+		}
+		dst.Entries[key] = value
+	}
+}
+
+// MapValues returns a new hash with the values returned by the function, called with
+// each value, and its key as an optional second parameter
+func (a *Hash) MapValues(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			if err := checkCallback("map_values", args[0]); err != nil {
+				return err
+			}
+			result := &Hash{Entries: make(map[string]Object, len(a.Entries))}
+			for _, key := range a.sortedKeys() {
+				obj := applyCallback(evaluator, args[0], []Object{a.Entries[key]}, NewString(key))
+				if isError(obj) {
+					return obj
+				}
+				result.Entries[key] = obj
+			}
+			return result
+		},
+	}
+}
+
+// Filter returns a new hash with the entries for which the function, called with
+// the key and the value, is truthy
+func (a *Hash) Filter(evaluator Evaluator) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			if err := checkCallback("filter", args[0]); err != nil {
+				return err
+			}
+			result := &Hash{Entries: make(map[string]Object)}
+			for _, key := range a.sortedKeys() {
+				obj := applyCallback(evaluator, args[0], []Object{NewString(key), a.Entries[key]})
+				if isError(obj) {
+					return obj
+				}
+				if ToBoolean(obj) {
+					result.Entries[key] = a.Entries[key]
+				}
+			}
+			return result
+		},
+	}
+}
+
+// Pick returns a new hash with only the keys given, if keep is true, or without
+// them. The keys are the arguments, or the elements of an array argument.
+func (a *Hash) Pick(method string, keep bool) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) == 1 {
+				if arr, ok := args[0].(*Array); ok {
+					args = *arr.Entries
+				}
+			}
+			keys := make(map[string]bool, len(args))
+			for _, arg := range args {
+				key := ToRawValue(arg)
+				if key == "" {
+					return invalidKeyError(arg.Inspect())
+				}
+				keys[key] = true
+			}
+			result := &Hash{Entries: make(map[string]Object)}
+			for key, value := range a.Entries {
+				if keys[key] == keep {
+					result.Entries[key] = value
+				}
+			}
+			return result
+		},
+	}
+}
+
+// Invert returns a new hash with the keys and the values swapped. The values must be
+// valid keys.
+func (a *Hash) Invert() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 0 {
+				return CountArgumentError("0", len(args))
+			}
+			result := &Hash{Entries: make(map[string]Object, len(a.Entries))}
+			for _, key := range a.sortedKeys() {
+				value := ToRawValue(a.Entries[key])
+				if value == "" {
+					return NewErrorWithMsg("cannot invert non-hashable value %s", a.Entries[key].Inspect())
+				}
+				result.Entries[value] = NewString(key)
+			}
+			return result
+		},
+	}
+}
+
+// ToArray returns the [key, value] pairs of the hash, in the order of their keys
+func (a *Hash) ToArray() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 0 {
+				return CountArgumentError("0", len(args))
+			}
+			pairs := lo.Map(a.sortedKeys(), func(key string, i int) Object {
+				return newArray([]Object{NewString(key), a.Entries[key]})
+			})
+			return &Array{Entries: &pairs}
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package object
+
+import "fmt"
+
+// Iterator produces its items one at a time, e.g. the rows of a file, so that a
+// for loop can range over them without loading them all. Next returns false when
+// there are no more items; an *Error item stops the loop with the error. Close,
+// when not nil, is called once the loop is over, even if it stopped early; a later
+// loop over the iterator calls Next again, after Close.
+type Iterator struct {
+	Name  string
+	Next  func() (Object, bool)
+	Close func()
+}
+
+var _ Object = (*Iterator)(nil)
+
+func (*Iterator) Type() Type        { return ITERATOR_OBJ }
+func (v *Iterator) Inspect() string { return fmt.Sprintf("<iterator %s>", v.Name) }
+func (v *Iterator) Equal(obj Object) bool {
+	return v == obj
+}
+
+func (v *Iterator) Native() any { return nil }
@@ -0,0 +1,16 @@
+package object
+
+// MethodNames are the names of the methods of each type, as handled by their GetMethod.
+// They are used by tools, e.g. for completion, and must be kept in sync with GetMethod.
+var MethodNames = map[Type][]string{
+	STRING_OBJ: {"split", "reverse", "replace", "length", "upper", "lower", "title", "trim", "trim_left", "trim_right", "starts_with", "ends_with", "contains", "index_of", "last_index_of", "count", "repeat", "pad_left", "pad_right", "lines", "chars", "fields", "substring", "to_int", "to_float", "format"},
+	INT_OBJ:    {"float", "string"},
+	FLOAT_OBJ:  {"int", "string"},
+	ARRAY_OBJ:  {"push", "pop", "first", "last", "length", "reverse", "map", "merge", "filter", "contains", "find", "join", "clear", "set", "reduce", "any", "all", "count", "sum", "min", "max", "index_of", "insert", "remove_at", "slice", "unique", "flatten", "zip", "chunk", "group_by", "partition", "take", "drop", "each", "copy"},
+	HASH_OBJ:   {"contains", "keys", "items", "clear", "get", "set", "delete", "values", "length", "merge", "deep_merge", "map_values", "filter", "pick", "omit", "invert", "to_array"},
+	SET_OBJ:    {"add", "delete", "contains", "items", "length", "clear"},
+	TIME_OBJ:   {"string", "sub"},
+}
+
+// CommonMethods are the methods every object has
+var CommonMethods = []string{"equal", "type"}
@@ -6,6 +6,27 @@ type Module interface {
 	Functions() map[string]*Builtin
 }
 
+// ValueModule is a module which also exports values other than functions, e.g.
+// the constants of math, read as module.name
+type ValueModule interface {
+	Module
+	Values() map[string]Object
+}
+
+// MemberNames returns the names of the functions and values of the module
+func MemberNames(mod Module) []string {
+	var names []string
+	for name := range mod.Functions() {
+		names = append(names, name)
+	}
+	if mod, ok := mod.(ValueModule); ok {
+		for name := range mod.Values() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 type Import struct {
 	Module    Module
 	Evaluator Evaluator
@@ -20,6 +41,16 @@ func (a *Import) GetMethod(name string, eval Evaluator) *Builtin {
 	return a.Module.Functions()[name]
 }
 
+// GetValue returns the value exported by the module with the name, if any
+func (a *Import) GetValue(name string) (Object, bool) {
+	mod, ok := a.Module.(ValueModule)
+	if !ok {
+		return nil, false
+	}
+	value, ok := mod.Values()[name]
+	return value, ok
+}
+
 func (*Import) Type() Type        { return IMPORT_OBJ }
 func (v *Import) Inspect() string { return v.Module.Name() }
 func (v *Import) Equal(obj Object) bool {
@@ -22,6 +22,11 @@ var (
 	SET_OBJ          Type = "SET"
 	IMPORT_OBJ       Type = "IMPORT"
 	TIME_OBJ         Type = "TIME"
+	EXIT_OBJ         Type = "EXIT"
+	QUOTE_OBJ        Type = "QUOTE"
+	MACRO_OBJ        Type = "MACRO"
+	ITERATOR_OBJ     Type = "ITERATOR"
+	ERROR_KIND_OBJ   Type = "ERROR_KIND"
 
 	NIL   = &Nil{}
 	TRUE  = NewBoolean(true)
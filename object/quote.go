@@ -0,0 +1,43 @@
+package object
+
+import (
+	"ede/ast"
+	"strings"
+)
+
+// Quote is the unevaluated code returned by quote(...)
+type Quote struct{ Node ast.Node }
+
+var _ Object = (*Quote)(nil)
+
+func (*Quote) Type() Type        { return QUOTE_OBJ }
+func (v *Quote) Inspect() string { return "quote(" + v.Node.String() + ")" }
+func (v *Quote) Equal(obj Object) bool {
+	if obj, ok := obj.(*Quote); ok {
+		return obj.Node.String() == v.Node.String()
+	}
+	return false
+}
+
+func (v *Quote) Native() any { return v.Node.String() }
+
+// Macro is a macro defined with macro(params) { ... }. Its calls are replaced by
+// the quote its body returns before the program runs.
+type Macro struct {
+	Params []*ast.Identifier
+	Body   *ast.BlockStmt
+	Env    *Environment
+}
+
+var _ Object = (*Macro)(nil)
+
+func (*Macro) Type() Type { return MACRO_OBJ }
+func (v *Macro) Inspect() string {
+	params := make([]string, len(v.Params))
+	for i, param := range v.Params {
+		params[i] = param.Value
+	}
+	return "macro(" + strings.Join(params, ", ") + ")"
+}
+func (v *Macro) Equal(obj Object) bool { return v == obj }
+func (v *Macro) Native() any           { return v.Inspect() }
@@ -0,0 +1,12 @@
+package object
+
+import "io"
+
+// Streams are the standard input and output streams a program reads from
+// and writes to. Builtins and modules should always go through these
+// rather than the process' os.Stdin/os.Stdout/os.Stderr.
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
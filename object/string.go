@@ -1,7 +1,10 @@
 package object
 
 import (
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/samber/lo"
 )
@@ -42,10 +45,106 @@ func (a *String) GetMethod(name string, eval Evaluator) *Builtin {
 		return a.Replace()
 	case "length":
 		return a.Length()
+	case "upper":
+		return a.transform(strings.ToUpper)
+	case "lower":
+		return a.transform(strings.ToLower)
+	case "title":
+		return a.transform(title)
+	case "trim":
+		return a.Trim(name, strings.Trim, strings.TrimSpace)
+	case "trim_left":
+		return a.Trim(name, strings.TrimLeft, func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) })
+	case "trim_right":
+		return a.Trim(name, strings.TrimRight, func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) })
+	case "starts_with":
+		return a.predicate(name, strings.HasPrefix)
+	case "ends_with":
+		return a.predicate(name, strings.HasSuffix)
+	case "contains":
+		return a.predicate(name, strings.Contains)
+	case "index_of":
+		return a.IndexOf(name, strings.Index)
+	case "last_index_of":
+		return a.IndexOf(name, strings.LastIndex)
+	case "count":
+		return a.Count()
+	case "repeat":
+		return a.Repeat()
+	case "pad_left":
+		return a.Pad(name, true)
+	case "pad_right":
+		return a.Pad(name, false)
+	case "lines":
+		return a.split(lines)
+	case "chars":
+		return a.split(func(s string) []string { return strings.Split(s, "") })
+	case "fields":
+		return a.split(strings.Fields)
+	case "substring":
+		return a.Substring()
+	case "to_int":
+		return a.ToInt()
+	case "to_float":
+		return a.ToFloat()
+	case "format":
+		return a.Format()
 	}
 	return nil
 }
 
+// stringArg returns the argument of the method at the index, which must be a String
+func stringArg(method string, args []Object, i int) (string, *Error) {
+	str, ok := args[i].(*String)
+	if !ok {
+		return "", methodExpectArgumentError(method, "String", string(args[i].Type()))
+	}
+	return str.Value, nil
+}
+
+// intArg returns the argument of the method at the index, which must be an Int
+func intArg(method string, args []Object, i int) (int, *Error) {
+	n, ok := args[i].(*Int)
+	if !ok {
+		return 0, methodExpectArgumentError(method, "Int", string(args[i].Type()))
+	}
+	return int(n.Value), nil
+}
+
+// runeIndex returns the index in runes of the byte index of the string, or -1
+func runeIndex(s string, i int) int {
+	if i < 0 {
+		return i
+	}
+	return utf8.RuneCountInString(s[:i])
+}
+
+// title returns the string with the first letter of each word in title case
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		start := unicode.IsSpace(prev) || unicode.IsPunct(prev) && prev != '\''
+		prev = r
+		if start {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// lines returns the lines of the string, without their line endings. A final line
+// ending does not start a new line.
+func lines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
 func (a *String) Split() *Builtin {
 	return &Builtin{
 		Fn: func(args ...Object) Object {
@@ -95,10 +194,242 @@ func (a *String) Replace() *Builtin {
 	}
 }
 
+// Length returns the number of characters, i.e. runes, of the string
 func (a *String) Length() *Builtin {
 	return &Builtin{
 		Fn: func(args ...Object) Object {
-			return &Int{Value: int64(len(a.Value))}
+			return &Int{Value: int64(utf8.RuneCountInString(a.Value))}
+		},
+	}
+}
+
+// transform returns the method returning the string transformed by f
+func (a *String) transform(f func(string) string) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			return NewString(f(a.Value))
+		},
+	}
+}
+
+// predicate returns the method reporting whether f holds for the string and its
+// String argument
+func (a *String) predicate(method string, f func(string, string) bool) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			arg, err := stringArg(method, args, 0)
+			if err != nil {
+				return err
+			}
+			return NewBoolean(f(a.Value, arg))
+		},
+	}
+}
+
+// split returns the method returning the parts of the string split by f
+func (a *String) split(f func(string) []string) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			entries := lo.Map(f(a.Value), func(val string, i int) Object { return NewString(val) })
+			return &Array{Entries: &entries}
+		},
+	}
+}
+
+// Trim removes the characters of the cutset argument from the string with trim, or
+// the white space with trimSpace if there is no argument
+func (a *String) Trim(method string, trim func(string, string) string, trimSpace func(string) string) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) > 1 {
+				return CountArgumentError("0 or 1", len(args))
+			}
+			if len(args) == 0 {
+				return NewString(trimSpace(a.Value))
+			}
+			cutset, err := stringArg(method, args, 0)
+			if err != nil {
+				return err
+			}
+			return NewString(trim(a.Value, cutset))
+		},
+	}
+}
+
+// IndexOf returns the index in runes of the substring found by index, or -1
+func (a *String) IndexOf(method string, index func(string, string) int) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			sub, err := stringArg(method, args, 0)
+			if err != nil {
+				return err
+			}
+			return NewInt(int64(runeIndex(a.Value, index(a.Value, sub))))
+		},
+	}
+}
+
+// Count returns the number of non-overlapping occurrences of the substring
+func (a *String) Count() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			sub, err := stringArg("count", args, 0)
+			if err != nil {
+				return err
+			}
+			return NewInt(int64(strings.Count(a.Value, sub)))
+		},
+	}
+}
+
+// maxStringLength is the most bytes of a string built by repeat or pad
+const maxStringLength = 1 << 30
+
+func (a *String) Repeat() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return CountArgumentError("1", len(args))
+			}
+			n, err := intArg("repeat", args, 0)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return NewErrorWithMsg("method 'repeat' expects a non-negative count, got %d", n)
+			}
+			if a.Value != "" && n > maxStringLength/len(a.Value) {
+				return NewErrorWithMsg("method 'repeat' would return a string longer than %d bytes", maxStringLength)
+			}
+			return NewString(strings.Repeat(a.Value, n))
+		},
+	}
+}
+
+// Pad pads the string on the left or the right to the width in runes, with the
+// padding argument or spaces. The string is returned as is if it is not shorter.
+func (a *String) Pad(method string, left bool) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 && len(args) != 2 {
+				return CountArgumentError("1 or 2", len(args))
+			}
+			width, err := intArg(method, args, 0)
+			if err != nil {
+				return err
+			}
+			pad := " "
+			if len(args) == 2 {
+				if pad, err = stringArg(method, args, 1); err != nil {
+					return err
+				}
+				if pad == "" {
+					return NewErrorWithMsg("method '%s' expects a non-empty padding", method)
+				}
+			}
+
+			missing := width - utf8.RuneCountInString(a.Value)
+			if missing <= 0 {
+				return a
+			}
+			// the padding is the whole pads and the first runes of one more
+			runes := []rune(pad)
+			rest := string(runes[:missing%len(runes)])
+			if missing > maxStringLength || (missing/len(runes))*len(pad)+len(rest)+len(a.Value) > maxStringLength {
+				return NewErrorWithMsg("method '%s' would return a string longer than %d bytes", method, maxStringLength)
+			}
+			padding := strings.Repeat(pad, missing/len(runes)) + rest
+			if left {
+				return NewString(padding + a.Value)
+			}
+			return NewString(a.Value + padding)
+		},
+	}
+}
+
+// Substring returns the runes of the string from the start index to the end index,
+// excluded, or to the end of the string
+func (a *String) Substring() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 && len(args) != 2 {
+				return CountArgumentError("1 or 2", len(args))
+			}
+			runes := []rune(a.Value)
+			start, err := intArg("substring", args, 0)
+			if err != nil {
+				return err
+			}
+			end := len(runes)
+			if len(args) == 2 {
+				if end, err = intArg("substring", args, 1); err != nil {
+					return err
+				}
+			}
+			if start < 0 || end > len(runes) || start > end {
+				return NewErrorWithMsg("substring [%d:%d] out of range for a string of length %d", start, end, len(runes))
+			}
+			return NewString(string(runes[start:end]))
+		},
+	}
+}
+
+// ToInt parses the string as a base 10 integer
+func (a *String) ToInt() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			n, err := strconv.ParseInt(a.Value, 10, 64)
+			if err != nil {
+				return NewErrorWithMsg("cannot parse %q as Int", a.Value)
+			}
+			return NewInt(n)
+		},
+	}
+}
+
+// ToFloat parses the string as a float
+func (a *String) ToFloat() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return CountArgumentError("0", len(args))
+			}
+			f, err := strconv.ParseFloat(a.Value, 64)
+			if err != nil {
+				return NewErrorWithMsg("cannot parse %q as Float", a.Value)
+			}
+			return NewFloat(f)
+		},
+	}
+}
+
+// Format formats the arguments with the string as format, as sprintf does
+func (a *String) Format() *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			str, err := Format(a.Value, args)
+			if err != nil {
+				return err
+			}
+			return NewString(str)
 		},
 	}
 }
@@ -2,6 +2,7 @@ package object
 
 import (
 	"ede/ast"
+	"ede/token"
 
 	"golang.org/x/exp/constraints"
 )
@@ -14,7 +15,6 @@ type (
 	Float       struct{ Value float64 }
 	Boolean     struct{ Value bool }
 	Nil         struct{}
-	Error       struct{ Message string }
 	ReturnValue struct{ Value Object }
 	Hash        struct{ Entries map[string]Object }
 
@@ -28,6 +28,12 @@ type (
 	Builtin   struct{ Fn BuiltinFn }
 )
 
+type Error struct {
+	Message string
+	Pos     token.Pos // where the error occurred, zero if unknown
+	Kind    string    // the kind of the error, e.g. "not_found", empty if unknown
+}
+
 func (a *Nil) Native() any {
 	return nil
 }
@@ -2,26 +2,28 @@ package parser
 
 import (
 	"ede/token"
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/go-multierror"
 )
 
-type parseError struct {
+// ParseError is an error found while parsing, at a line and column of the source
+type ParseError struct {
 	line   int
 	column int
 	err    error
 }
 
-func NewParseError(err error, pos token.Pos) *parseError {
-	return &parseError{
+func NewParseError(err error, pos token.Pos) *ParseError {
+	return &ParseError{
 		err:    err,
 		line:   pos.Line,
 		column: pos.Column,
 	}
 }
 
-func (p *parseError) Error() string {
+func (p *ParseError) Error() string {
 	return fmt.Sprintf(`
 	Error: %s
 	Line: %d
@@ -29,6 +31,32 @@ func (p *parseError) Error() string {
 	`, p.err, p.line, p.column)
 }
 
+// Pos returns the position of the error in the source
+func (p *ParseError) Pos() token.Pos { return token.Pos{Line: p.line, Column: p.column} }
+
+// Unwrap returns the error without its position
+func (p *ParseError) Unwrap() error { return p.err }
+
+// ErrorList returns the parse errors held by err, e.g. the ParseErrors of a program
+func ErrorList(err error) []*ParseError {
+	var errs []error
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		errs = merr.WrappedErrors()
+	} else if err != nil {
+		errs = []error{err}
+	}
+
+	list := make([]*ParseError, 0, len(errs))
+	for _, err := range errs {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			list = append(list, perr)
+		}
+	}
+	return list
+}
+
 func (p *Parser) addError(msg string, format ...interface{}) {
 	p.errors = append(p.errors, NewParseError(fmt.Errorf(msg, format...), p.currPos()))
 }
@@ -40,6 +40,10 @@ func (p *Parser) parseSetExpression(startPos token.Pos) ast.Expression {
 func (p *Parser) parseObjectMethodExpression(obj ast.Expression) ast.Expression {
 	expr := &ast.ObjectMethodExpression{Token: p.currToken, Object: obj}
 	p.advanceToken()
+	// a keyword after the dot is a name, e.g. path.match
+	if tok := p.currToken.Type; tok != token.IDENT && tok == token.LookupIdent(p.currToken.Literal) {
+		p.currToken.Type = token.IDENT
+	}
 	if !p.currTokenIs(token.IDENT) {
 		p.addError(unexpectedTokenError(token.IDENT, p.currToken.Literal))
 		return nil
@@ -135,6 +139,21 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return stmt
 }
 
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.currToken}
+	if !p.advanceNextTokenIs(token.LPAREN) {
+		return nil
+	}
+	p.advanceToken()
+	lit.Params = p.parseFunctionParams()
+
+	if !p.advanceCurrTokenIs(token.LBRACE) {
+		return nil
+	}
+	lit.Body = p.parseBlockStmt()
+	return lit
+}
+
 func (p *Parser) parseFunctionParams() []*ast.Identifier {
 	identifiers := make([]*ast.Identifier, 0)
 
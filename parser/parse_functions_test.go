@@ -68,3 +68,22 @@ func TestParseMethodExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKeywordMember(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`path.match("*.ede", name)`, "path.match(\"*.ede\", name)\n"},
+		{`config.default`, "config.default\n"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.Parse()
+		checkParserErrors(t, p)
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
@@ -105,13 +105,21 @@ func (p *Parser) parseIfStmt() *ast.IfStmt {
 		return nil
 	}
 
+	consequence := p.parseBlockStmt()
+	if consequence == nil {
+		return nil
+	}
 	stmt.Consequence = &ast.ConditionalStmt{
+		Token:     stmt.Token,
 		Condition: stmt.Condition,
-		Statement: p.parseBlockStmt(),
+		Statement: consequence,
+		Rbrace:    consequence.Rbrace,
 	}
 
 	if p.currTokenIs(token.ELSE) {
-		for p.advanceCurrTokenIs(token.ELSE) {
+		for p.currTokenIs(token.ELSE) {
+			elseToken := p.currToken
+			p.advanceToken()
 			// else if
 			if p.currTokenIs(token.IF) {
 				p.advanceToken()
@@ -134,7 +142,7 @@ func (p *Parser) parseIfStmt() *ast.IfStmt {
 
 				elifStmt := p.parseStmt()
 
-				condStmt := &ast.ConditionalStmt{Condition: condition, Statement: elifStmt}
+				condStmt := &ast.ConditionalStmt{Token: elseToken, Condition: condition, Statement: elifStmt, Rbrace: p.currPos()}
 				stmt.Alternatives = append(stmt.Alternatives, condStmt)
 
 				if !p.advanceCurrTokenIs(token.RBRACE) {
@@ -147,7 +155,7 @@ func (p *Parser) parseIfStmt() *ast.IfStmt {
 					return nil
 				}
 				elseStmt := p.parseStmt()
-				condStmt := &ast.ConditionalStmt{Statement: elseStmt}
+				condStmt := &ast.ConditionalStmt{Token: elseToken, Statement: elseStmt, Rbrace: p.currPos()}
 				stmt.Alternatives = append(stmt.Alternatives, condStmt)
 
 				if !p.advanceCurrTokenIs(token.RBRACE) {
@@ -164,7 +172,8 @@ func (p *Parser) parseIfStmt() *ast.IfStmt {
 }
 
 func (p *Parser) parseBlockStmt() *ast.BlockStmt {
-	blockStmt := &ast.BlockStmt{Statements: make([]ast.Statement, 0)}
+	// the opening brace has been consumed by the caller
+	blockStmt := &ast.BlockStmt{Token: p.prevToken, Statements: make([]ast.Statement, 0)}
 
 	for !p.currTokenIs(token.EOF) && !p.currTokenIs(token.RBRACE) {
 		if stmt := p.parseStmt(); stmt != nil {
@@ -176,6 +185,7 @@ func (p *Parser) parseBlockStmt() *ast.BlockStmt {
 
 		p.eatEndToken()
 	}
+	blockStmt.Rbrace = p.currPos()
 	if !p.advanceCurrTokenIs(token.RBRACE) {
 		p.addError(unexpectedTokenError(token.RBRACE, p.currToken.Literal))
 		return nil
@@ -227,6 +237,7 @@ outerloop:
 	for {
 		switch p.currToken.Type {
 		case token.RBRACE:
+			stmt.Rbrace = p.currPos()
 			p.advanceToken()
 			return stmt
 		case token.CASE:
@@ -69,6 +69,7 @@ func (p *Parser) registerParseFns() {
 	p.parseFns[token.LBRACKET] = parseFn{prefix: p.parseArrayLiteral, infix: p.parseIndexExpression}
 	p.parseFns[token.LBRACE] = parseFn{prefix: p.parseHashLiteral}
 	p.parseFns[token.FUNCTION] = parseFn{prefix: p.parseFunctionLiteral}
+	p.parseFns[token.MACRO] = parseFn{prefix: p.parseMacroLiteral}
 	p.parseFns[token.ASSIGN] = parseFn{infix: p.parseReassignment}
 	p.parseFns[token.PLUS_EQUAL] = parseFn{infix: p.parsePlusEqual}
 	p.parseFns[token.MINUS_EQUAL] = parseFn{infix: p.parseMinusEqual}
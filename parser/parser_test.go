@@ -350,6 +350,53 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	}
 }
 
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `let m = macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.Parse()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.LetStmt)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStmt. got=%T",
+			program.Statements[0])
+	}
+
+	macro, ok := stmt.Expr.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expr is not ast.MacroLiteral. got=%T", stmt.Expr)
+	}
+
+	if len(macro.Params) != 2 {
+		t.Fatalf("macro literal Params wrong. want 2, got=%d\n", len(macro.Params))
+	}
+
+	testLiteralExpression(t, macro.Params[0], "x")
+	testLiteralExpression(t, macro.Params[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n",
+			len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStmt)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStmt. got=%T",
+			macro.Body.Statements[0])
+	}
+
+	if !testInfixExpression(t, bodyStmt.Expr, "x", "+", "y") {
+		t.FailNow()
+	}
+}
+
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
 		input          string
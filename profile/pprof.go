@@ -0,0 +1,144 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format of pprof.
+// Each function is a frame, and the samples hold the calls and the exclusive time
+// of each call stack.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	b := &profileBuilder{strings: map[string]int64{"": 0}, stringTable: []string{""}}
+
+	// sample_type and period_type
+	b.valueType(1, "calls", "count")
+	b.valueType(1, "time", "nanoseconds")
+	b.valueType(11, "time", "nanoseconds")
+	b.varint(12, 1) // period
+
+	ids := map[*Function]uint64{}
+	var functions []*Function
+	id := func(f *Function) uint64 {
+		if n, ok := ids[f]; ok {
+			return n
+		}
+		functions = append(functions, f)
+		ids[f] = uint64(len(functions))
+		return ids[f]
+	}
+
+	var samples func(n *node, stack []uint64)
+	samples = func(n *node, stack []uint64) {
+		if n.fn != nil {
+			// the stack is innermost first, a location per function with the same id
+			stack = append([]uint64{id(n.fn)}, stack...)
+		}
+		if n.calls > 0 {
+			var sample protoBuffer
+			sample.packed(1, stack)
+			sample.packed(2, []uint64{uint64(n.calls), uint64(n.time.Nanoseconds())})
+			b.message(2, sample)
+		}
+		children := make([]*node, 0, len(n.children))
+		for _, c := range n.children {
+			children = append(children, c)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i].fn.Name < children[j].fn.Name })
+		for _, c := range children {
+			samples(c, stack)
+		}
+	}
+	samples(p.root, nil)
+
+	for _, f := range functions {
+		var line, loc, fn protoBuffer
+		line.varint(1, ids[f])
+		line.varint(2, uint64(f.Line))
+		loc.varint(1, ids[f])
+		loc.message(4, line)
+		b.message(4, loc)
+
+		fn.varint(1, ids[f])
+		fn.varint(2, uint64(b.str(f.Name)))
+		fn.varint(3, uint64(b.str(f.Name)))
+		fn.varint(4, uint64(b.str(p.file)))
+		fn.varint(5, uint64(f.Line))
+		b.message(5, fn)
+	}
+
+	for _, s := range b.stringTable {
+		b.bytes(6, []byte(s))
+	}
+	b.varint(9, uint64(p.start.UnixNano()))
+	b.varint(10, uint64(p.elapsed.Nanoseconds()))
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b.buf); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protoBuffer encodes the fields of a protocol buffer message
+type protoBuffer struct {
+	buf []byte
+}
+
+func (b *protoBuffer) uvarint(x uint64) {
+	for x >= 0x80 {
+		b.buf = append(b.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	b.buf = append(b.buf, byte(x))
+}
+
+func (b *protoBuffer) varint(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.uvarint(uint64(field) << 3)
+	b.uvarint(x)
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.uvarint(uint64(field)<<3 | 2)
+	b.uvarint(uint64(len(data)))
+	b.buf = append(b.buf, data...)
+}
+
+func (b *protoBuffer) packed(field int, values []uint64) {
+	var p protoBuffer
+	for _, v := range values {
+		p.uvarint(v)
+	}
+	b.bytes(field, p.buf)
+}
+
+func (b *protoBuffer) message(field int, m protoBuffer) {
+	b.bytes(field, m.buf)
+}
+
+// profileBuilder encodes a profile message, and its table of strings
+type profileBuilder struct {
+	protoBuffer
+	strings     map[string]int64
+	stringTable []string
+}
+
+func (b *profileBuilder) str(s string) int64 {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	b.strings[s] = int64(len(b.stringTable))
+	b.stringTable = append(b.stringTable, s)
+	return b.strings[s]
+}
+
+func (b *profileBuilder) valueType(field int, typ, unit string) {
+	var vt protoBuffer
+	vt.varint(1, uint64(b.str(typ)))
+	vt.varint(2, uint64(b.str(unit)))
+	b.message(field, vt)
+}
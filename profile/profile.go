@@ -0,0 +1,229 @@
+// Package profile records where the time of a program is spent: the calls and the
+// time of each function, and the number of times each line runs. The profile can
+// be written as a text report, or in the pprof format read by `go tool pprof`.
+package profile
+
+import (
+	"ede/ast"
+	"ede/evaluator"
+	"ede/object"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mainName is the name of the frame of the statements outside of functions
+const mainName = "main"
+
+// Function is the profile of a function
+type Function struct {
+	Name string
+	Line int // the line of the function literal, 0 for main
+	// Calls is the number of calls of the function
+	Calls int
+	// Inclusive is the time spent in the function and the functions it calls.
+	// The time of recursive calls is only counted once.
+	Inclusive time.Duration
+	// Exclusive is the time spent in the function itself
+	Exclusive time.Duration
+}
+
+// Profiler is a hook of the evaluator recording the profile of the program
+type Profiler struct {
+	evaluator.NopHook
+
+	// now returns the current time, it is replaced by the tests
+	now func() time.Time
+
+	file      string
+	start     time.Time
+	elapsed   time.Duration
+	functions map[*ast.BlockStmt]*Function // by body, the closures of a literal are the same function
+	main      *Function
+	lines     map[int]int
+	stack     []*frame
+	active    map[*Function]int // the number of frames of each function on the stack
+
+	// root is the tree of the call stacks, holding the calls and the exclusive
+	// time of each stack
+	root *node
+}
+
+type frame struct {
+	fn    *Function
+	node  *node
+	start time.Time
+	child time.Duration // the time spent in the functions called
+}
+
+// node is a call stack, the function called and the stacks of the functions it calls
+type node struct {
+	fn       *Function
+	children map[*Function]*node
+	calls    int64
+	time     time.Duration
+}
+
+func (n *node) child(fn *Function) *node {
+	c, ok := n.children[fn]
+	if !ok {
+		c = &node{fn: fn, children: make(map[*Function]*node)}
+		n.children[fn] = c
+	}
+	return c
+}
+
+// New returns a profiler of the program in the file, and adds it to the hooks of
+// the evaluator. The profile starts with Start, and ends with Stop.
+func New(e *evaluator.Evaluator, file string) *Profiler {
+	p := &Profiler{
+		now:       time.Now,
+		file:      file,
+		functions: make(map[*ast.BlockStmt]*Function),
+		main:      &Function{Name: mainName},
+		lines:     make(map[int]int),
+		active:    make(map[*Function]int),
+		root:      &node{children: make(map[*Function]*node)},
+	}
+	e.AddHook(p)
+	return p
+}
+
+// Start starts the profile, before the program runs
+func (p *Profiler) Start() {
+	p.start = p.now()
+	p.main.Calls = 1
+	p.push(p.main)
+}
+
+// Stop ends the profile once the program ends. The functions still running, e.g.
+// when the program exits, are stopped too.
+func (p *Profiler) Stop() {
+	for len(p.stack) > 0 {
+		p.pop()
+	}
+	p.elapsed = p.now().Sub(p.start)
+}
+
+func (p *Profiler) Statement(stmt ast.Statement, env *object.Environment) {
+	p.lines[stmt.Pos().Line]++
+}
+
+func (p *Profiler) Call(call *ast.CallExpression, fn *object.Function, args []object.Object, env *object.Environment) {
+	f, ok := p.functions[fn.Body]
+	if !ok {
+		f = &Function{Name: fmt.Sprintf("func@%d", fn.Body.Pos().Line), Line: fn.Body.Pos().Line}
+		if call != nil {
+			if ident, ok := call.Function.(*ast.Identifier); ok {
+				f.Name = ident.Value
+			}
+		}
+		p.functions[fn.Body] = f
+	}
+	f.Calls++
+	p.push(f)
+}
+
+func (p *Profiler) Return(call *ast.CallExpression, fn *object.Function, result object.Object) {
+	if len(p.stack) > 1 {
+		p.pop()
+	}
+}
+
+func (p *Profiler) push(f *Function) {
+	parent := p.root
+	if len(p.stack) > 0 {
+		parent = p.stack[len(p.stack)-1].node
+	}
+	p.stack = append(p.stack, &frame{fn: f, node: parent.child(f), start: p.now()})
+	p.active[f]++
+}
+
+// pop ends the innermost frame, adding its time to its function and to its caller
+func (p *Profiler) pop() {
+	fr := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.active[fr.fn]--
+
+	elapsed := p.now().Sub(fr.start)
+	if p.active[fr.fn] == 0 {
+		fr.fn.Inclusive += elapsed
+	}
+	fr.fn.Exclusive += elapsed - fr.child
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].child += elapsed
+	}
+
+	fr.node.calls++
+	fr.node.time += elapsed - fr.child
+}
+
+// Functions returns the profiles of the functions called and of main, sorted by
+// decreasing exclusive time
+func (p *Profiler) Functions() []*Function {
+	functions := []*Function{p.main}
+	for _, f := range p.functions {
+		functions = append(functions, f)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Exclusive != functions[j].Exclusive {
+			return functions[i].Exclusive > functions[j].Exclusive
+		}
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
+
+// Line is the number of times a line ran
+type Line struct {
+	Line int
+	Hits int
+}
+
+// Lines returns the lines that ran, sorted by decreasing hits
+func (p *Profiler) Lines() []Line {
+	lines := make([]Line, 0, len(p.lines))
+	for line, hits := range p.lines {
+		lines = append(lines, Line{Line: line, Hits: hits})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Hits != lines[j].Hits {
+			return lines[i].Hits > lines[j].Hits
+		}
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// WriteText writes the report of the profile: the functions sorted by exclusive
+// time, then the lines of the source sorted by hits
+func (p *Profiler) WriteText(w io.Writer, src string) error {
+	fmt.Fprintf(w, "total time %s\n\n", round(p.elapsed))
+	fmt.Fprintf(w, "%8s %12s %12s  %s\n", "calls", "inclusive", "exclusive", "function")
+	for _, f := range p.Functions() {
+		name := f.Name
+		if f.Line > 0 {
+			name = fmt.Sprintf("%s (%s:%d)", f.Name, p.file, f.Line)
+		}
+		fmt.Fprintf(w, "%8d %12s %12s  %s\n", f.Calls, round(f.Inclusive), round(f.Exclusive), name)
+	}
+
+	lines := strings.Split(src, "\n")
+	fmt.Fprintf(w, "\n%8s %6s  %s\n", "hits", "line", "source")
+	for _, l := range p.Lines() {
+		text := ""
+		if l.Line-1 < len(lines) {
+			text = strings.TrimSpace(lines[l.Line-1])
+		}
+		if _, err := fmt.Fprintf(w, "%8d %6d  %s\n", l.Hits, l.Line, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const src = `let fib = func(n) {
+    if (n < 2) {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+let twice = func(f) {
+    return [f(), f()]
+}
+twice(func() {
+    return fib(3)
+})
+`
+
+func profileProgram(t *testing.T) *Profiler {
+	t.Helper()
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		t.Fatal(prog.ParseErrors)
+	}
+	e := &evaluator.Evaluator{}
+	p := New(e, "fib.ede")
+	// every reading of the clock advances it by a millisecond
+	now := time.Unix(0, 0)
+	p.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	p.Start()
+	if err, ok := e.Eval(prog, object.NewEnvironment(nil)).(*object.Error); ok {
+		t.Fatal(err.Message)
+	}
+	p.Stop()
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	p := profileProgram(t)
+
+	calls := map[string]int{}
+	var exclusive time.Duration
+	for _, f := range p.Functions() {
+		calls[f.Name] = f.Calls
+		exclusive += f.Exclusive
+		if f.Exclusive > f.Inclusive {
+			t.Errorf("%s - exclusive time %s greater than inclusive time %s", f.Name, f.Exclusive, f.Inclusive)
+		}
+	}
+	expected := map[string]int{"main": 1, "twice": 1, "f": 2, "fib": 10}
+	for name, n := range expected {
+		if calls[name] != n {
+			t.Errorf("wrong calls of %s. expected=%d, got=%d", name, n, calls[name])
+		}
+	}
+	if len(calls) != len(expected) {
+		t.Errorf("wrong functions. expected=%v, got=%v", expected, calls)
+	}
+	if main := p.main; exclusive != main.Inclusive {
+		t.Errorf("the exclusive times %s do not add up to the time of main %s", exclusive, main.Inclusive)
+	}
+	for _, f := range p.Functions() {
+		if f.Name == "fib" && f.Inclusive >= p.main.Inclusive {
+			t.Errorf("the inclusive time of fib counts the recursive calls: %s", f.Inclusive)
+		}
+	}
+
+	hits := map[int]int{}
+	for _, l := range p.Lines() {
+		hits[l.Line] = l.Hits
+	}
+	for line, n := range map[int]int{1: 1, 2: 10, 3: 6, 5: 4, 8: 1, 11: 2} {
+		if hits[line] != n {
+			t.Errorf("wrong hits of line %d. expected=%d, got=%d", line, n, hits[line])
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := profileProgram(t).WriteText(out, src); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"      10", "fib (fib.ede:1)\n",
+		"\n    hits   line  source\n      10      2  if (n < 2) {\n",
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("report %q does not contain %q", out, exp)
+		}
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := profileProgram(t).WritePprof(out); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the string table holds the names of the frames
+	for _, s := range []string{"fib", "twice", "main", "fib.ede", "nanoseconds"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("profile does not contain %q", s)
+		}
+	}
+}
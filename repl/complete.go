@@ -0,0 +1,71 @@
+package repl
+
+import (
+	"ede/object"
+	"ede/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// completions returns the sorted names completing the identifier before the end
+// of the text: the meta-commands, keywords, builtins and bindings of the session,
+// or the functions of a module and the methods of a value after a dot
+func (r *REPL) completions(text string) ([]string, int) {
+	word := text[len(strings.TrimRightFunc(text, isIdentChar)):]
+	before := strings.TrimSuffix(text, word)
+
+	var names []string
+	switch {
+	case before == ":":
+		for _, cmd := range metaCommands {
+			names = append(names, strings.TrimPrefix(cmd, ":"))
+		}
+	case strings.HasSuffix(before, "."):
+		names = r.members(strings.TrimSuffix(before, "."))
+	default:
+		names = append(names, token.Keywords()...)
+		names = append(names, r.eval.BuiltinNames()...)
+		for env := r.env; env != nil; env = env.Outer() {
+			names = append(names, env.Names()...)
+		}
+	}
+
+	seen := map[string]bool{}
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates, len([]rune(word))
+}
+
+// members returns the functions of the module, or the methods of the value,
+// ending the text
+func (r *REPL) members(text string) []string {
+	var typ object.Type
+	switch {
+	case strings.HasSuffix(text, `"`), strings.HasSuffix(text, "`"):
+		typ = object.STRING_OBJ
+	case strings.HasSuffix(text, "]"):
+		typ = object.ARRAY_OBJ
+	default:
+		name := text[len(strings.TrimRightFunc(text, isIdentChar)):]
+		value, ok := r.env.Get(name)
+		if !ok {
+			return nil
+		}
+		if imp, ok := value.(*object.Import); ok {
+			return object.MemberNames(imp.Module)
+		}
+		typ = value.Type()
+	}
+	return append(append([]string{}, object.MethodNames[typ]...), object.CommonMethods...)
+}
+
+func isIdentChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,284 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errInterrupt is returned by ReadLine when the user interrupts the line with Ctrl-C
+var errInterrupt = errors.New("interrupt")
+
+// lineReader reads the lines typed by the user
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader reads lines from a stream that is not a terminal, e.g. a pipe
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// completer returns the candidates completing the text before the cursor, and
+// the length in runes of the word they complete
+type completer func(text string) (candidates []string, wordLen int)
+
+// editor edits lines on a terminal in raw mode, with a history and completion
+type editor struct {
+	fd       int
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete completer
+
+	prompt string
+	buf    []rune
+	cursor int
+}
+
+// newLineReader returns an editor if the input is a terminal, and a plain
+// reader otherwise
+func newLineReader(in *bufio.Reader, stdin io.Reader, out io.Writer, h *history, complete completer) lineReader {
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return &editor{fd: int(f.Fd()), in: in, out: out, history: h, complete: complete}
+	}
+	return &plainReader{in: in, out: out}
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(e.fd, state)
+	return e.readLine(prompt)
+}
+
+// readLine reads the keys until the line is entered
+func (e *editor) readLine(prompt string) (string, error) {
+	e.prompt, e.buf, e.cursor = prompt, nil, 0
+	e.history.reset()
+	e.redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(e.buf) > 0 {
+				break
+			}
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			line := string(e.buf)
+			e.history.add(line)
+			return line, nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupt
+		case 4: // Ctrl-D
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.cursor)
+		case 127, 8: // Backspace, Ctrl-H
+			if e.cursor > 0 {
+				e.cursor--
+				e.delete(e.cursor)
+			}
+		case 1: // Ctrl-A
+			e.cursor = 0
+		case 5: // Ctrl-E
+			e.cursor = len(e.buf)
+		case 11: // Ctrl-K
+			e.buf = e.buf[:e.cursor]
+		case 21: // Ctrl-U
+			e.buf = e.buf[e.cursor:]
+			e.cursor = 0
+		case '\t':
+			e.completeWord()
+		case 27: // escape sequences of the arrow keys
+			e.escape()
+		default:
+			if r >= ' ' {
+				e.insert(r)
+			}
+		}
+		e.redraw()
+	}
+	return string(e.buf), nil
+}
+
+func (e *editor) escape() {
+	if r, _, err := e.in.ReadRune(); err != nil || (r != '[' && r != 'O') {
+		return
+	}
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+	switch r {
+	case 'A': // up
+		if line, ok := e.history.prev(string(e.buf)); ok {
+			e.buf, e.cursor = []rune(line), len([]rune(line))
+		}
+	case 'B': // down
+		if line, ok := e.history.next(); ok {
+			e.buf, e.cursor = []rune(line), len([]rune(line))
+		}
+	case 'C': // right
+		if e.cursor < len(e.buf) {
+			e.cursor++
+		}
+	case 'D': // left
+		if e.cursor > 0 {
+			e.cursor--
+		}
+	case 'H':
+		e.cursor = 0
+	case 'F':
+		e.cursor = len(e.buf)
+	case '3': // delete, ESC [ 3 ~
+		if r, _, err := e.in.ReadRune(); err == nil && r == '~' {
+			e.delete(e.cursor)
+		}
+	}
+}
+
+func (e *editor) insert(runes ...rune) {
+	buf := make([]rune, 0, len(e.buf)+len(runes))
+	buf = append(buf, e.buf[:e.cursor]...)
+	buf = append(buf, runes...)
+	e.buf = append(buf, e.buf[e.cursor:]...)
+	e.cursor += len(runes)
+}
+
+func (e *editor) delete(i int) {
+	if i < len(e.buf) {
+		e.buf = append(e.buf[:i], e.buf[i+1:]...)
+	}
+}
+
+// completeWord completes the word before the cursor with the common prefix of
+// the candidates, or lists them if there is nothing to add
+func (e *editor) completeWord() {
+	candidates, wordLen := e.complete(string(e.buf[:e.cursor]))
+	if len(candidates) == 0 {
+		return
+	}
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if prefix := []rune(common); len(prefix) > wordLen {
+		e.insert(prefix[wordLen:]...)
+	} else if len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// redraw draws the prompt and the line, and moves the cursor to its position
+func (e *editor) redraw() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if n := len(e.buf) - e.cursor; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// history is the list of the lines entered, optionally saved to a file
+type history struct {
+	lines []string
+	file  string
+	pos   int    // the line being recalled, len(lines) when none
+	draft string // the line being edited before the history was recalled
+}
+
+// maxHistory is the number of lines loaded from the history file
+const maxHistory = 1000
+
+// loadHistory loads the history from the file, if any. A missing file is an
+// empty history.
+func loadHistory(file string) (*history, error) {
+	h := &history{file: file}
+	if file == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return h, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+	h.reset()
+	return h, nil
+}
+
+// add adds the line to the history and appends it to the file. Empty lines,
+// and lines repeating the last one, are skipped.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if h.file == "" {
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return // the history is a convenience, failing to save it is not an error
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func (h *history) reset() {
+	h.pos, h.draft = len(h.lines), ""
+}
+
+// prev returns the line before the one recalled. current is the line being edited.
+func (h *history) prev(current string) (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.lines) {
+		h.draft = current
+	}
+	h.pos--
+	return h.lines[h.pos], true
+}
+
+// next returns the line after the one recalled, or the draft after the last one
+func (h *history) next() (string, bool) {
+	if h.pos >= len(h.lines) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.lines) {
+		return h.draft, true
+	}
+	return h.lines[h.pos], true
+}
@@ -0,0 +1,28 @@
+package repl
+
+import (
+	"ede/lexer"
+	"ede/token"
+)
+
+// incomplete returns true if the source ends inside parentheses, brackets, braces
+// or a raw string, so that the next lines are part of the same input
+func incomplete(src string) bool {
+	depth := 0
+	l := lexer.New(src + "\n")
+	var prev token.TokenType
+	for {
+		tok := l.NextToken()
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		case token.EOF:
+			// a raw string is the only token that can hold the last newline,
+			// so an illegal token right before the end is an unterminated one
+			return depth > 0 || prev == token.ILLEGAL
+		}
+		prev = tok.Type
+	}
+}
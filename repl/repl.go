@@ -1,29 +1,213 @@
+// Package repl implements the interactive session of ede
 package repl
 
 import (
 	"bufio"
+	"ede/ast"
 	"ede/evaluator"
 	"ede/lexer"
 	"ede/object"
 	"ede/parser"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 )
 
+const (
+	prompt             = ">> "
+	continuationPrompt = ".. "
+)
+
+const help = `Enter statements to evaluate them. An input continues on the next lines
+until its parentheses, brackets and braces are closed.
+
+Commands:
+  :load FILE   evaluate the program in the file
+  :env         list the bindings of the session
+  :type EXPR   evaluate the expression and print the type of its value; its
+               bindings are dropped, but its calls run, e.g. println prints
+  :ast EXPR    print the syntax tree of the expression
+  :reset       clear the bindings of the session
+  :help        print this help
+  :quit        end the session
+`
+
+// metaCommands are the names of the commands, offered by completion
+var metaCommands = []string{":load", ":env", ":type", ":ast", ":reset", ":help", ":quit"}
+
+// REPL is an interactive session, reading the input from a stream and writing
+// the results and errors to another
+type REPL struct {
+	In  io.Reader
+	Out io.Writer
+	// HistoryFile is the file the lines entered on a terminal are loaded from and
+	// appended to. The history is not persistent if it is empty.
+	HistoryFile string
+
+	eval   *evaluator.Evaluator
+	env    *object.Environment
+	macros *object.Environment // the macros defined in the session
+}
+
+// Start runs a session without persistent history
 func Start(input io.Reader, output io.Writer) {
-	scanner := bufio.NewScanner(input)
-	env := object.NewEnvironment(nil)
-	fmt.Fprintf(output, ">> ")
-	for scanner.Scan() {
-		lex := lexer.New(scanner.Text())
-		p := parser.New(lex)
-		prog := p.Parse()
-		if p.Errors() != nil {
-			fmt.Println(p.Errors())
+	(&REPL{In: input, Out: output}).Run()
+}
+
+// Run runs the session until the input ends, or the session is quit. It returns
+// the exit code requested by the program, e.g. with os.exit.
+func (r *REPL) Run() int {
+	r.eval = &evaluator.Evaluator{Stdin: r.In, Stdout: r.Out, Stderr: r.Out}
+	r.env = object.NewEnvironment(nil)
+	r.macros = object.NewEnvironment(nil)
+	// the lines and the input of the programs are read from the same buffer
+	in := r.eval.Streams().Stdin.(*bufio.Reader)
+
+	h, err := loadHistory(r.HistoryFile)
+	if err != nil {
+		fmt.Fprintf(r.Out, "cannot load the history: %s\n", err)
+	}
+	lines := newLineReader(in, r.In, r.Out, h, r.completions)
+
+	var input []string
+	for {
+		p := prompt
+		if len(input) > 0 {
+			p = continuationPrompt
+		}
+		line, err := lines.ReadLine(p)
+		if err == errInterrupt {
+			input = nil
 			continue
 		}
-		if eval := (&evaluator.Evaluator{}).Eval(prog, env); eval != nil {
-			output.Write([]byte(fmt.Sprintf("Result: %v\n", eval.Inspect())))
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(r.Out, err)
+			}
+			return 0
+		}
+
+		if len(input) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if quit := r.command(strings.TrimSpace(line)); quit {
+				return 0
+			}
+			continue
+		}
+		input = append(input, line)
+		src := strings.Join(input, "\n")
+		if incomplete(src) {
+			continue
+		}
+		input = nil
+		if exit, ok := r.evalSource(src).(*object.Exit); ok {
+			return exit.Code
+		}
+	}
+}
+
+// evalSource evaluates the source in the session, and prints its result
+func (r *REPL) evalSource(src string) object.Object {
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		fmt.Fprintln(r.Out, prog.ParseErrors)
+		return nil
+	}
+	if err := r.expand(prog); err != nil {
+		fmt.Fprintln(r.Out, strings.TrimSpace(err.Message))
+		return nil
+	}
+	if len(prog.Statements) == 0 {
+		return nil
+	}
+
+	result := r.eval.Eval(prog, r.env)
+	switch result := result.(type) {
+	case nil, *object.Nil, *object.Exit:
+	case *object.Error:
+		fmt.Fprintln(r.Out, strings.TrimSpace(result.Message))
+	default:
+		// only the value of an expression is printed, not the one of e.g. a let
+		if _, ok := prog.Statements[len(prog.Statements)-1].(*ast.ExpressionStmt); ok {
+			fmt.Fprintln(r.Out, result.Inspect())
+		}
+	}
+	return result
+}
+
+// expand defines the macros of the program in the session, and expands the calls
+// of the macros of the session
+func (r *REPL) expand(prog *ast.Program) *object.Error {
+	evaluator.DefineMacros(prog, r.macros)
+	return r.eval.ExpandMacros(prog, r.macros)
+}
+
+// command runs the meta-command, and returns true if it quits the session
+func (r *REPL) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":quit", ":q":
+		return true
+	case ":help", ":h":
+		fmt.Fprint(r.Out, help)
+	case ":reset":
+		r.env = object.NewEnvironment(nil)
+		r.macros = object.NewEnvironment(nil)
+	case ":env":
+		for _, name := range r.env.Names() {
+			value, _ := r.env.Get(name)
+			fmt.Fprintf(r.Out, "%s = %s\n", name, value.Inspect())
+		}
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(r.Out, "usage: :load FILE")
+			return false
+		}
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(r.Out, err)
+			return false
+		}
+		prog := parser.New(lexer.New(string(data))).Parse()
+		if prog.ParseErrors != nil {
+			fmt.Fprintf(r.Out, "%s: %s\n", arg, prog.ParseErrors)
+			return false
+		}
+		if err := r.expand(prog); err != nil {
+			fmt.Fprintf(r.Out, "%s: %s\n", arg, strings.TrimSpace(err.Message))
+			return false
+		}
+		if err, ok := r.eval.Eval(prog, r.env).(*object.Error); ok {
+			fmt.Fprintf(r.Out, "%s: %s\n", arg, strings.TrimSpace(err.Message))
+		}
+	case ":type", ":ast":
+		if arg == "" {
+			fmt.Fprintf(r.Out, "usage: %s EXPR\n", name)
+			return false
+		}
+		prog := parser.New(lexer.New(arg)).Parse()
+		if prog.ParseErrors != nil {
+			fmt.Fprintln(r.Out, prog.ParseErrors)
+			return false
+		}
+		if name == ":ast" {
+			ast.Dump(r.Out, prog)
+			return false
+		}
+		// the type is that of the value, so the expression is evaluated and its side
+		// effects happen; its bindings are made in a child scope, to not define them
+		// in the session
+		switch value := r.eval.Eval(prog, object.NewEnvironment(r.env)).(type) {
+		case nil:
+			fmt.Fprintln(r.Out, object.NIL_OBJ)
+		case *object.Error:
+			fmt.Fprintln(r.Out, strings.TrimSpace(value.Message))
+		default:
+			fmt.Fprintln(r.Out, value.Type())
 		}
+	default:
+		fmt.Fprintf(r.Out, "unknown command %s, type :help for the list of commands\n", name)
 	}
+	return false
 }
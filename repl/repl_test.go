@@ -0,0 +1,205 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"ede/ast"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/object"
+	"ede/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func session(input string) (string, int) {
+	out := new(bytes.Buffer)
+	code := (&REPL{In: strings.NewReader(input), Out: out}).Run()
+	return out.String(), code
+}
+
+func TestREPL(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.ede")
+	if err := os.WriteFile(file, []byte("let double = func(n) {\n    return n * 2\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"expression", "1 + 2\n", ">> 3\n>> "},
+		{"let is not printed", "let a = 2\na * 3\n", ">> >> 6\n>> "},
+		{"multi-line function", "let add = func(a, b) {\n    return a + b\n}\nadd(1, 2)\n", ">> .. .. >> 3\n>> "},
+		{"multi-line if", "if (true) {\n  println(\"yes\")\n}\n", ">> .. .. yes\n>> "},
+		{"multi-line raw string", "let s = `a\nb`\ns.length()\n", ">> .. >> 3\n>> "},
+		{"input of the program", "let name = input()\nbob\nname\n", ">> >> bob\n>> "},
+		{"parse error", "let = 1\n", ">> 1 error occurred:\n\t* \n\tError: expected token IDENT, got ="},
+		{"runtime error", "1 + \"a\"\n", ">> error: invalid infix operator"},
+		{"env", "let b = [1]\nlet a = 1\n:env\n", ">> >> >> a = 1\nb = [1]\n>> "},
+		{"type", "let a = 1.5\n:type a * 2\n:type b\n", ">> >> FLOAT\n>> NIL\n>> "},
+		{"type does not define", ":type let x = 1\nx\n", ">> NIL\n>> >> "},
+		{"type runs the expression", ":type println(\"x\")\n", ">> x\nNIL\n>> "},
+		{"ast", ":ast a\n", ">> Program 0:0\n  Statements[0]: ExpressionStmt 1:1\n    Expr: Identifier 1:1 Value=\"a\"\n>> "},
+		{"reset", "let a = 1\n:reset\n:env\n", ">> >> >> >> "},
+		{"load", ":load " + file + "\ndouble(4)\n", ">> >> 8\n>> "},
+		{"load error", ":load missing.ede\n", ">> open missing.ede: no such file or directory\n>> "},
+		{"unknown command", ":foo\n", ">> unknown command :foo, type :help for the list of commands\n>> "},
+		{"quit", ":quit\n1\n", ">> "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := session(tt.input)
+			if !strings.HasPrefix(out, tt.expected) {
+				t.Errorf("wrong output.\nexpected prefix=%q\ngot=            %q", tt.expected, out)
+			}
+		})
+	}
+
+	if _, code := session("import os\nos.exit(3)\n1\n"); code != 3 {
+		t.Errorf("wrong exit code. expected=3, got=%d", code)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"", false},
+		{"let a = 1", false},
+		{"let f = func(a) {", true},
+		{"let f = func(a) {\n  return a\n}", false},
+		{"let a = [1,", true},
+		{"println(", true},
+		{"let s = `a", true},
+		{"let s = `a\nb`", false},
+		{`let s = "{"`, false},
+		{"// {", false},
+		{"}", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) - expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	r := &REPL{eval: &evaluator.Evaluator{}, env: object.NewEnvironment(nil)}
+	r.eval.Eval(parseProgram(t, "import json\nlet name = \"a\"\nlet nums = [1]\nlet number = 1\n"), r.env)
+
+	tests := []struct {
+		text     string
+		expected string
+		wordLen  int
+	}{
+		{"nu", "number nums", 2},
+		{"println(na", "name", 2},
+		{"ret", "return", 3},
+		{"pri", "print printf println", 3},
+		{"json.", "lines parse pretty string", 0},
+		{"name.re", "repeat replace reverse", 2},
+		{"nums.p", "partition pop push", 1},
+		{"number.", "equal float string type", 0},
+		{`"a".l`, "last_index_of length lines lower", 1},
+		{":lo", "load", 2},
+		{"missing.", "", 0},
+	}
+
+	for _, tt := range tests {
+		candidates, wordLen := r.completions(tt.text)
+		if got := strings.Join(candidates, " "); got != tt.expected || wordLen != tt.wordLen {
+			t.Errorf("completions(%q) - expected=%q (%d), got=%q (%d)", tt.text, tt.expected, tt.wordLen, got, wordLen)
+		}
+	}
+}
+
+func TestEditor(t *testing.T) {
+	complete := func(text string) ([]string, int) {
+		word := text[len(strings.TrimRightFunc(text, isIdentChar)):]
+		var candidates []string
+		for _, name := range []string{"print", "println", "len"} {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name)
+			}
+		}
+		return candidates, len(word)
+	}
+	newEditor := func(keys string, h *history) *editor {
+		return &editor{in: bufio.NewReader(strings.NewReader(keys)), out: new(bytes.Buffer), history: h, complete: complete}
+	}
+
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"typing", "abc\r", "abc"},
+		{"backspace", "abd\x7fc\r", "abc"},
+		{"left and insert", "ac\x1b[Db\r", "abc"},
+		{"home and end", "bc\x01a\x05d\r", "abcd"},
+		{"delete", "abc\x01\x1b[3~\r", "bc"},
+		{"kill", "abc\x1b[D\x1b[D\x0b\r", "a"},
+		{"history", "\x1b[A\x1b[A\r", "let a = 1"},
+		{"history down to the draft", "x\x1b[A\x1b[B\r", "x"},
+		{"completion of the common prefix", "pr\tl\t(1)\r", "println(1)"},
+		{"unique completion", "le\t\r", "len"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &history{lines: []string{"let a = 1", "println(a)"}}
+			line, err := newEditor(tt.keys, h).readLine(">> ")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if line != tt.expected {
+				t.Errorf("wrong line. expected=%q, got=%q", tt.expected, line)
+			}
+		})
+	}
+
+	if _, err := newEditor("abc\x03", &history{}).readLine(">> "); err != errInterrupt {
+		t.Errorf("expected an interrupt, got %v", err)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(file, []byte("let a = 1\nprintln(a)\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h, err := loadHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := h.prev(""); line != "println(a)" {
+		t.Errorf("expected the last line of the file, got %q", line)
+	}
+
+	h.add("let b = 2")
+	h.add("let b = 2") // repeated lines are skipped
+	h.add(" ")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "let a = 1\nprintln(a)\nlet b = 2\n"; string(data) != expected {
+		t.Errorf("wrong history file. expected=%q, got=%q", expected, data)
+	}
+}
+
+func parseProgram(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	prog := parser.New(lexer.New(src)).Parse()
+	if prog.ParseErrors != nil {
+		t.Fatal(prog.ParseErrors)
+	}
+	return prog
+}
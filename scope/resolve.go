@@ -0,0 +1,261 @@
+package scope
+
+import (
+	"ede/ast"
+	"ede/token"
+)
+
+// Info is the result of resolving a program
+type Info struct {
+	Scope      *Scope                             // the scope of the program
+	Defs       map[*ast.Identifier]*Object        // the declaring identifiers
+	Uses       map[*ast.Identifier]*Object        // the identifiers referring to a declaration
+	Unresolved []*ast.Identifier                  // the identifiers without declaration, e.g. builtins
+	Methods    map[*ast.Identifier]ast.Expression // the method names, with the object they are called on
+	Assigned   []*ast.Identifier                  // the identifiers reassigned, e.g. a in a = 1
+	Calls      []*ast.CallExpression              // the function calls, not including the method calls
+}
+
+// Resolve resolves the identifiers of the program. The program should have no parse errors.
+func Resolve(prog *ast.Program) *Info {
+	r := &resolver{info: &Info{
+		Defs:    make(map[*ast.Identifier]*Object),
+		Uses:    make(map[*ast.Identifier]*Object),
+		Methods: make(map[*ast.Identifier]ast.Expression),
+	}}
+	r.info.Scope = newScope(nil, prog, token.Pos{Line: 1, Column: 1}, token.Pos{})
+	r.scope = r.info.Scope
+	r.stmts(prog.Statements)
+
+	// function bodies run once the enclosing scopes are complete
+	for len(r.funcs) > 0 {
+		fn := r.funcs[0]
+		r.funcs = r.funcs[1:]
+		r.scope = fn.scope
+		r.function(fn.lit)
+	}
+	return r.info
+}
+
+// ObjectAt returns the identifier at the position, and its declaration. The object is
+// nil if the identifier is not declared in the program.
+func (info *Info) ObjectAt(pos token.Pos) (*ast.Identifier, *Object) {
+	for ident, obj := range info.Defs {
+		if covers(ident, pos) {
+			return ident, obj
+		}
+	}
+	for ident, obj := range info.Uses {
+		if covers(ident, pos) {
+			return ident, obj
+		}
+	}
+	for _, ident := range info.Unresolved {
+		if covers(ident, pos) {
+			return ident, nil
+		}
+	}
+	return nil, nil
+}
+
+// MethodAt returns the method name at the position, and the object it is called on
+func (info *Info) MethodAt(pos token.Pos) (*ast.Identifier, ast.Expression) {
+	for ident, obj := range info.Methods {
+		if covers(ident, pos) {
+			return ident, obj
+		}
+	}
+	return nil, nil
+}
+
+// References returns the identifiers referring to the object, in the order of the source
+func (info *Info) References(obj *Object) []*ast.Identifier {
+	var refs []*ast.Identifier
+	for ident, use := range info.Uses {
+		if use == obj {
+			refs = append(refs, ident)
+		}
+	}
+	sortIdents(refs)
+	return refs
+}
+
+// covers reports whether the position is on the identifier
+func covers(ident *ast.Identifier, pos token.Pos) bool {
+	start := ident.Pos()
+	return pos.Line == start.Line && pos.Column >= start.Column && pos.Column <= start.Column+len(ident.Value)
+}
+
+func sortIdents(idents []*ast.Identifier) {
+	for i := 1; i < len(idents); i++ {
+		for j := i; j > 0 && Before(idents[j].Pos(), idents[j-1].Pos()); j-- {
+			idents[j], idents[j-1] = idents[j-1], idents[j]
+		}
+	}
+}
+
+type resolver struct {
+	info  *Info
+	scope *Scope
+	funcs []pendingFunc
+}
+
+// pendingFunc is a function literal whose body is resolved after its enclosing scope
+type pendingFunc struct {
+	lit   *ast.FunctionLiteral
+	scope *Scope
+}
+
+func (r *resolver) open(node ast.Node, start, end token.Pos) {
+	r.scope = newScope(r.scope, node, start, end)
+}
+
+func (r *resolver) close() {
+	r.scope = r.scope.Parent
+}
+
+func (r *resolver) declare(obj *Object) {
+	r.scope.declare(obj)
+	if obj.Ident != nil {
+		r.info.Defs[obj.Ident] = obj
+	}
+}
+
+func (r *resolver) use(ident *ast.Identifier) {
+	if obj := r.scope.Lookup(ident.Value); obj != nil {
+		r.info.Uses[ident] = obj
+		return
+	}
+	r.info.Unresolved = append(r.info.Unresolved, ident)
+}
+
+func (r *resolver) stmts(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		r.stmt(stmt)
+	}
+}
+
+func (r *resolver) stmt(stmt ast.Statement) {
+	if stmt != nil {
+		ast.Walk(r, stmt)
+	}
+}
+
+func (r *resolver) block(block *ast.BlockStmt) {
+	r.open(block, block.Pos(), block.Rbrace)
+	r.stmts(block.Statements)
+	r.close()
+}
+
+func (r *resolver) exprs(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		r.expr(expr)
+	}
+}
+
+func (r *resolver) expr(expr ast.Expression) {
+	if expr != nil {
+		ast.Walk(r, expr)
+	}
+}
+
+// Visit resolves the node. The nodes which declare names, open scopes or are not
+// resolved as expressions are handled here; the children of the others are walked.
+func (r *resolver) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case *ast.LetStmt:
+		r.expr(node.Expr)
+		kind := Var
+		if _, ok := node.Expr.(*ast.FunctionLiteral); ok {
+			kind = Func
+		}
+		r.declare(&Object{Name: node.Name.Value, Kind: kind, Ident: node.Name, Pos: node.Name.Pos(), Decl: node, Value: node.Expr})
+		return nil
+	case *ast.BlockStmt:
+		r.block(node)
+		return nil
+	case *ast.IfStmt:
+		r.expr(node.Consequence.Condition)
+		r.stmt(node.Consequence.Statement)
+		for _, alt := range node.Alternatives {
+			r.expr(alt.Condition)
+			// the branches after the first run in the scope of the if statement
+			r.stmt(alt.Statement)
+		}
+		return nil
+	case *ast.ForLoopStmt:
+		r.expr(node.Boundary)
+		r.open(node, node.Pos(), node.Statement.Rbrace)
+		r.declare(&Object{Name: token.IndexIdentifier, Kind: Implicit, Pos: node.Pos(), Decl: node})
+		r.declare(&Object{Name: node.Variable.Value, Kind: LoopVar, Ident: node.Variable, Pos: node.Variable.Pos(), Decl: node})
+		r.stmts(node.Statement.Statements)
+		r.close()
+		return nil
+	case *ast.ImportStmt:
+		r.declare(&Object{Name: node.Value, Kind: Import, Pos: node.Pos(), Decl: node})
+		return nil
+	case *ast.Identifier:
+		r.use(node)
+		return nil
+	case *ast.ReassignmentStmt:
+		if ident, ok := node.Name.(*ast.Identifier); ok {
+			r.info.Assigned = append(r.info.Assigned, ident)
+		}
+	case *ast.CallExpression:
+		r.info.Calls = append(r.info.Calls, node)
+	case *ast.ObjectMethodExpression:
+		r.expr(node.Object)
+		r.method(node.Method, node.Object)
+		return nil
+	case *ast.FunctionLiteral:
+		r.funcs = append(r.funcs, pendingFunc{lit: node, scope: r.scope})
+		return nil
+	case *ast.MacroLiteral:
+		return nil // expanded before the program runs
+	case *ast.MatchExpression:
+		r.expr(node.Expression)
+		r.open(node, node.Pos(), node.Rbrace)
+		r.declare(&Object{Name: token.ErrorIdentifier, Kind: Implicit, Pos: node.Pos(), Decl: node})
+		for _, matchCase := range node.Cases {
+			r.expr(matchCase.Pattern)
+			r.expr(matchCase.Output)
+		}
+		r.expr(node.Default)
+		r.close()
+		return nil
+	}
+	return r
+}
+
+// method resolves the method part of obj.method(args), where the method name is not
+// a variable, but the arguments and the chained expressions are
+func (r *resolver) method(method ast.Expression, obj ast.Expression) {
+	switch method := method.(type) {
+	case *ast.Identifier:
+		r.info.Methods[method] = obj
+	case *ast.CallExpression:
+		r.method(method.Function, obj)
+		r.exprs(method.Args)
+	case *ast.IndexExpression:
+		r.method(method.Left, obj)
+		r.expr(method.Index)
+	case *ast.PostfixExpression:
+		r.method(method.Left, obj)
+	case *ast.ObjectMethodExpression:
+		r.method(method.Object, obj)
+		r.method(method.Method, method.Object)
+	default:
+		r.expr(method)
+	}
+}
+
+func (r *resolver) function(lit *ast.FunctionLiteral) {
+	r.open(lit, lit.Pos(), lit.Body.Rbrace)
+	for _, param := range lit.Params {
+		r.declare(&Object{Name: param.Value, Kind: Param, Ident: param, Pos: param.Pos(), Decl: lit})
+	}
+	r.block(lit.Body)
+	r.close()
+}
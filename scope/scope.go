@@ -0,0 +1,133 @@
+// Package scope resolves the identifiers of a program to their declarations.
+//
+// The scopes follow the environments created by the evaluator: the program, blocks,
+// for loops, function calls and match expressions each open a scope. Names are
+// declared in order, except in function bodies, which run after the enclosing scope
+// is complete and so see the names declared after the function.
+package scope
+
+import (
+	"ede/ast"
+	"ede/token"
+)
+
+// Kind is the kind of a declared name
+type Kind int
+
+const (
+	Var      Kind = iota // a let binding
+	Func                 // a let binding of a function literal
+	Param                // a function parameter
+	LoopVar              // the variable of a for loop
+	Import               // an imported module
+	Implicit             // a name bound by the language, i.e. index in loops and error in match
+)
+
+var kindNames = [...]string{
+	Var:      "variable",
+	Func:     "function",
+	Param:    "parameter",
+	LoopVar:  "loop variable",
+	Import:   "module",
+	Implicit: "implicit",
+}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Object is a declared name
+type Object struct {
+	Name  string
+	Kind  Kind
+	Ident *ast.Identifier // the declaring identifier, nil for imports and implicit names
+	Pos   token.Pos       // the position of the declaration
+	Decl  ast.Node        // the declaring node, e.g. a *ast.LetStmt
+	Value ast.Expression  // the value bound by a let, if any
+	Scope *Scope          // the scope of the declaration
+}
+
+// Scope is a region of the program where names are declared
+type Scope struct {
+	Parent   *Scope
+	Children []*Scope
+	Node     ast.Node  // the node opening the scope
+	Start    token.Pos // the start of the scope, inclusive
+	End      token.Pos // the end of the scope, inclusive. The zero position for the program
+
+	names map[string]*Object // the latest declaration of each name
+	decls []*Object
+}
+
+func newScope(parent *Scope, node ast.Node, start, end token.Pos) *Scope {
+	s := &Scope{Parent: parent, Node: node, Start: start, End: end, names: make(map[string]*Object)}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	return s
+}
+
+// Lookup returns the declaration of the name in the scope or its parents, or nil
+func (s *Scope) Lookup(name string) *Object {
+	for ; s != nil; s = s.Parent {
+		if obj, ok := s.names[name]; ok {
+			return obj
+		}
+	}
+	return nil
+}
+
+// Objects returns the declarations of the scope, in order
+func (s *Scope) Objects() []*Object { return s.decls }
+
+// Contains reports whether the position is in the scope
+func (s *Scope) Contains(pos token.Pos) bool {
+	if s.End == (token.Pos{}) {
+		return !Before(pos, s.Start)
+	}
+	return !Before(pos, s.Start) && !Before(s.End, pos)
+}
+
+// Innermost returns the innermost scope containing the position
+func (s *Scope) Innermost(pos token.Pos) *Scope {
+	for _, child := range s.Children {
+		if child.Contains(pos) {
+			return child.Innermost(pos)
+		}
+	}
+	return s
+}
+
+// Visible returns the names visible at the position in the scope, innermost first.
+// Names declared after the position are only visible from function bodies.
+func (s *Scope) Visible(pos token.Pos) []*Object {
+	var objs []*Object
+	seen := map[string]bool{}
+	inFunc := false
+	for ; s != nil; s = s.Parent {
+		for i := len(s.decls) - 1; i >= 0; i-- {
+			obj := s.decls[i]
+			if seen[obj.Name] || (!inFunc && Before(pos, obj.Pos)) {
+				continue
+			}
+			seen[obj.Name] = true
+			objs = append(objs, obj)
+		}
+		if _, ok := s.Node.(*ast.FunctionLiteral); ok {
+			inFunc = true
+		}
+	}
+	return objs
+}
+
+func (s *Scope) declare(obj *Object) {
+	obj.Scope = s
+	s.names[obj.Name] = obj
+	s.decls = append(s.decls, obj)
+}
+
+// Before reports whether the position a is before b
+func Before(a, b token.Pos) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -0,0 +1,146 @@
+package scope
+
+import (
+	"ede/ast"
+	"ede/object"
+	"ede/token"
+)
+
+// methodTypes are the types of values returned by methods, whatever their object
+var methodTypes = map[string]object.Type{
+	"length":   object.INT_OBJ,
+	"int":      object.INT_OBJ,
+	"float":    object.FLOAT_OBJ,
+	"string":   object.STRING_OBJ,
+	"join":     object.STRING_OBJ,
+	"contains": object.BOOLEAN_OBJ,
+	"split":    object.ARRAY_OBJ,
+	"keys":     object.ARRAY_OBJ,
+	"items":    object.ARRAY_OBJ,
+	"map":      object.ARRAY_OBJ,
+	"filter":   object.ARRAY_OBJ,
+}
+
+// builtinTypes are the types of values returned by builtin functions
+var builtinTypes = map[string]object.Type{
+	"len":      object.INT_OBJ,
+	"sprintf":  object.STRING_OBJ,
+	"input":    object.STRING_OBJ,
+	"readline": object.STRING_OBJ,
+}
+
+// TypeOf infers the type of the value of the expression, or returns an empty type
+// if it cannot be known before running the program
+func (info *Info) TypeOf(expr ast.Expression) object.Type {
+	return info.typeOf(expr, map[*Object]bool{})
+}
+
+// ObjectType infers the type of the value bound to the name
+func (info *Info) ObjectType(obj *Object) object.Type {
+	return info.objectType(obj, map[*Object]bool{})
+}
+
+func (info *Info) objectType(obj *Object, seen map[*Object]bool) object.Type {
+	if seen[obj] {
+		return ""
+	}
+	seen[obj] = true
+
+	switch obj.Kind {
+	case Var, Func:
+		return info.typeOf(obj.Value, seen)
+	case Import:
+		return object.IMPORT_OBJ
+	case Implicit:
+		if obj.Name == token.IndexIdentifier {
+			return object.INT_OBJ
+		}
+		return object.ERROR_OBJ
+	case LoopVar:
+		if loop, ok := obj.Decl.(*ast.ForLoopStmt); ok {
+			if _, ok := loop.Boundary.(*ast.RangeArrayLiteral); ok {
+				return object.INT_OBJ
+			}
+		}
+	}
+	return ""
+}
+
+func (info *Info) typeOf(expr ast.Expression, seen map[*Object]bool) object.Type {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return object.INT_OBJ
+	case *ast.FloatLiteral:
+		return object.FLOAT_OBJ
+	case *ast.StringLiteral:
+		return object.STRING_OBJ
+	case *ast.BooleanLiteral:
+		return object.BOOLEAN_OBJ
+	case *ast.NilLiteral:
+		return object.NIL_OBJ
+	case *ast.ArrayLiteral, *ast.RangeArrayLiteral:
+		return object.ARRAY_OBJ
+	case *ast.HashLiteral:
+		return object.HASH_OBJ
+	case *ast.SetLiteral:
+		return object.SET_OBJ
+	case *ast.FunctionLiteral:
+		return object.FUNCTION_OBJ
+	case *ast.Identifier:
+		if obj := info.Uses[expr]; obj != nil {
+			return info.objectType(obj, seen)
+		}
+		if obj := info.Defs[expr]; obj != nil {
+			return info.objectType(obj, seen)
+		}
+	case *ast.PrefixExpression:
+		if expr.Operator == token.BANG {
+			return object.BOOLEAN_OBJ
+		}
+		return info.typeOf(expr.Right, seen)
+	case *ast.PostfixExpression:
+		return info.typeOf(expr.Left, seen)
+	case *ast.InfixExpression:
+		switch expr.Operator {
+		case token.EQ, token.NEQ, token.LT, token.GT, token.LTE, token.GTE, token.AND_AND, token.OR_OR:
+			return object.BOOLEAN_OBJ
+		}
+		left, right := info.typeOf(expr.Left, seen), info.typeOf(expr.Right, seen)
+		switch {
+		case left == object.STRING_OBJ && expr.Operator == token.PLUS:
+			return object.STRING_OBJ
+		case left == object.INT_OBJ && right == object.INT_OBJ:
+			return object.INT_OBJ
+		case isNumber(left) && isNumber(right):
+			return object.FLOAT_OBJ
+		}
+	case *ast.CallExpression:
+		if ident, ok := expr.Function.(*ast.Identifier); ok && info.Uses[ident] == nil {
+			return builtinTypes[ident.Value]
+		}
+	case *ast.ObjectMethodExpression:
+		name := methodName(expr.Method)
+		if name == "reverse" {
+			return info.typeOf(expr.Object, seen)
+		}
+		if info.typeOf(expr.Object, seen) != object.IMPORT_OBJ {
+			return methodTypes[name]
+		}
+	}
+	return ""
+}
+
+// methodName returns the name of the method called by obj.method(args)
+func methodName(method ast.Expression) string {
+	switch method := method.(type) {
+	case *ast.Identifier:
+		return method.Value
+	case *ast.CallExpression:
+		return methodName(method.Function)
+	}
+	return ""
+}
+
+func isNumber(typ object.Type) bool {
+	return typ == object.INT_OBJ || typ == object.FLOAT_OBJ
+}
@@ -0,0 +1,166 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Summary counts the results by status
+type Summary struct {
+	Passed, Failed, Skipped int
+	Elapsed                 time.Duration
+}
+
+// Summarize counts the results
+func Summarize(results []Result) Summary {
+	var s Summary
+	for _, r := range results {
+		switch r.Status {
+		case Pass:
+			s.Passed++
+		case Fail:
+			s.Failed++
+		case Skip:
+			s.Skipped++
+		}
+		s.Elapsed += r.Elapsed
+	}
+	return s
+}
+
+// WriteText writes a line per test, followed by the position and message of the
+// failed and skipped tests, the output of the failed ones, and a summary
+func WriteText(w io.Writer, results []Result) {
+	for _, r := range results {
+		fmt.Fprintf(w, "--- %s: %s (%.2fs)\n", r.Status, r.Name, r.Elapsed.Seconds())
+		if r.Status == Pass {
+			continue
+		}
+		if r.Message != "" {
+			fmt.Fprintf(w, "    %s: %s\n", r.Location(), indent(r.Message, "    "))
+		}
+		if r.Status == Fail && r.Output != "" {
+			fmt.Fprintf(w, "    output:\n        %s\n", indent(strings.TrimRight(r.Output, "\n"), "        "))
+		}
+	}
+
+	s := Summarize(results)
+	status := "ok"
+	if s.Failed > 0 {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "%s\t%d passed, %d failed, %d skipped (%.2fs)\n", status, s.Passed, s.Failed, s.Skipped, s.Elapsed.Seconds())
+}
+
+// WriteTAP writes the results in the Test Anything Protocol, version 13
+func WriteTAP(w io.Writer, results []Result) {
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", len(results))
+	for i, r := range results {
+		switch r.Status {
+		case Pass:
+			fmt.Fprintf(w, "ok %d - %s %s\n", i+1, r.File, r.Name)
+		case Skip:
+			fmt.Fprintf(w, "ok %d - %s %s # SKIP %s\n", i+1, r.File, r.Name, firstLine(r.Message))
+		case Fail:
+			fmt.Fprintf(w, "not ok %d - %s %s\n", i+1, r.File, r.Name)
+			fmt.Fprintln(w, "  ---")
+			fmt.Fprintf(w, "  message: %q\n", r.Message)
+			fmt.Fprintf(w, "  at: %s\n", r.Location())
+			if r.Output != "" {
+				fmt.Fprintf(w, "  output: %q\n", r.Output)
+			}
+			fmt.Fprintln(w, "  ...")
+		}
+	}
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as JUnit XML, with a test suite per file
+func WriteJUnit(w io.Writer, results []Result) error {
+	s := Summarize(results)
+	doc := junitSuites{Tests: len(results), Failures: s.Failed, Skipped: s.Skipped, Time: seconds(s.Elapsed)}
+
+	var files []string
+	byFile := map[string][]Result{}
+	for _, r := range results {
+		if _, ok := byFile[r.File]; !ok {
+			files = append(files, r.File)
+		}
+		byFile[r.File] = append(byFile[r.File], r)
+	}
+	for _, file := range files {
+		fs := Summarize(byFile[file])
+		suite := junitSuite{Name: file, Tests: len(byFile[file]), Failures: fs.Failed, Skipped: fs.Skipped, Time: seconds(fs.Elapsed)}
+		for _, r := range byFile[file] {
+			c := junitCase{Name: r.Name, Classname: file, Time: seconds(r.Elapsed), SystemOut: r.Output}
+			switch r.Status {
+			case Fail:
+				c.Failure = &junitMessage{Message: firstLine(r.Message), Text: r.Location() + ": " + r.Message}
+			case Skip:
+				c.Skipped = &junitMessage{Message: r.Message}
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// indent indents the lines of s after the first one
+func indent(s, prefix string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
@@ -0,0 +1,214 @@
+// Package tester runs the tests of ede programs. The tests of a program are its
+// top-level functions named test_*, in files named *_test.ede. Each test is run
+// in isolation: the program is evaluated in a new environment before the test
+// function is called.
+package tester
+
+import (
+	"bytes"
+	"ede/ast"
+	"ede/evaluator"
+	"ede/lexer"
+	"ede/module"
+	"ede/object"
+	"ede/parser"
+	"ede/token"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a test
+type Status int
+
+const (
+	Pass Status = iota
+	Fail
+	Skip
+)
+
+func (s Status) String() string {
+	switch s {
+	case Fail:
+		return "FAIL"
+	case Skip:
+		return "SKIP"
+	}
+	return "PASS"
+}
+
+// Result is the result of a test
+type Result struct {
+	File   string
+	Name   string
+	Status Status
+	// Pos is where the test failed or was skipped, or the position of the test
+	// function if it is unknown
+	Pos     token.Pos
+	Message string
+	// Output is what the test printed
+	Output  string
+	Elapsed time.Duration
+}
+
+// Location returns the position of the result as file:line:column
+func (r Result) Location() string {
+	return fmt.Sprintf("%s:%d:%d", r.File, r.Pos.Line, r.Pos.Column)
+}
+
+// Discover returns the test files of the paths, sorted. The directories are
+// searched recursively, the files are returned even if they are not named
+// *_test.ede.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), "_test.ede") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Tests returns the declarations of the tests of the program, in the order
+// they are declared
+func Tests(prog *ast.Program) []*ast.LetStmt {
+	var tests []*ast.LetStmt
+	for _, stmt := range prog.Statements {
+		let, ok := stmt.(*ast.LetStmt)
+		if !ok || !strings.HasPrefix(let.Name.Value, "test_") {
+			continue
+		}
+		if _, ok := let.Expr.(*ast.FunctionLiteral); ok {
+			tests = append(tests, let)
+		}
+	}
+	return tests
+}
+
+// Options are the options of RunFile
+type Options struct {
+	// Run selects the tests whose name matches, all of them if nil
+	Run *regexp.Regexp
+	// Setup is called with the evaluator of each test before the program is
+	// evaluated, e.g. to add hooks
+	Setup func(e *evaluator.Evaluator, file, src string, prog *ast.Program)
+}
+
+// RunFile runs the tests of the file. An error is returned if the file cannot be
+// read or parsed.
+func RunFile(file string, opts Options) ([]Result, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	prog := parser.New(lexer.New(string(src))).Parse()
+	if prog.ParseErrors != nil {
+		return nil, fmt.Errorf("%s: %s", file, prog.ParseErrors)
+	}
+	if err := evaluator.Expand(prog); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, strings.TrimSpace(err.Message))
+	}
+
+	var results []Result
+	for _, test := range Tests(prog) {
+		if opts.Run != nil && !opts.Run.MatchString(test.Name.Value) {
+			continue
+		}
+		results = append(results, runTest(file, string(src), prog, test, opts))
+	}
+	return results, nil
+}
+
+// runTest evaluates the program, then calls the test function
+func runTest(file, src string, prog *ast.Program, test *ast.LetStmt, opts Options) Result {
+	start := time.Now()
+	out := new(bytes.Buffer)
+	e := &evaluator.Evaluator{Stdin: strings.NewReader(""), Stdout: out, Stderr: out, Args: []string{file}, File: file}
+	env := object.NewEnvironment(nil)
+	last := &lastStatement{}
+	e.AddHook(last)
+	if opts.Setup != nil {
+		opts.Setup(e, file, src, prog)
+	}
+
+	result := e.Eval(prog, env)
+	if !isFailure(result) {
+		fn, _ := env.Get(test.Name.Value)
+		result = apply(e, fn)
+	}
+
+	r := Result{File: file, Name: test.Name.Value, Pos: test.Pos(), Output: out.String(), Elapsed: time.Since(start)}
+	switch result := result.(type) {
+	case *object.Exit:
+		r.Status, r.Message = Fail, fmt.Sprintf("exited with code %d", result.Code)
+	case *object.Error:
+		r.Status, r.Message = Fail, strings.TrimSpace(result.Message)
+		if mod, ok := e.Modules()["testing"].(*module.TestingModule); ok {
+			if reason, skipped := mod.Skipped(result); skipped {
+				r.Status, r.Message = Skip, reason
+			}
+		}
+		if result.Pos != (token.Pos{}) {
+			r.Pos = result.Pos
+		} else if last.stmt != nil {
+			r.Pos = last.stmt.Pos()
+		}
+	}
+	return r
+}
+
+// lastStatement records the last statement evaluated, the position of the errors
+// that have none
+type lastStatement struct {
+	evaluator.NopHook
+	stmt ast.Statement
+}
+
+func (l *lastStatement) Statement(stmt ast.Statement, env *object.Environment) {
+	l.stmt = stmt
+}
+
+// apply calls the test function, recovering a call to exit
+func apply(e *evaluator.Evaluator, fn object.Object) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(*object.Exit)
+			if !ok {
+				panic(r)
+			}
+			result = exit
+		}
+	}()
+	return e.Apply(fn)
+}
+
+func isFailure(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Error, *object.Exit:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package tester
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const src = `import testing
+
+let add = func(a, b) {
+    return a + b
+}
+
+let test_add = func() {
+    testing.assert_eq(add(1, 2), 3)
+}
+
+let test_fail = func() {
+    println("adding")
+    testing.assert_eq(add(1, 2), 4)
+}
+
+let test_skip = func() {
+    testing.skip("not ready")
+}
+
+let test_error = func() {
+    let a = 1 + "a"
+}
+
+let helper = func() {}
+`
+
+func writeTests(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"math_test.ede":      src,
+		"sub/other_test.ede": "let test_other = func() {}\n",
+		"main.ede":           "println(1)\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeTests(t)
+	files, err := Discover([]string{dir, filepath.Join(dir, "main.ede")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "main.ede"), filepath.Join(dir, "math_test.ede"), filepath.Join(dir, "sub/other_test.ede")}
+	if strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong files.\nexpected=%v\ngot=     %v", expected, files)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	file := filepath.Join(writeTests(t), "math_test.ede")
+	results, err := RunFile(file, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name     string
+		status   Status
+		location string
+		message  string
+		output   string
+	}{
+		{"test_add", Pass, "7:1", "", ""},
+		{"test_fail", Fail, "13:13", "assert_eq failed: got 3, want 4", "adding\n"},
+		{"test_skip", Skip, "17:13", "not ready", ""},
+		{"test_error", Fail, "21:5", "error: invalid infix operator + for (1) and (a)", ""},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for i, exp := range expected {
+		r := results[i]
+		if r.Name != exp.name || r.Status != exp.status || r.Message != exp.message || r.Output != exp.output {
+			t.Errorf("wrong result %d. expected=%+v, got=%+v", i, exp, r)
+		}
+		if r.Location() != file+":"+exp.location {
+			t.Errorf("%s - wrong location. expected=%s, got=%s", exp.name, exp.location, r.Location())
+		}
+	}
+
+	results, err = RunFile(file, Options{Run: regexp.MustCompile("^test_s")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "test_skip" {
+		t.Errorf("expected only test_skip to run, got %+v", results)
+	}
+}
+
+func TestReports(t *testing.T) {
+	results := []Result{
+		{File: "a_test.ede", Name: "test_a", Status: Pass},
+		{File: "a_test.ede", Name: "test_b", Status: Fail, Message: "assertion failed", Output: "out\n"},
+		{File: "b_test.ede", Name: "test_c", Status: Skip, Message: "later"},
+	}
+	results[1].Pos.Line, results[1].Pos.Column = 3, 5
+
+	tests := []struct {
+		name     string
+		write    func(*bytes.Buffer)
+		expected string
+	}{
+		{"text", func(b *bytes.Buffer) { WriteText(b, results) }, `--- PASS: test_a (0.00s)
+--- FAIL: test_b (0.00s)
+    a_test.ede:3:5: assertion failed
+    output:
+        out
+--- SKIP: test_c (0.00s)
+    b_test.ede:0:0: later
+FAIL	1 passed, 1 failed, 1 skipped (0.00s)
+`},
+		{"tap", func(b *bytes.Buffer) { WriteTAP(b, results) }, `TAP version 13
+1..3
+ok 1 - a_test.ede test_a
+not ok 2 - a_test.ede test_b
+  ---
+  message: "assertion failed"
+  at: a_test.ede:3:5
+  output: "out\n"
+  ...
+ok 3 - b_test.ede test_c # SKIP later
+`},
+		{"junit", func(b *bytes.Buffer) { WriteJUnit(b, results) }, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" skipped="1" time="0.000">
+  <testsuite name="a_test.ede" tests="2" failures="1" skipped="0" time="0.000">
+    <testcase name="test_a" classname="a_test.ede" time="0.000"></testcase>
+    <testcase name="test_b" classname="a_test.ede" time="0.000">
+      <failure message="assertion failed">a_test.ede:3:5: assertion failed</failure>
+      <system-out>out&#xA;</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="b_test.ede" tests="1" failures="0" skipped="1" time="0.000">
+    <testcase name="test_c" classname="b_test.ede" time="0.000">
+      <skipped message="later"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			tt.write(out)
+			if out.String() != tt.expected {
+				t.Errorf("wrong report.\nexpected=%s\ngot=     %s", tt.expected, out)
+			}
+		})
+	}
+}
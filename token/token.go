@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 type Token struct {
@@ -65,6 +67,7 @@ const (
 
 	// Keywords
 	FUNCTION    = "FUNCTION"
+	MACRO       = "MACRO"
 	EXTEND      = "EXTEND"
 	STRUCT      = "STRUCT"
 	STRUCT_TYPE = "STRUCT_TYPE"
@@ -93,6 +96,7 @@ var (
 
 var keywords = map[string]TokenType{
 	"func":          FUNCTION,
+	"macro":         MACRO,
 	"struct":        STRUCT,
 	"let":           LET,
 	"if":            IF,
@@ -123,6 +127,18 @@ func LookupIdent(ident string) TokenType {
 	return IDENT
 }
 
+// Keywords returns the sorted keywords of the language
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name, tok := range keywords {
+		if tok != IDENT {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func IsReservedKeyword(ident string) bool {
 	_, isReserved := keywords[ident]
 	return isReserved